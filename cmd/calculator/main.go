@@ -1,7 +1,11 @@
 package main
 
-import "fmt"
+import (
+	"os"
+
+	"calculator/internal/terminal"
+)
 
 func main() {
-	fmt.Println("Calculator application initialized")
+	os.Exit(terminal.Run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
 }
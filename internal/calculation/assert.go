@@ -0,0 +1,78 @@
+package calculation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// AssertionError reports an assertion whose expression was false
+type AssertionError struct {
+	Expression string
+	// Operands holds the two sides when the assertion was a comparison, as
+	// displayed by the engine
+	Operands []string
+}
+
+func (e *AssertionError) Error() string {
+	if len(e.Operands) == 2 {
+		return fmt.Sprintf("assertion failed: %s (got %s and %s)", e.Expression, e.Operands[0], e.Operands[1])
+	}
+	return "assertion failed: " + e.Expression
+}
+
+// Assert evaluates an expression that must be true, such as "total ~= 1234.56".
+// It returns nil when it is, *AssertionError when it is false and any other
+// error when it cannot be evaluated or is not true or false
+func (ce *CalculationEngine) Assert(expression string, vars map[string]Value) error {
+	program, err := ce.compile(expression, vars)
+	if err != nil {
+		return err
+	}
+	ev := &evaluator{engine: ce, vars: vars, ctx: context.Background()}
+	return ev.assert(program.tree, strings.TrimSpace(expression))
+}
+
+// assert evaluates an assertion. The operands of a comparison are kept so a
+// failure can show what was compared
+func (ev *evaluator) assert(tree node, expression string) error {
+	var result Value
+	var operands []string
+	if b, ok := tree.(binaryNode); ok && isComparison(b.op) {
+		left, err := ev.eval(b.left)
+		if err != nil {
+			return err
+		}
+		right, err := ev.eval(b.right)
+		if err != nil {
+			return err
+		}
+		if result, err = ev.applyBinary(b.op, left, right); err != nil {
+			return err
+		}
+		operands = []string{ev.engine.FormatResult(left), ev.engine.FormatResult(right)}
+	} else {
+		var err error
+		if result, err = ev.eval(tree); err != nil {
+			return err
+		}
+	}
+
+	ok, isBool := result.(Bool)
+	if !isBool {
+		return fmt.Errorf("assert expects true or false, got %s", result.Kind())
+	}
+	if !ok.Value {
+		return &AssertionError{Expression: expression, Operands: operands}
+	}
+	return nil
+}
+
+// isComparison reports whether op is one of the built-in comparisons
+func isComparison(op string) bool {
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=", "~=":
+		return true
+	}
+	return false
+}
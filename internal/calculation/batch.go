@@ -0,0 +1,140 @@
+package calculation
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// BatchResult is the outcome of one expression evaluated by CalculateBatch
+type BatchResult struct {
+	Expression string
+	Value      Value
+	Err        error
+	Duration   time.Duration
+}
+
+// BatchStats summarises the results of a batch
+type BatchStats struct {
+	Total     int
+	Succeeded int
+	Failed    int
+	// Cancelled counts expressions that were not evaluated because the
+	// context was done
+	Cancelled int
+	// Busy is the evaluation time summed over all expressions; with several
+	// workers it exceeds the wall-clock time of the batch
+	Busy time.Duration
+	// Slowest is the longest time spent on a single expression
+	Slowest time.Duration
+}
+
+// BatchOption configures a CalculateBatch call
+type BatchOption func(*batchSettings)
+
+// batchSettings collects the options given to CalculateBatch
+type batchSettings struct {
+	workers int
+	vars    map[string]Value
+}
+
+// WithWorkers bounds the number of expressions evaluated at once. The default
+// is runtime.GOMAXPROCS(0)
+func WithWorkers(n int) BatchOption {
+	return func(s *batchSettings) {
+		s.workers = n
+	}
+}
+
+// WithBatchVariables makes vars available to every expression in the batch.
+// The map is shared by the workers and must not be modified during the call
+func WithBatchVariables(vars map[string]Value) BatchOption {
+	return func(s *batchSettings) {
+		s.vars = vars
+	}
+}
+
+// CalculateBatch evaluates expressions concurrently on a bounded pool of
+// goroutines and returns one result per expression, in input order. The
+// engine holds no mutable state, so every worker shares it. When ctx is done
+// no further expressions are started and those left over get ctx.Err() as
+// their error; long-running evaluations already under way stop the same way
+func (ce *CalculationEngine) CalculateBatch(ctx context.Context, expressions []string, opts ...BatchOption) []BatchResult {
+	settings := batchSettings{workers: runtime.GOMAXPROCS(0)}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	if settings.workers < 1 {
+		settings.workers = 1
+	}
+	if settings.workers > len(expressions) {
+		settings.workers = len(expressions)
+	}
+
+	results := make([]BatchResult, len(expressions))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < settings.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				start := time.Now()
+				value, err := ce.evaluateContext(ctx, expressions[i], settings.vars)
+				results[i] = BatchResult{Expression: expressions[i], Value: value, Err: err, Duration: time.Since(start)}
+			}
+		}()
+	}
+
+	next := 0
+feed:
+	for ; next < len(expressions) && ctx.Err() == nil; next++ {
+		select {
+		case indexes <- next:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	for i := next; i < len(expressions); i++ {
+		results[i] = BatchResult{Expression: expressions[i], Err: ctx.Err()}
+	}
+	return results
+}
+
+// SummarizeBatch computes aggregate statistics for the results of CalculateBatch
+func SummarizeBatch(results []BatchResult) BatchStats {
+	stats := BatchStats{Total: len(results)}
+	for _, r := range results {
+		switch {
+		case r.Err == nil:
+			stats.Succeeded++
+		case errors.Is(r.Err, context.Canceled) || errors.Is(r.Err, context.DeadlineExceeded):
+			stats.Cancelled++
+		default:
+			stats.Failed++
+		}
+		stats.Busy += r.Duration
+		if r.Duration > stats.Slowest {
+			stats.Slowest = r.Duration
+		}
+	}
+	return stats
+}
+
+// evaluateContext compiles and evaluates one expression, stopping when ctx is
+// done, going through the engine's cache if it has one
+func (ce *CalculationEngine) evaluateContext(ctx context.Context, expression string, vars map[string]Value) (Value, error) {
+	if ce.cache != nil {
+		return ce.cache.evaluate(ctx, ce, expression, vars)
+	}
+	program, err := ce.compile(expression, vars)
+	if err != nil {
+		return nil, err
+	}
+	return program.EvalContext(ctx, vars)
+}
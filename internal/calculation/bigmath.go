@@ -0,0 +1,238 @@
+package calculation
+
+import (
+	"errors"
+	"math/big"
+)
+
+// precisionBits is the working precision used for all big.Float values
+// Source: docs/architecture/tech-stack.md - math/big for precision
+const precisionBits = 100
+
+// newFloat returns a zero big.Float at the engine's working precision
+func newFloat() *big.Float {
+	return new(big.Float).SetPrec(precisionBits)
+}
+
+// floatFromInt returns n as a big.Float at the engine's working precision
+func floatFromInt(n int64) *big.Float {
+	return newFloat().SetInt64(n)
+}
+
+// bigSqrt returns the square root of x, treating rounding noise below zero as zero
+func bigSqrt(x *big.Float) *big.Float {
+	if x.Sign() <= 0 {
+		return newFloat()
+	}
+	return newFloat().Sqrt(x)
+}
+
+// bigHypot returns sqrt(a*a + b*b) without leaving big.Float precision
+func bigHypot(a, b *big.Float) *big.Float {
+	aa := newFloat().Mul(a, a)
+	bb := newFloat().Mul(b, b)
+	return bigSqrt(aa.Add(aa, bb))
+}
+
+// bigAtan computes the arctangent of x using argument halving followed by
+// the Taylor series, which converges quickly once |x| is small
+func bigAtan(x *big.Float) *big.Float {
+	if x.Sign() == 0 {
+		return newFloat()
+	}
+
+	// atan(x) = 2 * atan(x / (1 + sqrt(1 + x^2)))
+	reduced := newFloat().Set(x)
+	doublings := 0
+	limit := new(big.Float).SetFloat64(0.01)
+	one := floatFromInt(1)
+	for new(big.Float).Abs(reduced).Cmp(limit) > 0 {
+		sq := newFloat().Mul(reduced, reduced)
+		denom := bigSqrt(sq.Add(sq, one))
+		denom.Add(denom, one)
+		reduced.Quo(reduced, denom)
+		doublings++
+	}
+
+	// atan(x) = x - x^3/3 + x^5/5 - ...
+	sum := newFloat().Set(reduced)
+	power := newFloat().Set(reduced)
+	xSquared := newFloat().Mul(reduced, reduced)
+	epsilon := new(big.Float).SetMantExp(big.NewFloat(1), -precisionBits)
+	for n := int64(3); ; n += 2 {
+		power.Mul(power, xSquared)
+		power.Neg(power)
+		term := newFloat().Quo(power, floatFromInt(n))
+		if new(big.Float).Abs(term).Cmp(epsilon) < 0 {
+			break
+		}
+		sum.Add(sum, term)
+	}
+
+	return sum.SetMantExp(sum, doublings)
+}
+
+// bigPi returns pi at the engine's working precision
+func bigPi() *big.Float {
+	pi := bigAtan(floatFromInt(1))
+	return pi.Mul(pi, floatFromInt(4))
+}
+
+// bigAtan2 returns the angle of the point (x, y) in the range (-pi, pi]
+func bigAtan2(y, x *big.Float) *big.Float {
+	switch {
+	case x.Sign() > 0:
+		return bigAtan(newFloat().Quo(y, x))
+	case x.Sign() < 0:
+		angle := bigAtan(newFloat().Quo(y, x))
+		if y.Sign() < 0 {
+			return angle.Sub(angle, bigPi())
+		}
+		return angle.Add(angle, bigPi())
+	case y.Sign() > 0:
+		halfPi := bigPi()
+		return halfPi.Quo(halfPi, floatFromInt(2))
+	case y.Sign() < 0:
+		halfPi := bigPi()
+		halfPi.Quo(halfPi, floatFromInt(2))
+		return halfPi.Neg(halfPi)
+	default:
+		return newFloat()
+	}
+}
+
+// bigExp computes e^x by halving the argument, summing the Taylor series
+// and squaring the result back up
+func bigExp(x *big.Float) *big.Float {
+	if x.Sign() == 0 {
+		return floatFromInt(1)
+	}
+
+	reduced := newFloat().Set(x)
+	halvings := 0
+	half := new(big.Float).SetFloat64(0.5)
+	for new(big.Float).Abs(reduced).Cmp(half) > 0 {
+		reduced.SetMantExp(reduced, -1)
+		halvings++
+	}
+
+	// e^r = 1 + r + r^2/2! + r^3/3! + ...
+	sum := floatFromInt(1)
+	term := floatFromInt(1)
+	epsilon := new(big.Float).SetMantExp(big.NewFloat(1), -precisionBits)
+	for n := int64(1); ; n++ {
+		term.Mul(term, reduced)
+		term.Quo(term, floatFromInt(n))
+		if new(big.Float).Abs(term).Cmp(epsilon) < 0 {
+			break
+		}
+		sum.Add(sum, term)
+	}
+
+	for ; halvings > 0; halvings-- {
+		sum.Mul(sum, sum)
+	}
+	return sum
+}
+
+// bigAtanh computes atanh(z) = z + z^3/3 + z^5/5 + ... for |z| < 1
+func bigAtanh(z *big.Float) *big.Float {
+	sum := newFloat().Set(z)
+	power := newFloat().Set(z)
+	zSquared := newFloat().Mul(z, z)
+	epsilon := new(big.Float).SetMantExp(big.NewFloat(1), -precisionBits)
+	for n := int64(3); ; n += 2 {
+		power.Mul(power, zSquared)
+		term := newFloat().Quo(power, floatFromInt(n))
+		if new(big.Float).Abs(term).Cmp(epsilon) < 0 {
+			break
+		}
+		sum.Add(sum, term)
+	}
+	return sum
+}
+
+// bigLog computes the natural logarithm of a positive x by splitting it into
+// mantissa and binary exponent: ln(m * 2^e) = ln(m) + e * ln(2)
+func bigLog(x *big.Float) *big.Float {
+	mantissa := newFloat()
+	exp := x.MantExp(mantissa)
+
+	// ln(m) = 2 * atanh((m - 1) / (m + 1))
+	one := floatFromInt(1)
+	num := newFloat().Sub(mantissa, one)
+	den := newFloat().Add(mantissa, one)
+	lnMantissa := bigAtanh(num.Quo(num, den))
+	lnMantissa.SetMantExp(lnMantissa, 1)
+
+	// ln(2) = 2 * atanh(1/3)
+	ln2 := bigAtanh(newFloat().Quo(one, floatFromInt(3)))
+	ln2.SetMantExp(ln2, 1)
+
+	return lnMantissa.Add(lnMantissa, ln2.Mul(ln2, floatFromInt(int64(exp))))
+}
+
+// maxSafeExp bounds the binary exponent of the values bigPowInt multiplies,
+// so that no product of two of them can overflow to infinity
+const maxSafeExp = big.MaxExp/2 - 1
+
+// errOverflow reports a result too large for big.Float to represent
+var errOverflow = errors.New("result overflow in exponentiation")
+
+// bigPowInt raises x to an integer power by repeated squaring. It gives up,
+// returning false, as soon as an intermediate value's binary exponent
+// exceeds ±maxExp, before it can overflow to infinity
+func bigPowInt(x *big.Float, n int64, maxExp int) (*big.Float, bool) {
+	negative := n < 0
+	if negative {
+		n = -n
+	}
+
+	result := floatFromInt(1)
+	base := newFloat().Set(x)
+	for n > 0 {
+		if n&1 == 1 {
+			result.Mul(result, base)
+			if exceedsExp(maxExp, result) {
+				return nil, false
+			}
+		}
+		if n >>= 1; n > 0 {
+			base.Mul(base, base)
+			if exceedsExp(maxExp, base) {
+				return nil, false
+			}
+		}
+	}
+
+	if negative {
+		return newFloat().Quo(floatFromInt(1), result), true
+	}
+	return result, true
+}
+
+// exceedsExp reports whether any of fs is infinite or has a binary exponent
+// beyond ±maxExp
+func exceedsExp(maxExp int, fs ...*big.Float) bool {
+	for _, f := range fs {
+		if f.IsInf() {
+			return true
+		}
+		if f.Sign() == 0 {
+			continue
+		}
+		if exp := f.MantExp(nil); exp > maxExp || -exp > maxExp {
+			return true
+		}
+	}
+	return false
+}
+
+// exactInt64 returns f as an int64 when it is an integer within range
+func exactInt64(f *big.Float) (int64, bool) {
+	if !f.IsInt() {
+		return 0, false
+	}
+	n, accuracy := f.Int64()
+	return n, accuracy == big.Exact
+}
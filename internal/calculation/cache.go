@@ -0,0 +1,279 @@
+package calculation
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ResultCache is a bounded least-recently-used cache of evaluation results,
+// keyed by the whitespace-normalized expression and the settings of the
+// engine that evaluated it, so one cache may be shared by several engines.
+// Each entry remembers the variables its expression read and is invalidated
+// when any of them changes. Results that depend on the clock ("now",
+// "today") and failed evaluations are never cached. A ResultCache is safe
+// for concurrent use
+type ResultCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // front is most recently used
+	stats    CacheStats
+}
+
+// CacheStats counts the lookups made in a ResultCache
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	// Invalidations counts misses caused by a changed variable; they are
+	// included in Misses
+	Invalidations uint64
+	// Evictions counts entries dropped to stay within capacity
+	Evictions uint64
+	Entries   int
+	Capacity  int
+}
+
+// cacheEntry is one cached result together with the variable values it was
+// computed from
+type cacheEntry struct {
+	key     string
+	program *Program
+	value   Value
+	names   []string
+	// inputs holds the value of each of names, nil where it was undefined
+	inputs []Value
+}
+
+// NewResultCache creates a cache holding at most capacity results. A
+// capacity below 1 is treated as 1
+func NewResultCache(capacity int) *ResultCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &ResultCache{capacity: capacity, entries: map[string]*list.Element{}, order: list.New()}
+}
+
+// WithCache memoizes the results of Evaluate, EvaluateWithVariables,
+// CalculateContext and CalculateBatch in cache
+func WithCache(cache *ResultCache) EngineOption {
+	return func(ce *CalculationEngine) {
+		ce.cache = cache
+	}
+}
+
+// Cache returns the engine's result cache, or nil if it has none
+func (ce *CalculationEngine) Cache() *ResultCache {
+	return ce.cache
+}
+
+// Stats returns a snapshot of the cache's counters
+func (c *ResultCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = c.order.Len()
+	stats.Capacity = c.capacity
+	return stats
+}
+
+// Purge removes every entry, keeping the counters
+func (c *ResultCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[string]*list.Element{}
+	c.order.Init()
+}
+
+// evaluate returns the cached result of an expression when its variables
+// are unchanged, and otherwise evaluates it and caches the result
+func (c *ResultCache) evaluate(ctx context.Context, ce *CalculationEngine, expression string, vars map[string]Value) (Value, error) {
+	key := ce.settingsKey + normalizeExpression(expression)
+
+	c.mu.Lock()
+	var program *Program
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		if entry.matches(vars) {
+			c.order.MoveToFront(elem)
+			c.stats.Hits++
+			c.mu.Unlock()
+			return entry.value, nil
+		}
+		// Still valid as a parse of the expression
+		program = entry.program
+		c.stats.Invalidations++
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	if program == nil {
+		var err error
+		if program, err = ce.compile(expression, vars); err != nil {
+			return nil, err
+		}
+	}
+	value, err := program.EvalContext(ctx, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	names, volatile := referencedNames(program.tree)
+	if volatile {
+		return value, nil
+	}
+	for _, name := range names {
+		// A user-defined function may read variables its caller does not
+		// name, so its results are not cached
+		if _, ok := vars[name].(*UserFunction); ok {
+			return value, nil
+		}
+	}
+	entry := &cacheEntry{key: key, program: program, value: value, names: names, inputs: make([]Value, len(names))}
+	for i, name := range names {
+		entry.inputs[i] = vars[name]
+	}
+	c.add(entry)
+	return value, nil
+}
+
+// add stores an entry, replacing any entry with the same key and evicting
+// the least recently used one when the cache is full
+func (c *ResultCache) add(entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[entry.key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[entry.key] = c.order.PushFront(entry)
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+		c.stats.Evictions++
+	}
+}
+
+// matches reports whether vars gives every variable the entry read the same
+// value it had when the entry was computed
+func (e *cacheEntry) matches(vars map[string]Value) bool {
+	for i, name := range e.names {
+		v, ok := vars[name]
+		if !ok {
+			if e.inputs[i] != nil {
+				return false
+			}
+			continue
+		}
+		if e.inputs[i] == nil || !sameValue(e.inputs[i], v) {
+			return false
+		}
+	}
+	return true
+}
+
+// settingsKey identifies the engine settings that affect results, so that
+// engines with different settings sharing one cache never see each other's
+// entries. The clock is left out because results read from it are not cached
+func settingsKey(ce *CalculationEngine) string {
+	return fmt.Sprintf("%t|%s|%s|%p|%s|%+v|%s|%p\x00", ce.complexMode, ce.complexForm, ce.percentMode, ce.rates, ce.location, ce.limits, ce.tolerance.Text('g', 10), ce.registry)
+}
+
+// normalizeExpression trims an expression and collapses runs of whitespace,
+// which never change its meaning
+func normalizeExpression(expression string) string {
+	return strings.Join(strings.Fields(expression), " ")
+}
+
+// referencedNames lists the variables a compiled tree reads, in order of
+// first use, and reports whether it reads the clock
+func referencedNames(n node) (names []string, volatile bool) {
+	var walk func(n node)
+	walk = func(n node) {
+		switch n := n.(type) {
+		case identNode:
+			switch n.name {
+			case "now", "today":
+				volatile = true
+			default:
+				if !slices.Contains(names, n.name) {
+					names = append(names, n.name)
+				}
+			}
+		case unaryNode:
+			walk(n.operand)
+		case binaryNode:
+			walk(n.left)
+			walk(n.right)
+		case listNode:
+			for _, item := range n.items {
+				walk(item)
+			}
+		case conditionalNode:
+			walk(n.cond)
+			walk(n.then)
+			walk(n.els)
+		case percentNode:
+			walk(n.operand)
+		case conversionNode:
+			walk(n.value)
+		case callNode:
+			if !slices.Contains(names, n.name) {
+				names = append(names, n.name)
+			}
+			for _, arg := range n.args {
+				walk(arg)
+			}
+		}
+	}
+	walk(n)
+	return names, volatile
+}
+
+// sameValue reports whether two values are identical in kind and exact value
+func sameValue(a, b Value) bool {
+	switch a := a.(type) {
+	case Number:
+		b, ok := b.(Number)
+		return ok && a.Value.Cmp(b.Value) == 0
+	case Complex:
+		b, ok := b.(Complex)
+		return ok && a.Re.Cmp(b.Re) == 0 && a.Im.Cmp(b.Im) == 0
+	case Percent:
+		b, ok := b.(Percent)
+		return ok && a.Value.Cmp(b.Value) == 0
+	case Quantity:
+		b, ok := b.(Quantity)
+		return ok && a.Value.Cmp(b.Value) == 0 && slices.Equal(a.Unit.terms, b.Unit.terms)
+	case Money:
+		b, ok := b.(Money)
+		return ok && a.Amount.Cmp(b.Amount) == 0 && a.Currency == b.Currency && a.RatesDate == b.RatesDate
+	case DateTime:
+		b, ok := b.(DateTime)
+		return ok && a.Time.Equal(b.Time) && a.Time.Location() == b.Time.Location() && a.DateOnly == b.DateOnly
+	case Duration:
+		b, ok := b.(Duration)
+		return ok && a.Seconds.Cmp(b.Seconds) == 0
+	case TimeOfDay:
+		b, ok := b.(TimeOfDay)
+		return ok && a == b
+	case Text:
+		b, ok := b.(Text)
+		return ok && a == b
+	case Bool:
+		b, ok := b.(Bool)
+		return ok && a == b
+	case List:
+		b, ok := b.(List)
+		return ok && slices.EqualFunc(a.Items, b.Items, sameValue)
+	case *UserFunction:
+		b, ok := b.(*UserFunction)
+		return ok && a == b
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package calculation
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// errComplexOverflow reports complex arithmetic whose parts overflowed
+var errComplexOverflow = errors.New("result overflow in complex arithmetic")
+
+// ComplexForm selects how complex results are displayed
+type ComplexForm string
+
+const (
+	// ComplexRectangular displays complex numbers as a+bi
+	ComplexRectangular ComplexForm = "rectangular"
+	// ComplexPolar displays complex numbers as magnitude∠angle (radians)
+	ComplexPolar ComplexForm = "polar"
+)
+
+// Complex is a complex-valued result with big.Float real and imaginary parts
+type Complex struct {
+	Re *big.Float
+	Im *big.Float
+}
+
+// NewComplex builds a Complex value from its real and imaginary parts
+func NewComplex(re, im *big.Float) Complex {
+	return Complex{Re: re, Im: im}
+}
+
+// Kind implements Value
+func (c Complex) Kind() string {
+	return "complex"
+}
+
+// String formats the value in rectangular form
+func (c Complex) String() string {
+	return c.Format(ComplexRectangular)
+}
+
+// Format renders the value in the requested form
+func (c Complex) Format(form ComplexForm) string {
+	if form == ComplexPolar {
+		return fmt.Sprintf("%s∠%s", formatFloat(c.Abs()), formatFloat(c.Arg()))
+	}
+
+	re := formatFloat(c.Re)
+	im := formatFloat(new(big.Float).Abs(c.Im))
+	if im == "1" {
+		im = ""
+	}
+	switch {
+	case c.Im.Sign() == 0:
+		return re
+	case c.Re.Sign() == 0 && c.Im.Sign() < 0:
+		return "-" + im + "i"
+	case c.Re.Sign() == 0:
+		return im + "i"
+	case c.Im.Sign() < 0:
+		return re + "-" + im + "i"
+	default:
+		return re + "+" + im + "i"
+	}
+}
+
+// Abs returns the magnitude |c|
+func (c Complex) Abs() *big.Float {
+	return bigHypot(c.Re, c.Im)
+}
+
+// Arg returns the phase angle of c in radians
+func (c Complex) Arg() *big.Float {
+	return bigAtan2(c.Im, c.Re)
+}
+
+// Conj returns the complex conjugate of c
+func (c Complex) Conj() Complex {
+	return Complex{Re: newFloat().Set(c.Re), Im: newFloat().Neg(c.Im)}
+}
+
+// toComplex promotes a real number to a complex value with zero imaginary part
+func toComplex(n Number) Complex {
+	return Complex{Re: n.Value, Im: newFloat()}
+}
+
+// complexAdd returns a + b
+func complexAdd(a, b Complex) Complex {
+	return Complex{Re: newFloat().Add(a.Re, b.Re), Im: newFloat().Add(a.Im, b.Im)}
+}
+
+// complexSub returns a - b
+func complexSub(a, b Complex) Complex {
+	return Complex{Re: newFloat().Sub(a.Re, b.Re), Im: newFloat().Sub(a.Im, b.Im)}
+}
+
+// complexMul returns a * b. Infinite parts, which big.Float cannot multiply
+// by zero without panicking, are rejected as an overflow
+func complexMul(a, b Complex) (Complex, error) {
+	if !a.finite() || !b.finite() {
+		return Complex{}, errComplexOverflow
+	}
+	ac := newFloat().Mul(a.Re, b.Re)
+	bd := newFloat().Mul(a.Im, b.Im)
+	ad := newFloat().Mul(a.Re, b.Im)
+	bc := newFloat().Mul(a.Im, b.Re)
+	if ac.IsInf() || bd.IsInf() || ad.IsInf() || bc.IsInf() {
+		return Complex{}, errComplexOverflow
+	}
+	return Complex{Re: ac.Sub(ac, bd), Im: ad.Add(ad, bc)}, nil
+}
+
+// complexQuo returns a / b, rejecting division by zero and infinite parts
+func complexQuo(a, b Complex) (Complex, error) {
+	if !a.finite() || !b.finite() {
+		return Complex{}, errComplexOverflow
+	}
+	denom := newFloat().Mul(b.Re, b.Re)
+	denom.Add(denom, newFloat().Mul(b.Im, b.Im))
+	if denom.Sign() == 0 {
+		return Complex{}, ErrDivisionByZero
+	}
+	if denom.IsInf() {
+		return Complex{}, errComplexOverflow
+	}
+
+	num, err := complexMul(a, b.Conj())
+	if err != nil {
+		return Complex{}, err
+	}
+	return Complex{Re: num.Re.Quo(num.Re, denom), Im: num.Im.Quo(num.Im, denom)}, nil
+}
+
+// finite reports whether neither part of c is infinite
+func (c Complex) finite() bool {
+	return !c.Re.IsInf() && !c.Im.IsInf()
+}
+
+// complexSqrt returns the principal square root of c
+func complexSqrt(c Complex) Complex {
+	r := c.Abs()
+
+	// re = sqrt((r + a) / 2), im = sign(b) * sqrt((r - a) / 2)
+	two := floatFromInt(2)
+	re := newFloat().Add(r, c.Re)
+	re = bigSqrt(re.Quo(re, two))
+	im := newFloat().Sub(r, c.Re)
+	im = bigSqrt(im.Quo(im, two))
+	if c.Im.Sign() < 0 {
+		im.Neg(im)
+	}
+	return Complex{Re: re, Im: im}
+}
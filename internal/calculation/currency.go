@@ -0,0 +1,322 @@
+package calculation
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// currencyMinorUnits lists ISO 4217 currency codes with their number of minor unit digits
+// Source: ISO 4217 currency code list
+var currencyMinorUnits = map[string]int{
+	"AED": 2, "ARS": 2, "AUD": 2, "BGN": 2, "BHD": 3, "BRL": 2, "BYN": 2,
+	"CAD": 2, "CHF": 2, "CLP": 0, "CNY": 2, "COP": 2, "CZK": 2, "DKK": 2,
+	"EGP": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2,
+	"INR": 2, "ISK": 0, "JOD": 3, "JPY": 0, "KES": 2, "KRW": 0, "KWD": 3,
+	"MXN": 2, "MYR": 2, "NGN": 2, "NOK": 2, "NZD": 2, "OMR": 3, "PEN": 2,
+	"PHP": 2, "PLN": 2, "QAR": 2, "RON": 2, "RSD": 2, "RUB": 2, "SAR": 2,
+	"SEK": 2, "SGD": 2, "THB": 2, "TND": 3, "TRY": 2, "TWD": 2, "UAH": 2,
+	"USD": 2, "UYU": 2, "VND": 0, "ZAR": 2,
+}
+
+// isCurrency reports whether a symbol is a known ISO 4217 currency code
+func isCurrency(code string) bool {
+	_, ok := currencyMinorUnits[code]
+	return ok
+}
+
+// minorUnits returns the number of decimal places used by a currency
+func minorUnits(code string) int {
+	if digits, ok := currencyMinorUnits[code]; ok {
+		return digits
+	}
+	return 2
+}
+
+// RateTable holds exchange rates relative to a base currency as of a given date
+type RateTable struct {
+	Base  string
+	Date  string
+	Rates map[string]*big.Rat
+}
+
+// LoadRateTable reads an exchange rate table from a JSON or CSV file
+//
+// JSON format:
+//
+//	{"base": "EUR", "date": "2026-10-15", "rates": {"USD": "1.0842", "GBP": "0.8571"}}
+//
+// CSV format (one row per currency, all rows sharing date and base):
+//
+//	date,base,currency,rate
+//	2026-10-15,EUR,USD,1.0842
+func LoadRateTable(path string) (*RateTable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rate table: %w", err)
+	}
+	defer file.Close()
+
+	var table *RateTable
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		table, err = parseRateTableJSON(file)
+	case ".csv":
+		table, err = parseRateTableCSV(file)
+	default:
+		return nil, fmt.Errorf("unsupported rate table format: %s (expected .json or .csv)", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid rate table %s: %w", path, err)
+	}
+	return table, nil
+}
+
+// NewRateTable builds a rate table from decimal rate strings, each giving the
+// amount of a currency equal to one unit of the base currency
+func NewRateTable(base, date string, rates map[string]string) (*RateTable, error) {
+	table := &RateTable{Base: base, Date: date, Rates: map[string]*big.Rat{}}
+	for code, rate := range rates {
+		if err := table.addRate(code, rate); err != nil {
+			return nil, err
+		}
+	}
+	return table, table.validate()
+}
+
+// parseRateTableJSON decodes the JSON rate table format, keeping rates decimal-exact
+func parseRateTableJSON(r io.Reader) (*RateTable, error) {
+	var raw struct {
+		Base  string                 `json:"base"`
+		Date  string                 `json:"date"`
+		Rates map[string]json.Number `json:"rates"`
+	}
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	table := &RateTable{Base: raw.Base, Date: raw.Date, Rates: map[string]*big.Rat{}}
+	for code, rate := range raw.Rates {
+		if err := table.addRate(code, rate.String()); err != nil {
+			return nil, err
+		}
+	}
+	return table, table.validate()
+}
+
+// parseRateTableCSV decodes the CSV rate table format
+func parseRateTableCSV(r io.Reader) (*RateTable, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("expected a header row and at least one rate")
+	}
+
+	table := &RateTable{Rates: map[string]*big.Rat{}}
+	for i, row := range rows[1:] {
+		if len(row) != 4 {
+			return nil, fmt.Errorf("row %d: expected date,base,currency,rate", i+2)
+		}
+		date, base := strings.TrimSpace(row[0]), strings.TrimSpace(row[1])
+		if table.Date == "" {
+			table.Date, table.Base = date, base
+		} else if date != table.Date || base != table.Base {
+			return nil, fmt.Errorf("row %d: all rows must share the same date and base currency", i+2)
+		}
+		if err := table.addRate(row[2], row[3]); err != nil {
+			return nil, err
+		}
+	}
+	return table, table.validate()
+}
+
+// addRate parses a decimal rate exactly and stores it under the currency code
+func (rt *RateTable) addRate(code, rate string) error {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(rate))
+	if !ok || r.Sign() <= 0 {
+		return fmt.Errorf("invalid rate for %s: %s", code, rate)
+	}
+	rt.Rates[code] = r
+	return nil
+}
+
+// validate checks required fields and adds the implicit 1:1 base rate
+func (rt *RateTable) validate() error {
+	rt.Base = strings.ToUpper(rt.Base)
+	if rt.Base == "" {
+		return fmt.Errorf("missing base currency")
+	}
+	if rt.Date == "" {
+		return fmt.Errorf("missing rate date")
+	}
+	if _, ok := rt.Rates[rt.Base]; !ok {
+		rt.Rates[rt.Base] = big.NewRat(1, 1)
+	}
+	return nil
+}
+
+// convert expresses an amount in one currency as an exact amount in another
+func (rt *RateTable) convert(amount *big.Rat, from, to string) (*big.Rat, error) {
+	fromRate, ok := rt.Rates[from]
+	if !ok {
+		return nil, fmt.Errorf("no exchange rate for %s in table dated %s", from, rt.Date)
+	}
+	toRate, ok := rt.Rates[to]
+	if !ok {
+		return nil, fmt.Errorf("no exchange rate for %s in table dated %s", to, rt.Date)
+	}
+	result := new(big.Rat).Quo(amount, fromRate)
+	return result.Mul(result, toRate), nil
+}
+
+// Money is an exact currency amount. RatesDate is set once the amount has been
+// converted through the rate table, so the output can show which rates applied
+type Money struct {
+	Amount    *big.Rat
+	Currency  string
+	RatesDate string
+}
+
+// Kind implements Value
+func (m Money) Kind() string {
+	return "money"
+}
+
+// String formats the amount rounded to the currency's ISO 4217 minor units
+func (m Money) String() string {
+	text := formatRat(m.Amount, minorUnits(m.Currency)) + " " + m.Currency
+	if m.RatesDate != "" {
+		text += " (rates as of " + m.RatesDate + ")"
+	}
+	return text
+}
+
+// formatRat rounds r half away from zero to the given number of decimal places
+func formatRat(r *big.Rat, digits int) string {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(scale))
+
+	num := new(big.Int).Abs(scaled.Num())
+	quotient, remainder := new(big.Int).QuoRem(num, scaled.Denom(), new(big.Int))
+	if remainder.Mul(remainder, big.NewInt(2)).Cmp(scaled.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+
+	text := quotient.String()
+	if digits > 0 {
+		if len(text) <= digits {
+			text = strings.Repeat("0", digits-len(text)+1) + text
+		}
+		text = text[:len(text)-digits] + "." + text[len(text)-digits:]
+	}
+	if r.Sign() < 0 && strings.Trim(text, "0.") != "" {
+		text = "-" + text
+	}
+	return text
+}
+
+// decimalRat converts a big.Float to the exact decimal it represents at
+// display precision, so 1.2 scales money by exactly 6/5 rather than by
+// the nearest binary fraction
+func decimalRat(f *big.Float) *big.Rat {
+	r, ok := new(big.Rat).SetString(f.Text('g', 28))
+	if !ok {
+		r, _ = f.Rat(nil)
+	}
+	return r
+}
+
+// ratToFloat converts an exact rational to the engine's working precision
+func ratToFloat(r *big.Rat) *big.Float {
+	return newFloat().SetRat(r)
+}
+
+// convertMoney converts an amount into the target currency using the engine's rate table
+func (ev *evaluator) convertMoney(m Money, target string) (Money, error) {
+	if m.Currency == target {
+		return m, nil
+	}
+	if ev.engine.rates == nil {
+		return Money{}, fmt.Errorf("no exchange rate table loaded (set currency_rates_file in config.yaml)")
+	}
+	amount, err := ev.engine.rates.convert(m.Amount, m.Currency, target)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: target, RatesDate: ev.engine.rates.Date}, nil
+}
+
+// moneyBinary applies an arithmetic operator where at least one side is a currency amount
+func (ev *evaluator) moneyBinary(op string, left, right Value) (Value, error) {
+	lm, lIsMoney := left.(Money)
+	rm, rIsMoney := right.(Money)
+
+	if lIsMoney && rIsMoney {
+		converted, err := ev.convertMoney(rm, lm.Currency)
+		if err != nil {
+			return nil, err
+		}
+		ratesDate := lm.RatesDate
+		if converted.RatesDate != "" {
+			ratesDate = converted.RatesDate
+		}
+
+		switch op {
+		case "+":
+			return Money{Amount: new(big.Rat).Add(lm.Amount, converted.Amount), Currency: lm.Currency, RatesDate: ratesDate}, nil
+		case "-":
+			return Money{Amount: new(big.Rat).Sub(lm.Amount, converted.Amount), Currency: lm.Currency, RatesDate: ratesDate}, nil
+		case "/":
+			if converted.Amount.Sign() == 0 {
+				return nil, ErrDivisionByZero
+			}
+			return Number{Value: ratToFloat(new(big.Rat).Quo(lm.Amount, converted.Amount))}, nil
+		default:
+			return nil, fmt.Errorf("unsupported operator for currency amounts: %s", op)
+		}
+	}
+
+	if lIsMoney {
+		n, ok := right.(Number)
+		if !ok {
+			return nil, fmt.Errorf("cannot combine %s and %s", left.Kind(), right.Kind())
+		}
+		factor := decimalRat(n.Value)
+		switch op {
+		case "*":
+			return Money{Amount: new(big.Rat).Mul(lm.Amount, factor), Currency: lm.Currency, RatesDate: lm.RatesDate}, nil
+		case "/":
+			if factor.Sign() == 0 {
+				return nil, ErrDivisionByZero
+			}
+			return Money{Amount: new(big.Rat).Quo(lm.Amount, factor), Currency: lm.Currency, RatesDate: lm.RatesDate}, nil
+		case "+", "-":
+			return nil, fmt.Errorf("cannot %s a plain number and %s", verbFor(op), lm.Currency)
+		default:
+			return nil, fmt.Errorf("unsupported operator for currency amounts: %s", op)
+		}
+	}
+
+	n, ok := left.(Number)
+	if !ok {
+		return nil, fmt.Errorf("cannot combine %s and %s", left.Kind(), right.Kind())
+	}
+	switch op {
+	case "*":
+		return Money{Amount: new(big.Rat).Mul(decimalRat(n.Value), rm.Amount), Currency: rm.Currency, RatesDate: rm.RatesDate}, nil
+	case "+", "-":
+		return nil, fmt.Errorf("cannot %s a plain number and %s", verbFor(op), rm.Currency)
+	default:
+		return nil, fmt.Errorf("unsupported operator for currency amounts: %s", op)
+	}
+}
@@ -0,0 +1,452 @@
+package calculation
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// DateTime is a calendar date, optionally with a time of day and zone
+type DateTime struct {
+	Time     time.Time
+	DateOnly bool
+}
+
+// Kind implements Value
+func (d DateTime) Kind() string {
+	return "datetime"
+}
+
+// String formats the date as YYYY-MM-DD, adding the time and zone when present
+func (d DateTime) String() string {
+	switch {
+	case d.DateOnly:
+		return d.Time.Format("2006-01-02")
+	case d.Time.Second() == 0 && d.Time.Nanosecond() == 0:
+		return d.Time.Format("2006-01-02 15:04 MST")
+	default:
+		return d.Time.Format("2006-01-02 15:04:05 MST")
+	}
+}
+
+// TimeOfDay is a wall-clock time such as 17:30, independent of any date
+type TimeOfDay struct {
+	Offset time.Duration
+}
+
+// Kind implements Value
+func (t TimeOfDay) Kind() string {
+	return "time"
+}
+
+// String formats the time as HH:MM, or HH:MM:SS when seconds are present
+func (t TimeOfDay) String() string {
+	clock := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).Add(t.Offset)
+	if clock.Second() == 0 {
+		return clock.Format("15:04")
+	}
+	return clock.Format("15:04:05")
+}
+
+// Duration is a signed length of time held in seconds at big.Float precision
+type Duration struct {
+	Seconds *big.Float
+}
+
+// Kind implements Value
+func (d Duration) Kind() string {
+	return "duration"
+}
+
+// String formats the duration as days, hours, minutes and seconds, e.g. 1d13h40m
+func (d Duration) String() string {
+	if d.Seconds.Sign() == 0 {
+		return "0s"
+	}
+
+	var b strings.Builder
+	remaining := newFloat().Abs(d.Seconds)
+	if d.Seconds.Sign() < 0 {
+		b.WriteString("-")
+	}
+	for _, part := range []struct {
+		suffix  string
+		seconds int64
+	}{
+		{"d", 86400}, {"h", 3600}, {"m", 60},
+	} {
+		size := floatFromInt(part.seconds)
+		count, _ := newFloat().Quo(remaining, size).Int(nil)
+		if count.Sign() > 0 {
+			fmt.Fprintf(&b, "%s%s", count.String(), part.suffix)
+			remaining.Sub(remaining, newFloat().Mul(newFloat().SetInt(count), size))
+		}
+	}
+	if seconds := formatFloat(remaining); seconds != "0" {
+		b.WriteString(seconds + "s")
+	}
+	return b.String()
+}
+
+// durationSeconds returns the length of a duration or a time-dimension quantity in seconds
+func durationSeconds(v Value) (*big.Float, bool) {
+	switch v := v.(type) {
+	case Duration:
+		return v.Seconds, true
+	case Quantity:
+		if v.Unit.dim != (dimension{dimTime: 1}) {
+			return nil, false
+		}
+		return newFloat().Mul(v.Value, v.Unit.factor), true
+	default:
+		return nil, false
+	}
+}
+
+// toGoDuration converts seconds to a time.Duration, rejecting values beyond its ±292 year range
+func toGoDuration(seconds *big.Float) (time.Duration, error) {
+	nanos, accuracy := newFloat().Mul(seconds, floatFromInt(int64(time.Second))).Int64()
+	if accuracy != big.Exact && (nanos == 1<<63-1 || nanos == -1<<63) {
+		return 0, fmt.Errorf("duration out of range")
+	}
+	return time.Duration(nanos), nil
+}
+
+// isTemporal reports whether a value is a date, time of day or duration
+func isTemporal(v Value) bool {
+	switch v.(type) {
+	case DateTime, TimeOfDay, Duration:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseDateLiteral parses YYYY-MM-DD or YYYY-MM-DDTHH:MM[:SS][Z|±HH:MM] in the engine's location
+func (ev *evaluator) parseDateLiteral(text string) (DateTime, error) {
+	if !strings.Contains(text, "T") {
+		t, err := time.ParseInLocation("2006-01-02", text, ev.engine.location)
+		if err != nil {
+			return DateTime{}, fmt.Errorf("invalid date: %s", text)
+		}
+		return DateTime{Time: t, DateOnly: true}, nil
+	}
+
+	for _, layout := range []string{
+		"2006-01-02T15:04:05Z07:00", "2006-01-02T15:04Z07:00",
+		"2006-01-02T15:04:05", "2006-01-02T15:04",
+	} {
+		if t, err := time.ParseInLocation(layout, text, ev.engine.location); err == nil {
+			return DateTime{Time: t}, nil
+		}
+	}
+	return DateTime{}, fmt.Errorf("invalid date and time: %s", text)
+}
+
+// parseTimeLiteral parses an H:MM or HH:MM[:SS] time of day
+func parseTimeLiteral(text string) (TimeOfDay, error) {
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.Parse(layout, text); err == nil {
+			offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+				time.Duration(t.Second())*time.Second
+			return TimeOfDay{Offset: offset}, nil
+		}
+	}
+	return TimeOfDay{}, fmt.Errorf("invalid time of day: %s", text)
+}
+
+// parseDurationLiteral parses a compound duration such as 3h25m or 1d12h
+func parseDurationLiteral(text string) (Duration, error) {
+	total := newFloat()
+	rest := text
+	for rest != "" {
+		end := 0
+		for end < len(rest) && (isDigit(rest[end]) || rest[end] == '.') {
+			end++
+		}
+		amount, _, err := big.ParseFloat(rest[:end], 10, precisionBits, big.ToNearestEven)
+		if err != nil {
+			return Duration{}, fmt.Errorf("invalid duration: %s", text)
+		}
+		rest = rest[end:]
+
+		unit := ""
+		for _, candidate := range durationUnits {
+			if strings.HasPrefix(rest, candidate) {
+				unit = candidate
+				break
+			}
+		}
+		symbol := map[string]string{"d": "day", "h": "h", "m": "min", "s": "s", "ms": "ms", "us": "us", "ns": "ns"}[unit]
+		if symbol == "" {
+			return Duration{}, fmt.Errorf("invalid duration: %s", text)
+		}
+		total.Add(total, amount.Mul(amount, unitRegistry[symbol].factor))
+		rest = rest[len(unit):]
+	}
+	return Duration{Seconds: total}, nil
+}
+
+// addToDateTime shifts a date by a duration. Whole days are added on the
+// calendar so the wall-clock time survives daylight saving changes
+func addToDateTime(d DateTime, seconds *big.Float) (Value, error) {
+	days := newFloat().Quo(seconds, floatFromInt(86400))
+	if n, ok := exactInt64(days); ok {
+		return DateTime{Time: d.Time.AddDate(0, 0, int(n)), DateOnly: d.DateOnly}, nil
+	}
+
+	offset, err := toGoDuration(seconds)
+	if err != nil {
+		return nil, err
+	}
+	return DateTime{Time: d.Time.Add(offset)}, nil
+}
+
+// addToTimeOfDay shifts a clock time, wrapping around midnight
+func addToTimeOfDay(t TimeOfDay, seconds *big.Float) (Value, error) {
+	offset, err := toGoDuration(seconds)
+	if err != nil {
+		return nil, err
+	}
+	day := 24 * time.Hour
+	wrapped := (t.Offset + offset%day + day) % day
+	return TimeOfDay{Offset: wrapped}, nil
+}
+
+// temporalBinary applies an operator where at least one side is a date, time or duration
+func (ev *evaluator) temporalBinary(op string, left, right Value) (Value, error) {
+	leftSeconds, leftIsDuration := durationSeconds(left)
+	rightSeconds, rightIsDuration := durationSeconds(right)
+
+	switch l := left.(type) {
+	case DateTime:
+		switch {
+		case op == "+" && rightIsDuration:
+			return addToDateTime(l, rightSeconds)
+		case op == "-" && rightIsDuration:
+			return addToDateTime(l, newFloat().Neg(rightSeconds))
+		case op == "-":
+			if r, ok := right.(DateTime); ok {
+				elapsed := l.Time.Sub(r.Time)
+				seconds := newFloat().SetInt64(int64(elapsed))
+				return Duration{Seconds: seconds.Quo(seconds, floatFromInt(int64(time.Second)))}, nil
+			}
+		}
+	case TimeOfDay:
+		switch {
+		case op == "+" && rightIsDuration:
+			return addToTimeOfDay(l, rightSeconds)
+		case op == "-" && rightIsDuration:
+			return addToTimeOfDay(l, newFloat().Neg(rightSeconds))
+		case op == "-":
+			if r, ok := right.(TimeOfDay); ok {
+				seconds := newFloat().SetInt64(int64(l.Offset - r.Offset))
+				return Duration{Seconds: seconds.Quo(seconds, floatFromInt(int64(time.Second)))}, nil
+			}
+		}
+	}
+
+	if leftIsDuration && op == "+" {
+		switch r := right.(type) {
+		case DateTime:
+			return addToDateTime(r, leftSeconds)
+		case TimeOfDay:
+			return addToTimeOfDay(r, leftSeconds)
+		}
+	}
+
+	if leftIsDuration && rightIsDuration {
+		switch op {
+		case "+":
+			return Duration{Seconds: newFloat().Add(leftSeconds, rightSeconds)}, nil
+		case "-":
+			return Duration{Seconds: newFloat().Sub(leftSeconds, rightSeconds)}, nil
+		case "/":
+			if rightSeconds.Sign() == 0 {
+				return nil, ErrDivisionByZero
+			}
+			return Number{Value: newFloat().Quo(leftSeconds, rightSeconds)}, nil
+		}
+	}
+
+	if n, ok := right.(Number); ok && leftIsDuration {
+		switch op {
+		case "*":
+			return Duration{Seconds: newFloat().Mul(leftSeconds, n.Value)}, nil
+		case "/":
+			if n.Value.Sign() == 0 {
+				return nil, ErrDivisionByZero
+			}
+			return Duration{Seconds: newFloat().Quo(leftSeconds, n.Value)}, nil
+		}
+	}
+	if n, ok := left.(Number); ok && rightIsDuration && op == "*" {
+		return Duration{Seconds: newFloat().Mul(n.Value, rightSeconds)}, nil
+	}
+
+	return nil, fmt.Errorf("cannot apply %s to %s and %s", op, left.Kind(), right.Kind())
+}
+
+// convertTemporal handles "in"/"to" for dates (time zones) and durations (time units)
+func (ev *evaluator) convertTemporal(v Value, target []unitTerm) (Value, error) {
+	switch v := v.(type) {
+	case DateTime:
+		name := zoneName(target)
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("unknown time zone: %s", name)
+		}
+		return DateTime{Time: v.Time.In(loc)}, nil
+	case Duration:
+		unit, err := resolveUnit(target)
+		if err != nil {
+			return nil, err
+		}
+		seconds, err := resolveUnit([]unitTerm{{symbol: "s", power: 1}})
+		if err != nil {
+			return nil, err
+		}
+		return convertQuantity(Quantity{Value: v.Seconds, Unit: seconds}, unit)
+	default:
+		return nil, fmt.Errorf("cannot convert %s to %s", v.Kind(), zoneName(target))
+	}
+}
+
+// zoneName rebuilds an IANA zone name such as America/New_York, which the
+// parser reads as a unit expression
+func zoneName(target []unitTerm) string {
+	var b strings.Builder
+	for i, term := range target {
+		if i > 0 {
+			if term.power < 0 {
+				b.WriteString("/")
+			} else {
+				b.WriteString("*")
+			}
+		}
+		b.WriteString(term.symbol)
+	}
+	return b.String()
+}
+
+// asDate extracts a DateTime function argument
+func asDate(name string, v Value) (DateTime, error) {
+	d, ok := v.(DateTime)
+	if !ok {
+		return DateTime{}, fmt.Errorf("%s: expected a date, got %s", name, v.Kind())
+	}
+	return d, nil
+}
+
+// asInt extracts an integer function argument
+func asInt(name string, v Value) (int, error) {
+	n, ok := v.(Number)
+	if !ok {
+		return 0, fmt.Errorf("%s: expected a number, got %s", name, v.Kind())
+	}
+	i, ok := exactInt64(n.Value)
+	if !ok {
+		return 0, fmt.Errorf("%s: expected a whole number", name)
+	}
+	return int(i), nil
+}
+
+// isWorkday reports whether a date falls Monday to Friday
+func isWorkday(t time.Time) bool {
+	return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
+}
+
+// fnWeekday returns the day of the week of a date
+func fnWeekday(_ *CalculationEngine, args []Value) (Value, error) {
+	d, err := asDate("weekday", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return Text{Value: d.Time.Weekday().String()}, nil
+}
+
+// fnWorkdays counts Monday-to-Friday days between two dates, inclusive of both ends
+func fnWorkdays(_ *CalculationEngine, args []Value) (Value, error) {
+	start, err := asDate("workdays", args[0])
+	if err != nil {
+		return nil, err
+	}
+	end, err := asDate("workdays", args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	from := civilDate(start.Time)
+	to := civilDate(end.Time)
+	sign := int64(1)
+	if to.Before(from) {
+		from, to, sign = to, from, -1
+	}
+
+	count := int64(0)
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if isWorkday(day) {
+			count++
+		}
+	}
+	return Number{Value: floatFromInt(sign * count)}, nil
+}
+
+// fnAddWorkdays moves a date forward (or backward) by a number of Monday-to-Friday days
+func fnAddWorkdays(_ *CalculationEngine, args []Value) (Value, error) {
+	d, err := asDate("addworkdays", args[0])
+	if err != nil {
+		return nil, err
+	}
+	n, err := asInt("addworkdays", args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	t := d.Time
+	for n > 0 {
+		t = t.AddDate(0, 0, step)
+		if isWorkday(t) {
+			n--
+		}
+	}
+	return DateTime{Time: t, DateOnly: d.DateOnly}, nil
+}
+
+// fnAddMonths adds calendar months, clamping to the last day of a shorter
+// month as spreadsheet EDATE does, so 2026-01-31 plus one month is 2026-02-28
+func fnAddMonths(_ *CalculationEngine, args []Value) (Value, error) {
+	d, err := asDate("addmonths", args[0])
+	if err != nil {
+		return nil, err
+	}
+	n, err := asInt("addmonths", args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	t := d.Time
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return DateTime{Time: first.AddDate(0, 0, day-1), DateOnly: d.DateOnly}, nil
+}
+
+// civilDate strips the time of day, keeping only the calendar date
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// civilDateIn returns midnight of t's calendar day in the given location
+func civilDateIn(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
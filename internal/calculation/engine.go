@@ -1,55 +1,190 @@
 package calculation
 
 import (
+	"context"
 	"fmt"
 	"math/big"
-	"regexp"
 	"strings"
+	"time"
 )
 
 // CalculationEngine provides high-precision arithmetic operations
 // Source: docs/architecture/components.md - CalculationEngine component
-type CalculationEngine struct{}
+type CalculationEngine struct {
+	complexMode bool
+	complexForm ComplexForm
+	rates       *RateTable
+	percentMode PercentMode
+	clock       func() time.Time
+	location    *time.Location
+	limits      Limits
+	tolerance   *big.Float
+	registry    *Registry
+	cache       *ResultCache
+	// settingsKey prefixes the engine's cache keys
+	settingsKey string
+}
+
+// EngineOption configures a CalculationEngine at construction time
+type EngineOption func(*CalculationEngine)
+
+// WithComplexMode makes sqrt of a negative number return a complex result instead of an error
+func WithComplexMode(enabled bool) EngineOption {
+	return func(ce *CalculationEngine) {
+		ce.complexMode = enabled
+	}
+}
+
+// WithComplexForm selects rectangular or polar output for complex results
+func WithComplexForm(form ComplexForm) EngineOption {
+	return func(ce *CalculationEngine) {
+		ce.complexForm = form
+	}
+}
+
+// WithRateTable supplies the exchange rates used to convert between currencies
+func WithRateTable(table *RateTable) EngineOption {
+	return func(ce *CalculationEngine) {
+		ce.rates = table
+	}
+}
+
+// WithPercentMode selects desk-calculator or pure-math percentage semantics
+func WithPercentMode(mode PercentMode) EngineOption {
+	return func(ce *CalculationEngine) {
+		ce.percentMode = mode
+	}
+}
+
+// WithClock sets the source of the current time used by "now" and "today"
+func WithClock(clock func() time.Time) EngineOption {
+	return func(ce *CalculationEngine) {
+		ce.clock = clock
+	}
+}
+
+// WithLocation sets the time zone for date literals, "now" and "today"
+func WithLocation(loc *time.Location) EngineOption {
+	return func(ce *CalculationEngine) {
+		ce.location = loc
+	}
+}
 
 // NewCalculationEngine creates a new instance of the calculation engine
-func NewCalculationEngine() *CalculationEngine {
-	return &CalculationEngine{}
+func NewCalculationEngine(opts ...EngineOption) *CalculationEngine {
+	ce := &CalculationEngine{
+		complexForm: ComplexRectangular,
+		percentMode: PercentDesk,
+		clock:       time.Now,
+		location:    time.Local,
+		limits:      DefaultLimits(),
+		tolerance:   big.NewFloat(DefaultTolerance),
+		registry:    defaultRegistry,
+	}
+	for _, opt := range opts {
+		opt(ce)
+	}
+	if ce.cache != nil {
+		ce.settingsKey = settingsKey(ce)
+	}
+	return ce
+}
+
+// Evaluate parses and evaluates a full expression, returning a typed result
+// Supports parentheses, unary minus, exponentiation, complex literals (3+4i, 2i),
+// quantities with units (5 km), currency amounts (120 EUR), dates, times
+// and durations (2026-10-17 + 90 days, 17:30 + 45min, 3h25m * 4), "to"/"in"
+// conversions including time zones, the constants pi and e, and the built-in functions
+func (ce *CalculationEngine) Evaluate(expression string) (Value, error) {
+	return ce.EvaluateWithVariables(expression, nil)
+}
+
+// EvaluateWithVariables evaluates an expression in which bare identifiers may
+// refer to the given variables, e.g. "price * qty" with price and qty defined
+func (ce *CalculationEngine) EvaluateWithVariables(expression string, vars map[string]Value) (Value, error) {
+	return ce.evaluateContext(context.Background(), expression, vars)
+}
+
+// CalculateContext is Evaluate that gives up with ctx.Err() once ctx is done,
+// so a caller can bound evaluation time with a deadline. Together with the
+// engine's Limits this makes it safe to evaluate untrusted input
+func (ce *CalculationEngine) CalculateContext(ctx context.Context, expression string) (Value, error) {
+	return ce.evaluateContext(ctx, expression, nil)
+}
+
+// ValidateVariableName checks that name can be assigned a value: it must be an
+// identifier and must not shadow a built-in constant or keyword
+func ValidateVariableName(name string) error {
+	if name == "" || !isIdentStart(name[0]) {
+		return fmt.Errorf("invalid variable name %q", name)
+	}
+	for i := 1; i < len(name); i++ {
+		if !isIdentPart(name[i]) {
+			return fmt.Errorf("invalid variable name %q", name)
+		}
+	}
+	switch name {
+	case "i", "pi", "e", "true", "false", "now", "today", "to", "in", "of", "as", "if":
+		return fmt.Errorf("cannot assign to built-in name %q", name)
+	}
+	return nil
+}
+
+// CheckSyntax parses an expression the way Evaluate would without evaluating
+// it, so incomplete input such as "2 +" or "(3" is reported without side
+// effects. Failures are returned as *SyntaxError
+func (ce *CalculationEngine) CheckSyntax(expression string) error {
+	if err := ce.limits.checkLength(expression); err != nil {
+		return err
+	}
+	if _, err := parseExpression(expression, ce.registry); err != nil {
+		return &SyntaxError{Err: err}
+	}
+	return nil
+}
+
+// FormatResult renders a value using the engine's display settings
+func (ce *CalculationEngine) FormatResult(v Value) string {
+	switch v := v.(type) {
+	case Complex:
+		return v.Format(ce.complexForm)
+	case List:
+		items := make([]string, len(v.Items))
+		for i, item := range v.Items {
+			items[i] = ce.FormatResult(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return v.String()
 }
 
 // Calculate parses and evaluates a mathematical expression with 15-digit precision
-// Supports: addition (+), subtraction (-), multiplication (*), division (/)
+// Supports "number operator number" with any infix operator of the engine's
+// registry except comparisons and logic, which return true or false, e.g.
+// addition (+), subtraction (-), multiplication (*), division (/), and a
+// percentage second operand (200 * 15%, 80 + 10%)
 // Source: docs/architecture/components.md - Calculate interface
 func (ce *CalculationEngine) Calculate(expression string) (float64, error) {
-	// Validate input first
-	if err := ce.Validate(expression); err != nil {
+	// Validate and parse in a single pass
+	num1, op, num2, percent, err := ce.parseValidated(expression)
+	if err != nil {
 		return 0, err
 	}
 
-	// Parse the expression using simplified parser
-	num1, op, num2, err := ce.parseSimpleExpression(expression)
+	var right Value = Number{Value: num2}
+	if percent {
+		right = Percent{Value: num2}
+	}
+	ev := &evaluator{engine: ce}
+	value, err := ev.applyBinary(op, Number{Value: num1}, right)
 	if err != nil {
-		return 0, fmt.Errorf("expression parsing failed: %w", err)
+		return 0, err
 	}
-
-	result := new(big.Float)
-
-	switch op {
-	case "+":
-		result.Add(num1, num2)
-	case "-":
-		result.Sub(num1, num2)
-	case "*":
-		result.Mul(num1, num2)
-	case "/":
-		if num2.Sign() == 0 {
-			return 0, fmt.Errorf("division by zero")
-		}
-		result.Quo(num1, num2)
-	default:
-		return 0, fmt.Errorf("unsupported operator: %s", op)
+	number, ok := value.(Number)
+	if !ok {
+		return 0, fmt.Errorf("operator %s returned %s, expected a number", op, value.Kind())
 	}
-
-	// Convert back to float64 with precision handling
+	result := number.Value
 	floatResult, _ := result.Float64()
 
 	// Validate precision - ensure result has reasonable precision for the operation
@@ -62,71 +197,91 @@ func (ce *CalculationEngine) Calculate(expression string) (float64, error) {
 }
 
 // parseSimpleExpression provides a simplified, robust expression parser
-// Supports format: "number operator number" with flexible whitespace
-func (ce *CalculationEngine) parseSimpleExpression(expression string) (*big.Float, string, *big.Float, error) {
+// Supports format: "number operator number" with flexible whitespace, where the
+// second number may carry a % suffix
+func (ce *CalculationEngine) parseSimpleExpression(expression string) (*big.Float, string, *big.Float, bool, error) {
 	// Trim whitespace
 	expr := strings.TrimSpace(expression)
 
 	// Split by whitespace to get parts
-	parts := strings.Fields(expr)
-	if len(parts) != 3 {
-		return nil, "", nil, fmt.Errorf("invalid format: expected 'number operator number'")
+	var parts [3]string
+	if splitFields(expr, parts[:]) != 3 {
+		return nil, "", nil, false, fmt.Errorf("invalid format: expected 'number operator number'")
 	}
 
 	num1Str, op, num2Str := parts[0], parts[1], parts[2]
+	num2Str, percent := strings.CutSuffix(num2Str, "%")
 
-	// Validate operator
-	validOps := []string{"+", "-", "*", "/"}
-	isValidOp := false
-	for _, validOp := range validOps {
-		if op == validOp {
-			isValidOp = true
-			break
-		}
+	// % is a postfix percentage, not a binary operator
+	if op == "%" {
+		return nil, "", nil, false, fmt.Errorf("unsupported operator: %% (use %% as a postfix percentage, e.g. 200 * 15%%)")
 	}
-	if !isValidOp {
-		return nil, "", nil, fmt.Errorf("unsupported operator: %s", op)
+
+	// Validate operator
+	if _, ok := ce.registry.infix.bySymbol[op]; !ok || isLogicOperator(op) {
+		return nil, "", nil, false, fmt.Errorf("unsupported operator: %s", op)
 	}
 
 	// Parse numbers
 	num1, err := ce.parseBigFloat(num1Str)
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("invalid first number: %w", err)
+		return nil, "", nil, false, fmt.Errorf("invalid first number: %w", err)
 	}
 
 	num2, err := ce.parseBigFloat(num2Str)
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("invalid second number: %w", err)
+		return nil, "", nil, false, fmt.Errorf("invalid second number: %w", err)
 	}
 
-	return num1, op, num2, nil
+	return num1, op, num2, percent, nil
 }
 
 // Validate checks if the expression is syntactically valid
 // Source: docs/architecture/components.md - Validate interface
 func (ce *CalculationEngine) Validate(expression string) error {
+	_, _, _, _, err := ce.parseValidated(expression)
+	return err
+}
+
+// parseValidated parses a three-token expression with the checks Validate
+// applies, so Calculate does not have to parse the expression twice
+func (ce *CalculationEngine) parseValidated(expression string) (*big.Float, string, *big.Float, bool, error) {
 	if strings.TrimSpace(expression) == "" {
-		return fmt.Errorf("expression cannot be empty")
+		return nil, "", nil, false, fmt.Errorf("expression cannot be empty")
 	}
 
 	// Use the same parser as Calculate for consistency
-	_, op, num2, err := ce.parseSimpleExpression(expression)
+	num1, op, num2, percent, err := ce.parseSimpleExpression(expression)
 	if err != nil {
-		return err
+		return nil, "", nil, false, err
 	}
 
 	// Check for division by zero
 	if op == "/" && num2.Sign() == 0 {
-		return fmt.Errorf("division by zero detected")
+		return nil, "", nil, false, fmt.Errorf("%w detected", ErrDivisionByZero)
 	}
 
-	return nil
+	return num1, op, num2, percent, nil
 }
 
-// GetSupportedOperations returns the list of supported arithmetic operations
+// GetSupportedOperations returns the infix operators Calculate accepts, in
+// registration order, followed by the postfix percent. Comparisons and logic
+// return true or false, so they are left to Evaluate
 // Source: docs/architecture/components.md - GetSupportedOperations interface
 func (ce *CalculationEngine) GetSupportedOperations() []string {
-	return []string{"+", "-", "*", "/"}
+	var ops []string
+	for _, op := range ce.registry.operators {
+		if op.Arity == 2 && !isLogicOperator(op.Symbol) {
+			ops = append(ops, op.Symbol)
+		}
+	}
+	return append(ops, "%")
+}
+
+// GetSupportedFunctions returns the names of the functions callable from
+// Evaluate expressions, in alphabetical order
+func (ce *CalculationEngine) GetSupportedFunctions() []string {
+	return ce.registry.Functions()
 }
 
 // parseBigFloat converts a string to big.Float with error handling
@@ -135,7 +290,7 @@ func (ce *CalculationEngine) parseBigFloat(s string) (*big.Float, error) {
 	s = strings.TrimSpace(s)
 
 	// Basic validation for numeric format
-	if matched, _ := regexp.MatchString(`^-?\d+(\.\d+)?$`, s); !matched {
+	if !isDecimal(s) {
 		return nil, fmt.Errorf("invalid number format: %s", s)
 	}
 
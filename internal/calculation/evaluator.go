@@ -0,0 +1,422 @@
+package calculation
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+)
+
+// cancelCheckInterval is how many evaluation steps pass between checks of
+// the context, which are too costly to make on every node
+const cancelCheckInterval = 64
+
+// evaluator walks a parsed expression tree and produces a Value
+type evaluator struct {
+	engine *CalculationEngine
+	vars   map[string]Value
+	// ctx, when set, cancels evaluation
+	ctx   context.Context
+	steps int
+	// calls counts the user-defined functions being called, to bound recursion
+	calls int
+}
+
+// step counts one evaluation step against MaxSteps and checks for cancellation
+func (ev *evaluator) step() error {
+	ev.steps++
+	if max := ev.engine.limits.MaxSteps; max > 0 && ev.steps > max {
+		return &LimitError{Limit: LimitSteps, Max: max}
+	}
+	if ev.ctx != nil && ev.steps%cancelCheckInterval == 0 {
+		return ev.ctx.Err()
+	}
+	return nil
+}
+
+// eval evaluates a single node of the expression tree
+func (ev *evaluator) eval(n node) (Value, error) {
+	if err := ev.step(); err != nil {
+		return nil, err
+	}
+	switch n := n.(type) {
+	case literalNode:
+		return n.value, nil
+	case numberNode:
+		f, _, err := big.ParseFloat(n.text, 10, precisionBits, big.ToNearestEven)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number format: %s", n.text)
+		}
+		if n.imaginary {
+			return Complex{Re: newFloat(), Im: f}, nil
+		}
+		return Number{Value: f}, nil
+	case identNode:
+		switch n.name {
+		case "i":
+			return Complex{Re: newFloat(), Im: floatFromInt(1)}, nil
+		case "pi":
+			return Number{Value: bigPi()}, nil
+		case "e":
+			return Number{Value: bigExp(floatFromInt(1))}, nil
+		case "true", "false":
+			return Bool{Value: n.name == "true"}, nil
+		case "now":
+			return DateTime{Time: ev.engine.clock().In(ev.engine.location)}, nil
+		case "today":
+			return DateTime{Time: civilDateIn(ev.engine.clock(), ev.engine.location), DateOnly: true}, nil
+		}
+		if v, ok := ev.vars[n.name]; ok {
+			return v, nil
+		}
+		return nil, fmt.Errorf("unknown identifier: %s", n.name)
+	case unaryNode:
+		operand, err := ev.eval(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		op, ok := ev.engine.registry.prefix.bySymbol[n.op]
+		if !ok {
+			return nil, fmt.Errorf("unsupported operator: %s", n.op)
+		}
+		result, err := op.Unary(ev.engine, operand)
+		if err != nil {
+			return nil, err
+		}
+		return result, ev.engine.limits.checkDigits(result)
+	case binaryNode:
+		left, err := ev.eval(n.left)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "&&" || n.op == "||" {
+			return ev.shortCircuit(n.op, left, n.right)
+		}
+		right, err := ev.eval(n.right)
+		if err != nil {
+			return nil, err
+		}
+		return ev.applyBinary(n.op, left, right)
+	case listNode:
+		items := make([]Value, len(n.items))
+		for i, itemNode := range n.items {
+			item, err := ev.eval(itemNode)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return List{Items: items}, nil
+	case conditionalNode:
+		cond, err := ev.eval(n.cond)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := truth(cond)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return ev.eval(n.then)
+		}
+		return ev.eval(n.els)
+	case percentNode:
+		operand, err := ev.eval(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		number, ok := operand.(Number)
+		if !ok {
+			return nil, fmt.Errorf("percent applies to plain numbers, got %s", operand.Kind())
+		}
+		return Percent{Value: number.Value}, nil
+	case quantityNode:
+		value, err := ev.eval(n.value)
+		if err != nil {
+			return nil, err
+		}
+		unit, err := resolveUnit(n.unit)
+		if err != nil {
+			return nil, err
+		}
+		return Quantity{Value: value.(Number).Value, Unit: unit}, nil
+	case moneyNode:
+		amount, ok := new(big.Rat).SetString(n.text)
+		if !ok {
+			return nil, fmt.Errorf("invalid number format: %s", n.text)
+		}
+		return Money{Amount: amount, Currency: n.currency}, nil
+	case dateNode:
+		return ev.parseDateLiteral(n.text)
+	case timeNode:
+		return parseTimeLiteral(n.text)
+	case durationNode:
+		return parseDurationLiteral(n.text)
+	case conversionNode:
+		value, err := ev.eval(n.value)
+		if err != nil {
+			return nil, err
+		}
+		result, err := ev.convert(value, n.target)
+		if err != nil {
+			return nil, err
+		}
+		return result, ev.engine.limits.checkDigits(result)
+	case callNode:
+		fn, ok := ev.engine.registry.functions[n.name]
+		if !ok {
+			return ev.callUserFunction(n)
+		}
+		args := make([]Value, 0, len(n.args))
+		for _, argNode := range n.args {
+			arg, err := ev.eval(argNode)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+		if fn.Arity != Variadic && len(args) != fn.Arity {
+			return nil, fmt.Errorf("%s expects %d argument(s), got %d", n.name, fn.Arity, len(args))
+		}
+		result, err := fn.Call(ev.engine, args)
+		if err != nil {
+			return nil, err
+		}
+		return result, ev.engine.limits.checkDigits(result)
+	default:
+		return nil, fmt.Errorf("unsupported expression element")
+	}
+}
+
+// convert applies a "to"/"in" conversion to the target unit or currency
+func (ev *evaluator) convert(v Value, target []unitTerm) (Value, error) {
+	if m, ok := v.(Money); ok {
+		if len(target) != 1 || target[0].power != 1 || !isCurrency(target[0].symbol) {
+			return nil, fmt.Errorf("cannot convert %s to a non-currency unit", m.Currency)
+		}
+		return ev.convertMoney(m, target[0].symbol)
+	}
+	if _, ok := v.(DateTime); ok {
+		return ev.convertTemporal(v, target)
+	}
+	if _, ok := v.(Duration); ok {
+		return ev.convertTemporal(v, target)
+	}
+
+	unit, err := resolveUnit(target)
+	if err != nil {
+		return nil, err
+	}
+	q, ok := v.(Quantity)
+	if !ok {
+		return nil, fmt.Errorf("cannot convert %s to %s: value has no unit", v.Kind(), unit)
+	}
+	return convertQuantity(q, unit)
+}
+
+// negate returns -v
+func negate(v Value) (Value, error) {
+	switch v := v.(type) {
+	case Number:
+		return Number{Value: newFloat().Neg(v.Value)}, nil
+	case Complex:
+		return Complex{Re: newFloat().Neg(v.Re), Im: newFloat().Neg(v.Im)}, nil
+	case Quantity:
+		return Quantity{Value: newFloat().Neg(v.Value), Unit: v.Unit}, nil
+	case Money:
+		return Money{Amount: new(big.Rat).Neg(v.Amount), Currency: v.Currency, RatesDate: v.RatesDate}, nil
+	case Percent:
+		return Percent{Value: newFloat().Neg(v.Value)}, nil
+	case Duration:
+		return Duration{Seconds: newFloat().Neg(v.Seconds)}, nil
+	default:
+		return nil, fmt.Errorf("cannot negate %s", v.Kind())
+	}
+}
+
+// applyBinary applies an arithmetic operator within the engine's limits on
+// exponents and result size
+func (ev *evaluator) applyBinary(op string, left, right Value) (Value, error) {
+	if op == "^" {
+		if err := ev.engine.limits.checkExponent(right); err != nil {
+			return nil, err
+		}
+	}
+	result, err := ev.applyOperator(op, left, right)
+	if err != nil {
+		return nil, err
+	}
+	if err := ev.engine.limits.checkDigits(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// applyOperator applies the percentage phrases "of" and "as % of" or an infix
+// operator from the engine's registry
+func (ev *evaluator) applyOperator(op string, left, right Value) (Value, error) {
+	switch op {
+	case "of":
+		return ev.percentOf(left, right)
+	case "as % of":
+		return ev.asPercentOf(left, right)
+	}
+	operator, ok := ev.engine.registry.infix.bySymbol[op]
+	if !ok {
+		return nil, fmt.Errorf("unsupported operator: %s", op)
+	}
+	return operator.Binary(ev.engine, left, right)
+}
+
+// arithmetic applies a built-in arithmetic operator, promoting to complex when
+// either side is complex, tracking units when either side is a quantity,
+// converting currencies when either side is a currency amount and doing
+// calendar arithmetic when either side is a date, time or duration
+func (ev *evaluator) arithmetic(op string, left, right Value) (Value, error) {
+	if a, ok := left.(Number); ok {
+		if b, ok := right.(Number); ok {
+			return realBinary(op, a, b, ev.engine.limits)
+		}
+	}
+	_, leftIsPercent := left.(Percent)
+	_, rightIsPercent := right.(Percent)
+	if leftIsPercent || rightIsPercent {
+		return ev.percentBinary(op, left, right)
+	}
+	_, leftIsMoney := left.(Money)
+	_, rightIsMoney := right.(Money)
+	if leftIsMoney || rightIsMoney {
+		return ev.moneyBinary(op, left, right)
+	}
+	if isTemporal(left) || isTemporal(right) {
+		return ev.temporalBinary(op, left, right)
+	}
+	if op == "^" {
+		return applyPower(left, right, ev.engine.limits)
+	}
+	_, leftIsQuantity := left.(Quantity)
+	_, rightIsQuantity := right.(Quantity)
+	if leftIsQuantity || rightIsQuantity {
+		return quantityBinary(op, left, right)
+	}
+
+	a, err := asComplex(left)
+	if err != nil {
+		return nil, err
+	}
+	b, err := asComplex(right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "+":
+		return complexAdd(a, b), nil
+	case "-":
+		return complexSub(a, b), nil
+	case "*":
+		return complexMul(a, b)
+	case "/":
+		return complexQuo(a, b)
+	default:
+		return nil, fmt.Errorf("unsupported operator: %s", op)
+	}
+}
+
+// realBinary applies an arithmetic operator to two real numbers, raising to
+// powers within limits
+func realBinary(op string, a, b Number, limits Limits) (Value, error) {
+	var result *big.Float
+	var err error
+	switch op {
+	case "+":
+		result, err = Add(a.Value, b.Value)
+	case "-":
+		result, err = Subtract(a.Value, b.Value)
+	case "*":
+		result, err = Multiply(a.Value, b.Value)
+	case "/":
+		result, err = Divide(a.Value, b.Value)
+	case "^":
+		result, err = power(a.Value, b.Value, limits)
+	default:
+		return nil, fmt.Errorf("unsupported operator: %s", op)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Number{Value: result}, nil
+}
+
+// applyPower raises a complex number or quantity to an integer power,
+// checking the size of every intermediate value against limits so that huge
+// powers fail before they overflow
+func applyPower(base, exponent Value, limits Limits) (Value, error) {
+	n, ok := exponent.(Number)
+	if !ok {
+		return nil, fmt.Errorf("exponent of %s must be an integer", base.Kind())
+	}
+	power, ok := exactInt64(n.Value)
+	if !ok {
+		return nil, fmt.Errorf("exponent of %s must be an integer", base.Kind())
+	}
+
+	switch b := base.(type) {
+	case Quantity:
+		if b.Value.Sign() == 0 && power < 0 {
+			return nil, ErrDivisionByZero
+		}
+		value, ok := bigPowInt(b.Value, power, limits.maxBinaryExp())
+		if !ok {
+			return nil, limits.overflowError()
+		}
+		unit, err := b.Unit.pow(int(power))
+		if err != nil {
+			return nil, err
+		}
+		return newQuantity(value, unit), nil
+	case Complex:
+		result := Complex{Re: floatFromInt(1), Im: newFloat()}
+		factor := b
+		if power < 0 {
+			inverse, err := complexQuo(result, b)
+			if err != nil {
+				return nil, err
+			}
+			factor, power = inverse, -power
+		}
+		for power > 0 {
+			var err error
+			if power&1 == 1 {
+				if result, err = complexMul(result, factor); err != nil {
+					return nil, err
+				}
+				if exceedsExp(limits.maxBinaryExp(), result.Re, result.Im) {
+					return nil, limits.overflowError()
+				}
+			}
+			if power >>= 1; power > 0 {
+				if factor, err = complexMul(factor, factor); err != nil {
+					return nil, err
+				}
+				if exceedsExp(limits.maxBinaryExp(), factor.Re, factor.Im) {
+					return nil, limits.overflowError()
+				}
+			}
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("cannot raise %s to a power", base.Kind())
+	}
+}
+
+// asComplex converts a numeric value to Complex
+func asComplex(v Value) (Complex, error) {
+	switch v := v.(type) {
+	case Complex:
+		return v, nil
+	case Number:
+		return toComplex(v), nil
+	default:
+		return Complex{}, fmt.Errorf("expected a number, got %s", v.Kind())
+	}
+}
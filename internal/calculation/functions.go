@@ -0,0 +1,90 @@
+package calculation
+
+import (
+	"fmt"
+)
+
+// builtinFunctions are the functions every registry starts with
+var builtinFunctions = append([]Function{
+	{Name: "re", Arity: 1, Call: fnRe},
+	{Name: "im", Arity: 1, Call: fnIm},
+	{Name: "abs", Arity: 1, Call: fnAbs},
+	{Name: "arg", Arity: 1, Call: fnArg},
+	{Name: "conj", Arity: 1, Call: fnConj},
+	{Name: "sqrt", Arity: 1, Call: fnSqrt},
+
+	{Name: "pctchange", Arity: 2, Call: fnPctChange},
+
+	{Name: "weekday", Arity: 1, Call: fnWeekday},
+	{Name: "workdays", Arity: 2, Call: fnWorkdays},
+	{Name: "addworkdays", Arity: 2, Call: fnAddWorkdays},
+	{Name: "addmonths", Arity: 2, Call: fnAddMonths},
+}, statsFunctions...)
+
+// fnRe returns the real part of a number
+func fnRe(_ *CalculationEngine, args []Value) (Value, error) {
+	c, err := asComplex(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("re: %w", err)
+	}
+	return Number{Value: c.Re}, nil
+}
+
+// fnIm returns the imaginary part of a number
+func fnIm(_ *CalculationEngine, args []Value) (Value, error) {
+	c, err := asComplex(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("im: %w", err)
+	}
+	return Number{Value: c.Im}, nil
+}
+
+// fnAbs returns the absolute value or complex magnitude
+func fnAbs(_ *CalculationEngine, args []Value) (Value, error) {
+	if n, ok := args[0].(Number); ok {
+		return Number{Value: newFloat().Abs(n.Value)}, nil
+	}
+	c, err := asComplex(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("abs: %w", err)
+	}
+	return Number{Value: c.Abs()}, nil
+}
+
+// fnArg returns the phase angle in radians
+func fnArg(_ *CalculationEngine, args []Value) (Value, error) {
+	c, err := asComplex(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("arg: %w", err)
+	}
+	return Number{Value: c.Arg()}, nil
+}
+
+// fnConj returns the complex conjugate
+func fnConj(_ *CalculationEngine, args []Value) (Value, error) {
+	if n, ok := args[0].(Number); ok {
+		return n, nil
+	}
+	c, err := asComplex(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("conj: %w", err)
+	}
+	return c.Conj(), nil
+}
+
+// fnSqrt returns the square root; negative reals yield a complex root only in complex mode
+func fnSqrt(ce *CalculationEngine, args []Value) (Value, error) {
+	if n, ok := args[0].(Number); ok {
+		if n.Value.Sign() >= 0 {
+			return Number{Value: bigSqrt(n.Value)}, nil
+		}
+		if !ce.complexMode {
+			return nil, fmt.Errorf("square root of negative number (enable complex mode for complex results)")
+		}
+	}
+	c, err := asComplex(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("sqrt: %w", err)
+	}
+	return complexSqrt(c), nil
+}
@@ -0,0 +1,470 @@
+package calculation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tokenKind identifies the lexical class of a token
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenImaginary
+	tokenIdent
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+	tokenLBracket
+	tokenRBracket
+	tokenDate
+	tokenTime
+	tokenDuration
+	// Script tokens: statement separators, block braces and print labels
+	tokenNewline
+	tokenLBrace
+	tokenRBrace
+	tokenString
+)
+
+// token is a single lexical element of an expression
+type token struct {
+	kind tokenKind
+	text string
+	// pos is the offset in the input, or in the line of a script
+	pos int
+	// line is the 1-based line of a script token
+	line int
+}
+
+// lexer is a hand-written scanner that splits an expression into tokens
+type lexer struct {
+	input string
+	pos   int
+	// operators holds further characters to read as operators, used by
+	// registered operator symbols
+	operators string
+
+	// script enables the tokens of scripts. Line breaks outside parentheses
+	// end statements, and positions count from the start of the line
+	script    bool
+	line      int
+	lineStart int
+	depth     int
+	// newline defers counting a line break until the token after it
+	newline bool
+	// ternaries holds the depth of each "?" still waiting for its ":", in
+	// whose branch a time of day must leave a ":" for the other branch
+	ternaries []int
+}
+
+// tokenize scans the whole expression and appends its tokens, followed by
+// tokenEOF, to buf. Tokens refer to the input rather than copying it, so a
+// caller-provided buffer makes lexing a small expression allocation-free.
+// Besides + - * / ^ % ? : the characters in operators are read as operators
+func tokenize(input, operators string, buf []token) ([]token, error) {
+	lx := lexer{input: input, operators: operators}
+	tokens := buf[:0]
+	for {
+		tok, err := lx.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, tok)
+		if tok.kind == tokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+// tokenizeScript scans a script like tokenize, adding newline, brace and
+// string tokens, skipping # comments and numbering lines from 1
+func tokenizeScript(input, operators string) ([]token, error) {
+	lx := lexer{input: input, operators: operators + "=", script: true, line: 1}
+	var tokens []token
+	for {
+		tok, err := lx.next()
+		if err != nil {
+			return nil, &ScriptError{Line: lx.line, Err: &SyntaxError{Err: err}}
+		}
+		tok.pos -= lx.lineStart
+		tok.line = lx.line
+		tokens = append(tokens, tok)
+		if tok.kind == tokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+// next returns the next token in the input
+func (lx *lexer) next() (token, error) {
+	if lx.script {
+		if tok, ok, err := lx.scriptToken(); ok || err != nil {
+			return tok, err
+		}
+	}
+	for lx.pos < len(lx.input) && isSpace(lx.input[lx.pos]) {
+		lx.pos++
+	}
+	if lx.pos >= len(lx.input) {
+		return token{kind: tokenEOF, pos: lx.pos}, nil
+	}
+
+	start := lx.pos
+	c := lx.input[lx.pos]
+	switch {
+	case isDigit(c) || (c == '.' && lx.pos+1 < len(lx.input) && isDigit(lx.input[lx.pos+1])):
+		return lx.scanNumber()
+	case isIdentStart(c):
+		for lx.pos < len(lx.input) && isIdentPart(lx.input[lx.pos]) {
+			lx.pos++
+		}
+		return token{kind: tokenIdent, text: lx.input[start:lx.pos], pos: start}, nil
+	case c == '(':
+		lx.pos++
+		lx.depth++
+		return token{kind: tokenLParen, text: "(", pos: start}, nil
+	case c == ')':
+		lx.pos++
+		lx.closeDepth()
+		return token{kind: tokenRParen, text: ")", pos: start}, nil
+	case c == ',':
+		lx.pos++
+		return token{kind: tokenComma, text: ",", pos: start}, nil
+	case c == '[':
+		lx.pos++
+		lx.depth++
+		return token{kind: tokenLBracket, text: "[", pos: start}, nil
+	case c == ']':
+		lx.pos++
+		lx.closeDepth()
+		return token{kind: tokenRBracket, text: "]", pos: start}, nil
+	case strings.IndexByte(builtinOperatorChars, c) >= 0 || strings.IndexByte(lx.operators, c) >= 0:
+		lx.pos++
+		switch {
+		case c == '?':
+			lx.ternaries = append(lx.ternaries, lx.depth)
+		case c == ':' && lx.inTernary():
+			lx.ternaries = lx.ternaries[:len(lx.ternaries)-1]
+		}
+		return token{kind: tokenOperator, text: lx.input[start:lx.pos], pos: start}, nil
+	default:
+		return token{}, fmt.Errorf("unexpected character %q at position %d", c, start-lx.lineStart+1)
+	}
+}
+
+// closeDepth leaves a parenthesis or bracket, dropping the "?" opened inside it
+func (lx *lexer) closeDepth() {
+	if lx.depth > 0 {
+		lx.depth--
+	}
+	for len(lx.ternaries) > 0 && lx.ternaries[len(lx.ternaries)-1] > lx.depth {
+		lx.ternaries = lx.ternaries[:len(lx.ternaries)-1]
+	}
+}
+
+// inTernary reports whether the lexer is in the first branch of a "?" at the
+// current depth, where the next ":" ends that branch
+func (lx *lexer) inTernary() bool {
+	return len(lx.ternaries) > 0 && lx.ternaries[len(lx.ternaries)-1] == lx.depth
+}
+
+// scriptToken skips the blanks and comments of a script and returns a token
+// only scripts have, with ok false when the next token is an ordinary one
+func (lx *lexer) scriptToken() (tok token, ok bool, err error) {
+	if lx.newline {
+		lx.line++
+		lx.lineStart = lx.pos
+		lx.newline = false
+	}
+	for lx.pos < len(lx.input) {
+		c := lx.input[lx.pos]
+		switch {
+		case c == '\n' && lx.depth == 0:
+			lx.pos++
+			lx.newline = true
+			lx.ternaries = lx.ternaries[:0]
+			return token{kind: tokenNewline, text: "\n", pos: lx.pos - 1}, true, nil
+		case c == '\n':
+			lx.pos++
+			lx.line++
+			lx.lineStart = lx.pos
+		case isSpace(c):
+			lx.pos++
+		case c == '#':
+			for lx.pos < len(lx.input) && lx.input[lx.pos] != '\n' {
+				lx.pos++
+			}
+		case c == ';':
+			lx.pos++
+			lx.ternaries = lx.ternaries[:0]
+			return token{kind: tokenNewline, text: ";", pos: lx.pos - 1}, true, nil
+		case c == '{':
+			lx.pos++
+			return token{kind: tokenLBrace, text: "{", pos: lx.pos - 1}, true, nil
+		case c == '}':
+			lx.pos++
+			return token{kind: tokenRBrace, text: "}", pos: lx.pos - 1}, true, nil
+		case c == '"':
+			start := lx.pos
+			end := strings.IndexAny(lx.input[start+1:], "\"\n")
+			if end < 0 || lx.input[start+1+end] != '"' {
+				return token{}, false, fmt.Errorf("unterminated string at position %d", start-lx.lineStart+1)
+			}
+			lx.pos = start + end + 2
+			return token{kind: tokenString, text: lx.input[start+1 : start+1+end], pos: start}, true, nil
+		default:
+			return token{}, false, nil
+		}
+	}
+	return token{}, false, nil
+}
+
+// scanNumber reads a decimal literal, turning a trailing "i" into an imaginary
+// literal. Digits shaped like a date (2026-10-17), a time of day (17:30) or a
+// compound duration (3h25m) are returned as the corresponding literal instead
+func (lx *lexer) scanNumber() (token, error) {
+	start := lx.pos
+	if end := lx.matchDate(start); end > start {
+		return lx.literal(tokenDate, start, end), nil
+	}
+	if end := lx.matchTime(start); end > start {
+		if lx.inTernary() {
+			end = lx.ternaryTime(start, end)
+		}
+		if end > start {
+			return lx.literal(tokenTime, start, end), nil
+		}
+	}
+	if end := lx.matchDuration(start); end > start {
+		return lx.literal(tokenDuration, start, end), nil
+	}
+
+	for lx.pos < len(lx.input) && isDigit(lx.input[lx.pos]) {
+		lx.pos++
+	}
+	if lx.pos < len(lx.input) && lx.input[lx.pos] == '.' {
+		lx.pos++
+		if lx.pos >= len(lx.input) || !isDigit(lx.input[lx.pos]) {
+			return token{}, fmt.Errorf("invalid number format: %s", lx.input[start:lx.pos])
+		}
+		for lx.pos < len(lx.input) && isDigit(lx.input[lx.pos]) {
+			lx.pos++
+		}
+	}
+
+	text := lx.input[start:lx.pos]
+	if lx.pos < len(lx.input) && lx.input[lx.pos] == 'i' &&
+		(lx.pos+1 >= len(lx.input) || !isIdentPart(lx.input[lx.pos+1])) {
+		lx.pos++
+		return token{kind: tokenImaginary, text: text, pos: start}, nil
+	}
+	return token{kind: tokenNumber, text: text, pos: start}, nil
+}
+
+// literal consumes the input up to end as a token of the given kind
+func (lx *lexer) literal(kind tokenKind, start, end int) token {
+	lx.pos = end
+	return token{kind: kind, text: lx.input[start:end], pos: start}
+}
+
+// matchDate returns the end of a YYYY-MM-DD[THH:MM[:SS][Z|±HH:MM]] literal at i, or i if there is none
+func (lx *lexer) matchDate(i int) int {
+	if !lx.digitsAt(i, 4) || !lx.byteAt(i+4, '-') || !lx.digitsAt(i+5, 2) ||
+		!lx.byteAt(i+7, '-') || !lx.digitsAt(i+8, 2) || lx.digitsAt(i+10, 1) {
+		return i
+	}
+	end := i + 10
+	if !lx.byteAt(end, 'T') {
+		return end
+	}
+	timeEnd := lx.matchTime(end + 1)
+	if timeEnd == end+1 {
+		return end
+	}
+	end = timeEnd
+	switch {
+	case lx.byteAt(end, 'Z'):
+		end++
+	case (lx.byteAt(end, '+') || lx.byteAt(end, '-')) && lx.digitsAt(end+1, 2) &&
+		lx.byteAt(end+3, ':') && lx.digitsAt(end+4, 2):
+		end += 6
+	}
+	return end
+}
+
+// matchTime returns the end of an H:MM or HH:MM[:SS] literal at i, or i if there is none
+func (lx *lexer) matchTime(i int) int {
+	hours := 2
+	if !lx.digitsAt(i, 2) {
+		hours = 1
+	}
+	if !lx.digitsAt(i, hours) || !lx.byteAt(i+hours, ':') || !lx.digitsAt(i+hours+1, 2) {
+		return i
+	}
+	end := i + hours + 3
+	if lx.byteAt(end, ':') && lx.digitsAt(end+1, 2) {
+		end += 3
+	}
+	if lx.digitsAt(end, 1) {
+		return i
+	}
+	return end
+}
+
+// ternaryTime shortens the time of day from start to end, found in the first
+// branch of a conditional, so that a ":" for the second branch still follows
+// it. 1>0?17:30:00 thus reads as 1>0 ? 17:30 : 00, and 1>0?17:30 as
+// 1>0 ? 17 : 30. It returns start when no time of day fits
+func (lx *lexer) ternaryTime(start, end int) int {
+	for end > start {
+		if lx.closesTernary(end) {
+			return end
+		}
+		if end-start < 7 {
+			return start
+		}
+		// Drop the seconds and try HH:MM
+		end -= 3
+	}
+	return start
+}
+
+// closesTernary reports whether the input from i has the ":" that ends the
+// innermost open "?" branch, skipping nested conditionals and parentheses
+func (lx *lexer) closesTernary(i int) bool {
+	depth, open := 0, 1
+	for ; i < len(lx.input); i++ {
+		switch c := lx.input[i]; {
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			if depth == 0 {
+				return false
+			}
+			depth--
+		case depth > 0:
+		case c == '?':
+			open++
+		case c == ':':
+			if open--; open == 0 {
+				return true
+			}
+		case lx.script && strings.IndexByte("\n;{}", c) >= 0:
+			return false
+		}
+	}
+	return false
+}
+
+// durationUnits are the suffixes allowed in compound duration literals, longest first
+var durationUnits = []string{"ms", "us", "ns", "d", "h", "m", "s"}
+
+// matchDuration returns the end of a compound duration such as 3h25m or
+// 1d2h30m15s at i, or i if there is none. A single component such as 3h is
+// left to the unit parser
+func (lx *lexer) matchDuration(i int) int {
+	pos, components := i, 0
+	for lx.digitsAt(pos, 1) {
+		for lx.digitsAt(pos, 1) {
+			pos++
+		}
+		if lx.byteAt(pos, '.') && lx.digitsAt(pos+1, 1) {
+			pos++
+			for lx.digitsAt(pos, 1) {
+				pos++
+			}
+		}
+		unit := ""
+		for _, candidate := range durationUnits {
+			if strings.HasPrefix(lx.input[pos:], candidate) {
+				unit = candidate
+				break
+			}
+		}
+		if unit == "" {
+			return i
+		}
+		pos += len(unit)
+		components++
+	}
+	if components < 2 || (pos < len(lx.input) && isIdentPart(lx.input[pos])) {
+		return i
+	}
+	return pos
+}
+
+// digitsAt reports whether n digits start at index i
+func (lx *lexer) digitsAt(i, n int) bool {
+	if i+n > len(lx.input) {
+		return false
+	}
+	for j := i; j < i+n; j++ {
+		if !isDigit(lx.input[j]) {
+			return false
+		}
+	}
+	return true
+}
+
+// byteAt reports whether the input has byte c at index i
+func (lx *lexer) byteAt(i int, c byte) bool {
+	return i < len(lx.input) && lx.input[i] == c
+}
+
+// isDecimal reports whether s is a plain decimal number with an optional
+// leading minus sign, such as 42, -0.5 or 123.456
+func isDecimal(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	whole, fraction, hasPoint := strings.Cut(s, ".")
+	if whole == "" || (hasPoint && fraction == "") {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) && s[i] != '.' {
+			return false
+		}
+	}
+	return !strings.Contains(fraction, ".")
+}
+
+// splitFields splits s around runs of whitespace like strings.Fields, storing
+// up to len(fields) of them in fields without allocating. It returns the total
+// number of fields, which may be more than were stored
+func splitFields(s string, fields []string) int {
+	n := 0
+	for i := 0; i < len(s); {
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+		start := i
+		for i < len(s) && !isSpace(s[i]) {
+			i++
+		}
+		if i > start {
+			if n < len(fields) {
+				fields[n] = s[start:i]
+			}
+			n++
+		}
+	}
+	return n
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
@@ -0,0 +1,159 @@
+package calculation
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Names of the limits reported in LimitError.Limit
+const (
+	LimitExpressionLength = "expression length"
+	LimitDepth            = "nesting depth"
+	LimitDigits           = "result digits"
+	LimitExponent         = "exponent"
+	LimitSteps            = "evaluation steps"
+	LimitIterations       = "loop iterations"
+)
+
+// Limits bounds the resources one expression may use, so that untrusted
+// input such as 9^9^9 fails fast instead of running for hours. A zero field
+// means no limit
+type Limits struct {
+	// MaxExpressionLength is the longest expression accepted, in bytes
+	MaxExpressionLength int
+	// MaxDepth is the deepest nesting of operators and function calls
+	MaxDepth int
+	// MaxDigits bounds the magnitude of every intermediate result: its
+	// decimal exponent must lie within ±MaxDigits
+	MaxDigits int
+	// MaxExponent is the largest absolute exponent accepted by ^
+	MaxExponent int
+	// MaxSteps is the most expression nodes evaluated for one expression
+	MaxSteps int
+	// MaxIterations is the most loop iterations one script runs, counting
+	// every pass of every while and for loop
+	MaxIterations int
+}
+
+// DefaultLimits returns the limits every engine starts with, generous enough
+// for any hand-written expression
+func DefaultLimits() Limits {
+	return Limits{
+		MaxExpressionLength: 10000,
+		MaxDepth:            100,
+		MaxDigits:           10000,
+		MaxExponent:         1000000,
+		MaxSteps:            100000,
+		MaxIterations:       1000000,
+	}
+}
+
+// LimitError reports an expression that exceeded one of the engine's Limits
+type LimitError struct {
+	// Limit names the limit, e.g. LimitDepth
+	Limit string
+	// Max is the configured maximum
+	Max int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s limit exceeded (maximum %d)", e.Limit, e.Max)
+}
+
+// WithLimits replaces the engine's resource limits
+func WithLimits(limits Limits) EngineOption {
+	return func(ce *CalculationEngine) {
+		ce.limits = limits
+	}
+}
+
+// checkLength enforces MaxExpressionLength
+func (l Limits) checkLength(expression string) error {
+	if l.MaxExpressionLength > 0 && len(expression) > l.MaxExpressionLength {
+		return &LimitError{Limit: LimitExpressionLength, Max: l.MaxExpressionLength}
+	}
+	return nil
+}
+
+// checkDepth enforces MaxDepth
+func (l Limits) checkDepth(depth int) error {
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return &LimitError{Limit: LimitDepth, Max: l.MaxDepth}
+	}
+	return nil
+}
+
+// checkExponent enforces MaxExponent on the right operand of ^
+func (l Limits) checkExponent(exponent Value) error {
+	n, ok := exponent.(Number)
+	if !ok || l.MaxExponent <= 0 {
+		return nil
+	}
+	if new(big.Float).Abs(n.Value).Cmp(big.NewFloat(float64(l.MaxExponent))) > 0 {
+		return &LimitError{Limit: LimitExponent, Max: l.MaxExponent}
+	}
+	return nil
+}
+
+// checkDigits enforces MaxDigits on the numeric parts of a result. An
+// infinite part, which only an overflow produces, always exceeds it, and is
+// rejected even when MaxDigits is zero
+func (l Limits) checkDigits(v Value) error {
+	var parts []*big.Float
+	switch v := v.(type) {
+	case Number:
+		parts = []*big.Float{v.Value}
+	case Complex:
+		parts = []*big.Float{v.Re, v.Im}
+	case Quantity:
+		parts = []*big.Float{v.Value}
+	case Percent:
+		parts = []*big.Float{v.Value}
+	case Duration:
+		parts = []*big.Float{v.Seconds}
+	case Money:
+		if l.MaxDigits <= 0 {
+			break
+		}
+		// The binary exponent of a fraction is about the difference of the
+		// bit lengths of its numerator and denominator
+		if v.Amount.Sign() != 0 {
+			exp := v.Amount.Num().BitLen() - v.Amount.Denom().BitLen()
+			if exp > l.maxBinaryExp() || -exp > l.maxBinaryExp() {
+				return &LimitError{Limit: LimitDigits, Max: l.MaxDigits}
+			}
+		}
+	}
+	if l.MaxDigits <= 0 {
+		// Without a limit only overflow to infinity is an error
+		for _, f := range parts {
+			if f.IsInf() {
+				return errResultOverflow
+			}
+		}
+		return nil
+	}
+	if exceedsExp(l.maxBinaryExp(), parts...) {
+		return &LimitError{Limit: LimitDigits, Max: l.MaxDigits}
+	}
+	return nil
+}
+
+// maxBinaryExp returns the largest binary exponent MaxDigits allows, or
+// maxSafeExp when there is no limit
+func (l Limits) maxBinaryExp() int {
+	if l.MaxDigits <= 0 {
+		return maxSafeExp
+	}
+	// A binary exponent of e is about e * log10(2) decimal digits
+	return int(float64(l.MaxDigits) / 0.30103)
+}
+
+// overflowError reports a result beyond maxBinaryExp: a LimitDigits error,
+// or a plain overflow when there is no limit
+func (l Limits) overflowError() error {
+	if l.MaxDigits <= 0 {
+		return errOverflow
+	}
+	return &LimitError{Limit: LimitDigits, Max: l.MaxDigits}
+}
@@ -0,0 +1,288 @@
+package calculation
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// DefaultTolerance is the relative tolerance of ~= unless WithTolerance
+// changes it
+const DefaultTolerance = 1e-9
+
+// WithTolerance sets the tolerance of ~=: two values are approximately equal
+// when they differ by at most tolerance times the larger of them, or by at
+// most tolerance itself when both are close to zero
+func WithTolerance(tolerance float64) EngineOption {
+	return func(ce *CalculationEngine) {
+		ce.tolerance = big.NewFloat(tolerance)
+	}
+}
+
+// logicOperators are the built-in comparison and logical operators. && and
+// || are evaluated lazily by the evaluator, so their right operand is skipped
+// when the left one decides the result
+var logicOperators = []Operator{
+	{Symbol: "==", Precedence: PrecedenceComparison, Arity: 2, Binary: comparison("==")},
+	{Symbol: "!=", Precedence: PrecedenceComparison, Arity: 2, Binary: comparison("!=")},
+	{Symbol: "<", Precedence: PrecedenceComparison, Arity: 2, Binary: comparison("<")},
+	{Symbol: "<=", Precedence: PrecedenceComparison, Arity: 2, Binary: comparison("<=")},
+	{Symbol: ">", Precedence: PrecedenceComparison, Arity: 2, Binary: comparison(">")},
+	{Symbol: ">=", Precedence: PrecedenceComparison, Arity: 2, Binary: comparison(">=")},
+	{Symbol: "~=", Precedence: PrecedenceComparison, Arity: 2, Binary: func(ce *CalculationEngine, left, right Value) (Value, error) {
+		ev := evaluator{engine: ce}
+		return ev.approxEqual(left, right)
+	}},
+	{Symbol: "&&", Precedence: PrecedenceAnd, Arity: 2, Binary: logical("&&")},
+	{Symbol: "||", Precedence: PrecedenceOr, Arity: 2, Binary: logical("||")},
+	{Symbol: "!", Precedence: PrecedencePrefix, Arity: 1, Unary: func(_ *CalculationEngine, v Value) (Value, error) {
+		b, err := truth(v)
+		if err != nil {
+			return nil, err
+		}
+		return Bool{Value: !b}, nil
+	}},
+}
+
+// isLogicOperator reports whether symbol is a built-in comparison or logical
+// infix operator, which returns true or false rather than a number
+func isLogicOperator(symbol string) bool {
+	for _, op := range logicOperators {
+		if op.Arity == 2 && op.Symbol == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// comparison returns the implementation of a comparison operator
+func comparison(op string) func(*CalculationEngine, Value, Value) (Value, error) {
+	return func(ce *CalculationEngine, left, right Value) (Value, error) {
+		ev := evaluator{engine: ce}
+		return ev.compare(op, left, right)
+	}
+}
+
+// logical returns the eager implementation of && or ||, used when the
+// operator is applied to values that are already evaluated
+func logical(op string) func(*CalculationEngine, Value, Value) (Value, error) {
+	return func(_ *CalculationEngine, left, right Value) (Value, error) {
+		a, err := truth(left)
+		if err != nil {
+			return nil, err
+		}
+		b, err := truth(right)
+		if err != nil {
+			return nil, err
+		}
+		if op == "&&" {
+			return Bool{Value: a && b}, nil
+		}
+		return Bool{Value: a || b}, nil
+	}
+}
+
+// truth returns the value of a boolean operand. Numbers are not treated as
+// true or false, so "if x" must be written "if x != 0"
+func truth(v Value) (bool, error) {
+	b, ok := v.(Bool)
+	if !ok {
+		return false, fmt.Errorf("expected true or false, got %s", v.Kind())
+	}
+	return b.Value, nil
+}
+
+// shortCircuit evaluates left && right or left || right, evaluating right
+// only when left does not decide the result
+func (ev *evaluator) shortCircuit(op string, left Value, right node) (Value, error) {
+	a, err := truth(left)
+	if err != nil {
+		return nil, err
+	}
+	if a == (op == "||") {
+		return Bool{Value: a}, nil
+	}
+	value, err := ev.eval(right)
+	if err != nil {
+		return nil, err
+	}
+	b, err := truth(value)
+	if err != nil {
+		return nil, err
+	}
+	return Bool{Value: b}, nil
+}
+
+// compare applies a comparison operator. Values of different units,
+// currencies or calendar types are compared by subtracting them, so 1 km > 900 m
+// and 1 EUR < 2 USD follow the usual conversions. Complex numbers, text and
+// booleans only support == and !=
+func (ev *evaluator) compare(op string, left, right Value) (Value, error) {
+	equality := op == "==" || op == "!="
+	var cmp int
+	switch l := left.(type) {
+	case Bool:
+		r, ok := right.(Bool)
+		if !ok || !equality {
+			return nil, cannotCompare(op, left, right)
+		}
+		return Bool{Value: (l == r) == (op == "==")}, nil
+	case Text:
+		r, ok := right.(Text)
+		if !ok {
+			return nil, cannotCompare(op, left, right)
+		}
+		cmp = strings.Compare(l.Value, r.Value)
+	default:
+		_, leftIsComplex := left.(Complex)
+		_, rightIsComplex := right.(Complex)
+		if leftIsComplex || rightIsComplex {
+			if !equality {
+				return nil, cannotCompare(op, left, right)
+			}
+			a, errA := asComplex(left)
+			b, errB := asComplex(right)
+			if errA != nil || errB != nil {
+				return nil, cannotCompare(op, left, right)
+			}
+			equal := a.Re.Cmp(b.Re) == 0 && a.Im.Cmp(b.Im) == 0
+			return Bool{Value: equal == (op == "==")}, nil
+		}
+		sign, err := ev.differenceSign(left, right)
+		if err != nil {
+			return nil, cannotCompare(op, left, right)
+		}
+		cmp = sign
+	}
+
+	var result bool
+	switch op {
+	case "==":
+		result = cmp == 0
+	case "!=":
+		result = cmp != 0
+	case "<":
+		result = cmp < 0
+	case "<=":
+		result = cmp <= 0
+	case ">":
+		result = cmp > 0
+	case ">=":
+		result = cmp >= 0
+	default:
+		return nil, fmt.Errorf("unsupported operator: %s", op)
+	}
+	return Bool{Value: result}, nil
+}
+
+// differenceSign returns the sign of left - right for values that can be
+// ordered. A percentage is only compared with another percentage, since
+// "80 - 10%" means a discount rather than a difference
+func (ev *evaluator) differenceSign(left, right Value) (int, error) {
+	if a, ok := left.(Number); ok {
+		if b, ok := right.(Number); ok {
+			return a.Value.Cmp(b.Value), nil
+		}
+	}
+	_, leftIsPercent := left.(Percent)
+	_, rightIsPercent := right.(Percent)
+	if leftIsPercent != rightIsPercent {
+		return 0, fmt.Errorf("percentage compared with %s", right.Kind())
+	}
+
+	diff, err := ev.arithmetic("-", left, right)
+	if err != nil {
+		return 0, err
+	}
+	switch d := diff.(type) {
+	case Number:
+		return d.Value.Sign(), nil
+	case Percent:
+		return d.Value.Sign(), nil
+	case Quantity:
+		return d.Value.Sign(), nil
+	case Money:
+		return d.Amount.Sign(), nil
+	case Duration:
+		return d.Seconds.Sign(), nil
+	}
+	return 0, fmt.Errorf("cannot order %s", diff.Kind())
+}
+
+// approxEqual applies ~=. The difference of the values is compared with the
+// engine's tolerance scaled by their magnitudes, so 0.1 * 3 ~= 0.3 and
+// 1 km ~= 1000.0000001 m. Text and booleans are compared exactly
+func (ev *evaluator) approxEqual(left, right Value) (Value, error) {
+	switch left.(type) {
+	case Bool, Text:
+		return ev.compare("==", left, right)
+	}
+
+	var diff, leftSize, rightSize *big.Float
+	_, leftIsComplex := left.(Complex)
+	_, rightIsComplex := right.(Complex)
+	if leftIsComplex || rightIsComplex {
+		a, errA := asComplex(left)
+		b, errB := asComplex(right)
+		if errA != nil || errB != nil {
+			return nil, cannotCompare("~=", left, right)
+		}
+		diff, leftSize, rightSize = complexSub(a, b).Abs(), a.Abs(), b.Abs()
+	} else {
+		_, leftIsPercent := left.(Percent)
+		_, rightIsPercent := right.(Percent)
+		if leftIsPercent != rightIsPercent {
+			return nil, cannotCompare("~=", left, right)
+		}
+		difference, err := ev.arithmetic("-", left, right)
+		if err != nil {
+			return nil, cannotCompare("~=", left, right)
+		}
+		d, ok := magnitude(difference)
+		if !ok {
+			return nil, cannotCompare("~=", left, right)
+		}
+		// The difference is in the unit or currency of left, so the size of
+		// right in that unit is left - difference
+		leftSize = newFloat()
+		if l, ok := magnitude(left); ok {
+			leftSize = l
+		}
+		diff = newFloat().Abs(d)
+		rightSize = newFloat().Abs(newFloat().Sub(leftSize, d))
+		leftSize.Abs(leftSize)
+	}
+
+	bound := newFloat().Set(leftSize)
+	if rightSize.Cmp(bound) > 0 {
+		bound.Set(rightSize)
+	}
+	bound.Mul(bound, ev.engine.tolerance)
+	if bound.Cmp(ev.engine.tolerance) < 0 {
+		bound.Set(ev.engine.tolerance)
+	}
+	return Bool{Value: diff.Cmp(bound) <= 0}, nil
+}
+
+// magnitude returns the signed size of a number, percentage, quantity,
+// currency amount or duration in its own unit
+func magnitude(v Value) (*big.Float, bool) {
+	switch v := v.(type) {
+	case Number:
+		return newFloat().Set(v.Value), true
+	case Percent:
+		return newFloat().Set(v.Value), true
+	case Quantity:
+		return newFloat().Set(v.Value), true
+	case Money:
+		return newFloat().SetRat(v.Amount), true
+	case Duration:
+		return newFloat().Set(v.Seconds), true
+	}
+	return nil, false
+}
+
+// cannotCompare reports operands a comparison does not apply to
+func cannotCompare(op string, left, right Value) error {
+	return fmt.Errorf("cannot compare %s and %s with %s", left.Kind(), right.Kind(), op)
+}
@@ -0,0 +1,182 @@
+package calculation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DefaultRegister is the memory register used by M+, M-, MR and MC when no
+// register is named
+const DefaultRegister = "M"
+
+// Memory holds calculator memory registers: the main register M plus any
+// number of numbered (M1, M2) or named (tax) registers. Registers hold plain
+// numbers at full working precision and can be read in expressions by name
+type Memory struct {
+	registers map[string]*big.Float
+}
+
+// memoryFile is the JSON layout of a saved memory file. Values are decimal
+// strings so that no precision is lost
+type memoryFile struct {
+	Registers map[string]string `json:"registers"`
+}
+
+// NewMemory creates a memory with every register cleared
+func NewMemory() *Memory {
+	return &Memory{registers: map[string]*big.Float{}}
+}
+
+// Store replaces a register's contents (MS)
+func (m *Memory) Store(name string, v Value) error {
+	return m.update(name, v, func(_, value *big.Float) *big.Float {
+		return value
+	})
+}
+
+// Add adds a value to a register (M+)
+func (m *Memory) Add(name string, v Value) error {
+	return m.update(name, v, func(current, value *big.Float) *big.Float {
+		return newFloat().Add(current, value)
+	})
+}
+
+// Subtract subtracts a value from a register (M-)
+func (m *Memory) Subtract(name string, v Value) error {
+	return m.update(name, v, func(current, value *big.Float) *big.Float {
+		return newFloat().Sub(current, value)
+	})
+}
+
+// Recall returns a register's contents (MR). A cleared register holds zero
+func (m *Memory) Recall(name string) Value {
+	if value, ok := m.registers[registerName(name)]; ok {
+		return Number{Value: value}
+	}
+	return Number{Value: newFloat()}
+}
+
+// Clear empties one register (MC)
+func (m *Memory) Clear(name string) {
+	delete(m.registers, registerName(name))
+}
+
+// ClearAll empties every register
+func (m *Memory) ClearAll() {
+	m.registers = map[string]*big.Float{}
+}
+
+// Names returns the names of the registers in use, in order
+func (m *Memory) Names() []string {
+	names := make([]string, 0, len(m.registers))
+	for name := range m.registers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Variables returns the registers in use as variables for EvaluateWithVariables
+func (m *Memory) Variables() map[string]Value {
+	vars := make(map[string]Value, len(m.registers))
+	for name, value := range m.registers {
+		vars[name] = Number{Value: value}
+	}
+	return vars
+}
+
+// Clone returns an independent copy of the memory
+func (m *Memory) Clone() *Memory {
+	clone := NewMemory()
+	for name, value := range m.registers {
+		clone.registers[name] = value
+	}
+	return clone
+}
+
+// Save writes the registers to a JSON file, creating its directory if needed
+func (m *Memory) Save(path string) error {
+	file := memoryFile{Registers: make(map[string]string, len(m.registers))}
+	for name, value := range m.registers {
+		file.Registers[name] = value.Text('g', -1)
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode memory: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create memory directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write memory: %w", err)
+	}
+	return nil
+}
+
+// LoadMemory reads registers saved by Save. A missing file gives an empty memory
+func LoadMemory(path string) (*Memory, error) {
+	memory := NewMemory()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return memory, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read memory: %w", err)
+	}
+
+	var file memoryFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid memory file %s: %w", path, err)
+	}
+	for name, text := range file.Registers {
+		if err := ValidateVariableName(name); err != nil {
+			return nil, fmt.Errorf("invalid memory file %s: %w", path, err)
+		}
+		value, _, err := big.ParseFloat(text, 10, precisionBits, big.ToNearestEven)
+		if err != nil {
+			return nil, fmt.Errorf("invalid memory file %s: register %s: invalid number %q", path, name, text)
+		}
+		memory.registers[name] = value
+	}
+	return memory, nil
+}
+
+// update combines a register's current contents with a value
+func (m *Memory) update(name string, v Value, combine func(current, value *big.Float) *big.Float) error {
+	name = registerName(name)
+	if err := ValidateVariableName(name); err != nil {
+		return fmt.Errorf("invalid memory register: %w", err)
+	}
+	// "2 km" reads km as a unit, so a register of that name could not be
+	// used after a number
+	switch {
+	case isUnit(name):
+		return fmt.Errorf("invalid memory register: %q is a unit", name)
+	case isCurrency(name):
+		return fmt.Errorf("invalid memory register: %q is a currency code", name)
+	}
+	n, ok := v.(Number)
+	if !ok {
+		return fmt.Errorf("memory registers hold plain numbers, got %s", v.Kind())
+	}
+
+	current, ok := m.registers[name]
+	if !ok {
+		current = newFloat()
+	}
+	m.registers[name] = combine(current, n.Value)
+	return nil
+}
+
+// registerName maps an empty register name to the main register
+func registerName(name string) string {
+	if name == "" {
+		return DefaultRegister
+	}
+	return name
+}
@@ -0,0 +1,506 @@
+package calculation
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// node is an element of a parsed expression tree
+type node interface{}
+
+// numberNode is a real or imaginary numeric literal
+type numberNode struct {
+	text      string
+	imaginary bool
+}
+
+// identNode is a bare identifier such as the imaginary unit "i"
+type identNode struct {
+	name string
+}
+
+// unaryNode applies a prefix operator to its operand
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+// binaryNode applies an infix operator to two operands
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+// callNode is a function call such as sqrt(x)
+type callNode struct {
+	name string
+	args []node
+}
+
+// percentNode applies the postfix percent operator, e.g. 15%
+type percentNode struct {
+	operand node
+}
+
+// quantityNode is a numeric literal followed by a unit, e.g. 9.81 m/s^2
+type quantityNode struct {
+	value node
+	unit  []unitTerm
+}
+
+// moneyNode is a numeric literal tagged with an ISO 4217 currency code, e.g. 120 EUR
+type moneyNode struct {
+	text     string
+	currency string
+}
+
+// dateNode is a date or date-time literal, e.g. 2026-10-17 or 2026-10-17T14:30
+type dateNode struct {
+	text string
+}
+
+// timeNode is a time-of-day literal, e.g. 17:30
+type timeNode struct {
+	text string
+}
+
+// durationNode is a compound duration literal, e.g. 3h25m
+type durationNode struct {
+	text string
+}
+
+// conditionalNode is cond ? then : els or if(cond, then, els). Only the
+// branch that cond selects is evaluated
+type conditionalNode struct {
+	cond, then, els node
+}
+
+// listNode is a list literal such as [1, 2, 3]
+type listNode struct {
+	items []node
+}
+
+// conversionNode converts a value to a target unit with "to" or "in"
+type conversionNode struct {
+	value  node
+	target []unitTerm
+}
+
+// parser is a recursive-descent parser over the lexer's token stream. Prefix
+// and infix operators come from a Registry and are parsed by precedence
+// climbing
+//
+// Grammar:
+//
+//	expression  = conditional { ("to" | "in") unit }
+//	conditional = operators [ "?" conditional ":" conditional ]
+//	operators   = prefix { infix prefix }
+//	prefix      = prefix-operator operators | postfix
+//	infix       = infix-operator | "of" | "as" "%" "of"
+//	postfix     = primary { "%" }
+//	primary     = number [ unit | currency ] | imaginary | date [ time ] | time | duration | ident [ "(" [ conditional { "," conditional } ] ")" ] | "(" conditional ")" | list
+//	list        = "[" [ conditional { "," conditional } ] "]"
+//	unit        = ident [ "^" ["-"] integer ] { ("*" | "/") ident [ "^" ["-"] integer ] }
+//
+// The operand of a prefix operator and the right operand of an infix operator
+// extend over the operators that bind more tightly, so with the built-ins
+// -2^2 is -(2^2) and 2^3^2 is 2^(3^2). The call if(cond, a, b) is parsed as
+// the conditional cond ? a : b
+type parser struct {
+	tokens   []token
+	pos      int
+	registry *Registry
+}
+
+// percentPhrases are the infix operators "of" and "as % of", which are parsed
+// as keywords rather than looked up in the registry
+var (
+	ofOperator = &registeredOperator{
+		Operator: Operator{Symbol: "of", Precedence: PrecedenceMultiplicative, Arity: 2},
+		pieces:   []symbolPiece{{text: "of"}},
+	}
+	asPercentOfOperator = &registeredOperator{
+		Operator: Operator{Symbol: "as % of", Precedence: PrecedenceMultiplicative, Arity: 2},
+		pieces:   []symbolPiece{{text: "as"}, {text: "%"}, {text: "of"}},
+	}
+)
+
+// parseExpression parses a complete expression into a tree, using the
+// operators of the given registry
+func parseExpression(input string, registry *Registry) (node, error) {
+	// Most expressions fit in this buffer, which then never leaves the stack
+	var buf [32]token
+	tokens, err := tokenize(input, registry.chars, buf[:0])
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, registry: registry}
+	if p.peek().kind == tokenEOF {
+		return nil, fmt.Errorf("expression cannot be empty")
+	}
+
+	n, err := p.parseConversion()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos+1)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) advance() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// isOperator reports whether the next token is one of the given operators
+func (p *parser) isOperator(ops ...string) bool {
+	tok := p.peek()
+	if tok.kind != tokenOperator {
+		return false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+// isKeyword reports whether the next token is the given keyword identifier
+func (p *parser) isKeyword(word string) bool {
+	tok := p.peek()
+	return tok.kind == tokenIdent && tok.text == word
+}
+
+// parseConversion parses a conditional optionally followed by "to"/"in" and a target unit
+func (p *parser) parseConversion() (node, error) {
+	value, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("to") || p.isKeyword("in") {
+		keyword := p.advance()
+		if p.peek().kind != tokenIdent {
+			return nil, fmt.Errorf("expected unit after %q", keyword.text)
+		}
+		target, err := p.parseUnit(false)
+		if err != nil {
+			return nil, err
+		}
+		value = conversionNode{value: value, target: target}
+	}
+	return value, nil
+}
+
+// parseConditional parses operators optionally followed by "? then : else".
+// It groups to the right, so a ? b : c ? d : e needs no parentheses
+func (p *parser) parseConditional() (node, error) {
+	cond, err := p.parseOperators(0)
+	if err != nil || !p.isOperator("?") {
+		return cond, err
+	}
+	p.advance()
+	then, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOperator(":") {
+		return nil, fmt.Errorf("expected ':' after '?' branch")
+	}
+	p.advance()
+	els, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	return conditionalNode{cond: cond, then: then, els: els}, nil
+}
+
+// parseOperators parses operands joined by infix operators that bind at least
+// as tightly as minPrecedence
+func (p *parser) parseOperators(minPrecedence int) (node, error) {
+	left, err := p.parsePrefix()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, err := p.infixOperator()
+		if err != nil {
+			return nil, err
+		}
+		if op == nil || op.Precedence < minPrecedence {
+			return left, nil
+		}
+		p.pos += len(op.pieces)
+
+		next := op.Precedence + 1
+		if op.Associativity == RightAssociative {
+			next = op.Precedence
+		}
+		right, err := p.parseOperators(next)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op.Symbol, left: left, right: right}
+	}
+}
+
+// parsePrefix parses an operand, with any prefix operators applied to it
+func (p *parser) parsePrefix() (node, error) {
+	op := p.matchOperator(p.registry.prefix)
+	if op == nil {
+		return p.parsePostfix()
+	}
+	p.pos += len(op.pieces)
+	operand, err := p.parseOperators(op.Precedence)
+	if err != nil {
+		return nil, err
+	}
+	return unaryNode{op: op.Symbol, operand: operand}, nil
+}
+
+// infixOperator returns the infix operator at the current token, or nil
+func (p *parser) infixOperator() (*registeredOperator, error) {
+	switch {
+	case p.isKeyword("of"):
+		return ofOperator, nil
+	case p.isKeyword("as"):
+		if !p.matchesPieces(asPercentOfOperator.pieces) {
+			return nil, fmt.Errorf("expected '%% of' after 'as'")
+		}
+		return asPercentOfOperator, nil
+	}
+	return p.matchOperator(p.registry.infix), nil
+}
+
+// matchOperator returns the operator of the table whose symbol is spelled by
+// the tokens at the current position, preferring the longest
+func (p *parser) matchOperator(table operatorTable) *registeredOperator {
+	tok := p.peek()
+	if tok.kind != tokenOperator && tok.kind != tokenIdent {
+		return nil
+	}
+	for _, op := range table.byFirst[tok.text] {
+		if p.matchesPieces(op.pieces) {
+			return op
+		}
+	}
+	return nil
+}
+
+// matchesPieces reports whether the tokens at the current position spell an
+// operator symbol, with no space where the symbol has none
+func (p *parser) matchesPieces(pieces []symbolPiece) bool {
+	for i, piece := range pieces {
+		tok := p.tokens[p.pos+i]
+		if (tok.kind != tokenOperator && tok.kind != tokenIdent) || tok.text != piece.text {
+			return false
+		}
+		if i > 0 && piece.glued {
+			if prev := p.tokens[p.pos+i-1]; tok.pos != prev.pos+len(prev.text) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// parsePostfix parses a primary followed by any number of postfix % operators
+func (p *parser) parsePostfix() (node, error) {
+	operand, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("%") {
+		p.advance()
+		operand = percentNode{operand: operand}
+	}
+	return operand, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.advance()
+	switch tok.kind {
+	case tokenNumber:
+		number := numberNode{text: tok.text}
+		if next := p.peek(); next.kind == tokenIdent && isCurrency(next.text) {
+			p.advance()
+			return moneyNode{text: tok.text, currency: next.text}, nil
+		}
+		if !p.startsUnit(p.pos) {
+			return number, nil
+		}
+		unit, err := p.parseUnit(true)
+		if err != nil {
+			return nil, err
+		}
+		return quantityNode{value: number, unit: unit}, nil
+	case tokenImaginary:
+		return numberNode{text: tok.text, imaginary: true}, nil
+	case tokenDate:
+		if next := p.peek(); next.kind == tokenTime && len(tok.text) == len("2006-01-02") {
+			p.advance()
+			return dateNode{text: tok.text + "T" + next.text}, nil
+		}
+		return dateNode{text: tok.text}, nil
+	case tokenTime:
+		return timeNode{text: tok.text}, nil
+	case tokenDuration:
+		return durationNode{text: tok.text}, nil
+	case tokenIdent:
+		if p.peek().kind == tokenLParen {
+			p.advance()
+			args, err := p.parseArguments()
+			if err != nil {
+				return nil, err
+			}
+			if tok.text == "if" {
+				if len(args) != 3 {
+					return nil, fmt.Errorf("if expects 3 argument(s), got %d", len(args))
+				}
+				return conditionalNode{cond: args[0], then: args[1], els: args[2]}, nil
+			}
+			return callNode{name: tok.text, args: args}, nil
+		}
+		return identNode{name: tok.text}, nil
+	case tokenLParen:
+		inner, err := p.parseConditional()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokenRParen {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.advance()
+		return inner, nil
+	case tokenLBracket:
+		items, err := p.parseSequence(tokenRBracket, "missing closing bracket in list")
+		if err != nil {
+			return nil, err
+		}
+		return listNode{items: items}, nil
+	case tokenEOF, tokenNewline:
+		return nil, fmt.Errorf("unexpected end of expression")
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos+1)
+	}
+}
+
+// parseArguments parses a comma-separated argument list after "("
+func (p *parser) parseArguments() ([]node, error) {
+	return p.parseSequence(tokenRParen, "missing closing parenthesis in function call")
+}
+
+// parseSequence parses comma-separated conditionals up to and including the
+// closing token, failing with missing if another token ends them
+func (p *parser) parseSequence(closing tokenKind, missing string) ([]node, error) {
+	var items []node
+	if p.peek().kind == closing {
+		p.advance()
+		return items, nil
+	}
+	for {
+		item, err := p.parseConditional()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+
+		switch p.advance().kind {
+		case tokenComma:
+			continue
+		case closing:
+			return items, nil
+		default:
+			return nil, errors.New(missing)
+		}
+	}
+}
+
+// startsUnit reports whether the token at index i begins a unit symbol after a
+// number literal. "in" is read as the conversion keyword when a unit follows
+// it, so "5 in cm" converts while "5 in" and "5 in to cm" are five inches
+func (p *parser) startsUnit(i int) bool {
+	tok := p.tokens[i]
+	if tok.kind != tokenIdent || !isUnit(tok.text) {
+		return false
+	}
+	next := p.tokens[i+1]
+	if next.kind == tokenLParen {
+		return false
+	}
+	if tok.text == "in" && next.kind == tokenIdent && next.text != "to" && next.text != "in" {
+		return false
+	}
+	return true
+}
+
+// parseUnit parses a unit expression such as km/h or kg*m/s^2. In a literal
+// (after a number) it only continues across "*" and "/" when a known unit
+// follows, so "9.81 m/s^2 * 70 kg" splits before the second number
+func (p *parser) parseUnit(literal bool) ([]unitTerm, error) {
+	var terms []unitTerm
+	sign := 1
+	for {
+		tok := p.advance()
+		if tok.kind != tokenIdent {
+			return nil, fmt.Errorf("expected unit at position %d", tok.pos+1)
+		}
+		power := 1
+		if p.isOperator("^") {
+			p.advance()
+			n, err := p.parseUnitPower()
+			if err != nil {
+				return nil, err
+			}
+			power = n
+		}
+		terms = append(terms, unitTerm{symbol: tok.text, power: sign * power})
+
+		if !p.isOperator("*", "/") {
+			return terms, nil
+		}
+		if literal && !p.startsUnit(p.pos+1) {
+			return terms, nil
+		}
+		if !literal && p.tokens[p.pos+1].kind != tokenIdent {
+			return terms, nil
+		}
+		if p.advance().text == "/" {
+			sign = -1
+		} else {
+			sign = 1
+		}
+	}
+}
+
+// parseUnitPower parses the integer exponent of a unit symbol
+func (p *parser) parseUnitPower() (int, error) {
+	negative := false
+	if p.isOperator("-") {
+		p.advance()
+		negative = true
+	}
+	tok := p.advance()
+	if tok.kind != tokenNumber {
+		return 0, fmt.Errorf("unit exponent must be an integer")
+	}
+	n, err := strconv.Atoi(tok.text)
+	if err != nil {
+		return 0, fmt.Errorf("unit exponent must be an integer")
+	}
+	if negative {
+		n = -n
+	}
+	return n, nil
+}
@@ -0,0 +1,114 @@
+package calculation
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// PercentMode selects how "a + b%" and "a - b%" are interpreted
+//
+// In PercentDesk mode (the default) a percentage added to or subtracted from
+// a value is taken relative to that value, as on a desk calculator:
+//
+//	80 + 10%   = 88    (80 + 10% of 80)
+//	50 - 20%   = 40    (50 - 20% of 50)
+//	200 * 15%  = 30
+//	60 / 20%   = 300
+//
+// In PercentMath mode b% is always the number b/100, so 80 + 10% = 80.1.
+// Multiplication and division behave identically in both modes
+type PercentMode string
+
+const (
+	// PercentDesk applies +/- percentages relative to the left operand
+	PercentDesk PercentMode = "desk"
+	// PercentMath treats b% as b/100 everywhere
+	PercentMath PercentMode = "math"
+)
+
+// Percent is a percentage; Value holds the number of percent, so 15% is 15
+type Percent struct {
+	Value *big.Float
+}
+
+// Kind implements Value
+func (p Percent) Kind() string {
+	return "percent"
+}
+
+// String formats the percentage with a trailing % sign
+func (p Percent) String() string {
+	return formatFloat(p.Value) + "%"
+}
+
+// Fraction returns the percentage as a plain fraction, e.g. 15% is 0.15
+func (p Percent) Fraction() Number {
+	return Number{Value: newFloat().Quo(p.Value, floatFromInt(100))}
+}
+
+// percentBinary applies an operator where at least one side is a percentage
+func (ev *evaluator) percentBinary(op string, left, right Value) (Value, error) {
+	lp, leftIsPercent := left.(Percent)
+	rp, rightIsPercent := right.(Percent)
+
+	if leftIsPercent && rightIsPercent && (op == "+" || op == "-") {
+		sum, err := realBinary(op, Number{Value: lp.Value}, Number{Value: rp.Value}, ev.engine.limits)
+		if err != nil {
+			return nil, err
+		}
+		return Percent{Value: sum.(Number).Value}, nil
+	}
+
+	if !leftIsPercent && rightIsPercent && (op == "+" || op == "-") && ev.engine.percentMode != PercentMath {
+		// Desk calculator: a + b% = a + a * b/100
+		delta, err := ev.applyBinary("*", left, rp.Fraction())
+		if err != nil {
+			return nil, err
+		}
+		return ev.applyBinary(op, left, delta)
+	}
+
+	if leftIsPercent {
+		left = lp.Fraction()
+	}
+	if rightIsPercent {
+		right = rp.Fraction()
+	}
+	return ev.applyBinary(op, left, right)
+}
+
+// percentOf evaluates "p% of x"
+func (ev *evaluator) percentOf(left, right Value) (Value, error) {
+	p, ok := left.(Percent)
+	if !ok {
+		return nil, fmt.Errorf("'of' requires a percentage on the left, e.g. 15%% of 200")
+	}
+	return ev.applyBinary("*", right, p.Fraction())
+}
+
+// asPercentOf evaluates "a as % of b", the share of b that a represents
+func (ev *evaluator) asPercentOf(left, right Value) (Value, error) {
+	ratio, err := ev.applyBinary("/", left, right)
+	if err != nil {
+		return nil, err
+	}
+	n, ok := ratio.(Number)
+	if !ok {
+		return nil, fmt.Errorf("'as %% of' requires values of the same kind, got %s and %s", left.Kind(), right.Kind())
+	}
+	return Percent{Value: newFloat().Mul(n.Value, floatFromInt(100))}, nil
+}
+
+// fnPctChange returns the percentage change from old to new: (new - old) / old * 100%
+func fnPctChange(ce *CalculationEngine, args []Value) (Value, error) {
+	ev := &evaluator{engine: ce}
+	diff, err := ev.applyBinary("-", args[1], args[0])
+	if err != nil {
+		return nil, fmt.Errorf("pctchange: %w", err)
+	}
+	change, err := ev.asPercentOf(diff, args[0])
+	if err != nil {
+		return nil, fmt.Errorf("pctchange: %w", err)
+	}
+	return change, nil
+}
@@ -0,0 +1,174 @@
+package calculation
+
+import (
+	"context"
+	"fmt"
+)
+
+// Program is an expression parsed once by Compile that can be evaluated any
+// number of times. Literals, the constants pi, e, i, true and false, and
+// units are resolved and function calls checked at compile time, so Eval
+// only does arithmetic. A Program is not modified by Eval and may be shared
+// between goroutines
+type Program struct {
+	engine     *CalculationEngine
+	expression string
+	tree       node
+}
+
+// literalNode is a constant value resolved at compile time
+type literalNode struct {
+	value Value
+}
+
+// SyntaxError marks an error found while parsing an expression, as opposed to
+// one found while checking or evaluating it
+type SyntaxError struct {
+	Err error
+}
+
+func (e *SyntaxError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// Compile parses and checks an expression for repeated evaluation with
+// Program.Eval. Parse failures are returned as *SyntaxError; invalid literals,
+// unknown units and unknown functions or wrong argument counts are reported
+// here rather than on every Eval, as are expressions longer or more deeply
+// nested than the engine's Limits allow
+func (ce *CalculationEngine) Compile(expression string) (*Program, error) {
+	return ce.compile(expression, nil)
+}
+
+// compile is Compile that also accepts calls to the user-defined functions
+// in vars
+func (ce *CalculationEngine) compile(expression string, vars map[string]Value) (*Program, error) {
+	if err := ce.limits.checkLength(expression); err != nil {
+		return nil, err
+	}
+	tree, err := parseExpression(expression, ce.registry)
+	if err != nil {
+		return nil, &SyntaxError{Err: err}
+	}
+
+	ev := &evaluator{engine: ce, vars: vars}
+	tree, err = ev.compile(tree, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &Program{engine: ce, expression: expression, tree: tree}, nil
+}
+
+// Expression returns the source text the program was compiled from
+func (p *Program) Expression() string {
+	return p.expression
+}
+
+// Eval evaluates the program, resolving bare identifiers from vars
+func (p *Program) Eval(vars map[string]Value) (Value, error) {
+	return p.EvalContext(context.Background(), vars)
+}
+
+// EvalContext is Eval that stops with ctx.Err() once ctx is done
+func (p *Program) EvalContext(ctx context.Context, vars map[string]Value) (Value, error) {
+	ev := &evaluator{engine: p.engine, vars: vars, ctx: ctx}
+	return ev.eval(p.tree)
+}
+
+// compile replaces the literal leaves of a tree with their values and checks
+// function calls and nesting depth, returning a new tree
+func (ev *evaluator) compile(n node, depth int) (node, error) {
+	if err := ev.engine.limits.checkDepth(depth); err != nil {
+		return nil, err
+	}
+	switch n := n.(type) {
+	case numberNode, moneyNode, dateNode, timeNode, durationNode, quantityNode:
+		value, err := ev.eval(n)
+		if err != nil {
+			return nil, err
+		}
+		return literalNode{value: value}, nil
+	case identNode:
+		switch n.name {
+		case "i", "pi", "e", "true", "false":
+			value, err := ev.eval(n)
+			if err != nil {
+				return nil, err
+			}
+			return literalNode{value: value}, nil
+		}
+		return n, nil
+	case unaryNode:
+		operand, err := ev.compile(n.operand, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{op: n.op, operand: operand}, nil
+	case binaryNode:
+		left, err := ev.compile(n.left, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		right, err := ev.compile(n.right, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return binaryNode{op: n.op, left: left, right: right}, nil
+	case conditionalNode:
+		var branches [3]node
+		for i, branch := range []node{n.cond, n.then, n.els} {
+			compiled, err := ev.compile(branch, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			branches[i] = compiled
+		}
+		return conditionalNode{cond: branches[0], then: branches[1], els: branches[2]}, nil
+	case listNode:
+		items := make([]node, len(n.items))
+		for i, item := range n.items {
+			compiled, err := ev.compile(item, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = compiled
+		}
+		return listNode{items: items}, nil
+	case percentNode:
+		operand, err := ev.compile(n.operand, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return percentNode{operand: operand}, nil
+	case conversionNode:
+		value, err := ev.compile(n.value, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return conversionNode{value: value, target: n.target}, nil
+	case callNode:
+		if fn, ok := ev.engine.registry.functions[n.name]; ok {
+			if fn.Arity != Variadic && len(n.args) != fn.Arity {
+				return nil, fmt.Errorf("%s expects %d argument(s), got %d", n.name, fn.Arity, len(n.args))
+			}
+		} else if v, ok := ev.vars[n.name]; !ok {
+			return nil, fmt.Errorf("unknown function: %s", n.name)
+		} else if _, ok := v.(*UserFunction); !ok {
+			return nil, fmt.Errorf("%s is not a function", n.name)
+		}
+		args := make([]node, len(n.args))
+		for i, arg := range n.args {
+			compiled, err := ev.compile(arg, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = compiled
+		}
+		return callNode{name: n.name, args: args}, nil
+	}
+	return n, nil
+}
@@ -0,0 +1,319 @@
+package calculation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Associativity says how a chain of infix operators of equal precedence groups
+type Associativity int
+
+const (
+	// LeftAssociative groups a - b - c as (a - b) - c
+	LeftAssociative Associativity = iota
+	// RightAssociative groups a ^ b ^ c as a ^ (b ^ c)
+	RightAssociative
+)
+
+// Precedences of the built-in operators; higher binds tighter. The "of" and
+// "as % of" percentage phrases bind like PrecedenceMultiplicative, and "to"
+// and "in" conversions bind looser than any operator
+const (
+	PrecedenceOr             = 2
+	PrecedenceAnd            = 4
+	PrecedenceComparison     = 6
+	PrecedenceAdditive       = 10
+	PrecedenceMultiplicative = 20
+	PrecedencePrefix         = 30
+	PrecedencePower          = 40
+)
+
+// Variadic as a Function's Arity accepts any number of arguments
+const Variadic = -1
+
+// operatorPunctuation lists the characters operator symbols may use besides
+// letters, digits and spaces
+const operatorPunctuation = "!#$&*+-/:<=>?@\\^|~"
+
+// builtinOperatorChars are the characters the lexer always reads as
+// operators, including the ? and : of conditionals
+const builtinOperatorChars = "+-*/^%?:"
+
+// Operator describes a prefix or infix operator
+type Operator struct {
+	// Symbol is the operator as written: punctuation such as "<<", a word
+	// such as "mod", or several of these separated by spaces
+	Symbol string
+	// Precedence orders the operator against the others, see PrecedenceAdditive
+	Precedence int
+	// Associativity applies to infix operators
+	Associativity Associativity
+	// Arity is 1 for a prefix operator and 2 for an infix operator
+	Arity int
+	// Unary implements a prefix operator
+	Unary func(ce *CalculationEngine, operand Value) (Value, error)
+	// Binary implements an infix operator
+	Binary func(ce *CalculationEngine, left, right Value) (Value, error)
+}
+
+// Function describes a function callable from expressions, e.g. sqrt(x)
+type Function struct {
+	Name string
+	// Arity is the number of arguments, or Variadic
+	Arity int
+	Call  func(ce *CalculationEngine, args []Value) (Value, error)
+}
+
+// Registry holds the operators and functions an engine understands.
+// NewRegistry returns one holding the built-ins, registered the same way as
+// any other; Go code adds its own and passes the registry to WithRegistry.
+// A Registry is not safe for concurrent modification, but WithRegistry takes
+// a copy, so registering more later does not affect existing engines
+type Registry struct {
+	infix     operatorTable
+	prefix    operatorTable
+	operators []*registeredOperator // in registration order
+	functions map[string]Function
+	// chars holds the punctuation of registered symbols that the lexer does
+	// not already read as operators
+	chars string
+}
+
+// operatorTable indexes the operators of one arity
+type operatorTable struct {
+	bySymbol map[string]*registeredOperator
+	// byFirst lists operators by the first token of their symbol, longest
+	// symbols first so that the parser matches greedily
+	byFirst map[string][]*registeredOperator
+}
+
+// registeredOperator is an operator with its symbol split into tokens
+type registeredOperator struct {
+	Operator
+	pieces []symbolPiece
+}
+
+// symbolPiece is one token of an operator symbol
+type symbolPiece struct {
+	text string
+	// glued means the token follows the previous one without a space
+	glued bool
+}
+
+// defaultRegistry holds the built-ins used by engines without WithRegistry
+var defaultRegistry = NewRegistry()
+
+// NewRegistry creates a registry holding the built-in operators and functions
+func NewRegistry() *Registry {
+	r := &Registry{
+		infix:     newOperatorTable(),
+		prefix:    newOperatorTable(),
+		functions: map[string]Function{},
+	}
+	for _, op := range builtinOperators {
+		if err := r.RegisterOperator(op); err != nil {
+			panic(err)
+		}
+	}
+	for _, op := range logicOperators {
+		if err := r.RegisterOperator(op); err != nil {
+			panic(err)
+		}
+	}
+	for _, fn := range builtinFunctions {
+		if err := r.RegisterFunction(fn); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+func newOperatorTable() operatorTable {
+	return operatorTable{bySymbol: map[string]*registeredOperator{}, byFirst: map[string][]*registeredOperator{}}
+}
+
+// WithRegistry makes the engine use a copy of the registry's operators and
+// functions instead of the built-ins alone
+func WithRegistry(r *Registry) EngineOption {
+	return func(ce *CalculationEngine) {
+		ce.registry = r.clone()
+	}
+}
+
+// RegisterOperator adds an operator. The symbol must not already be taken by
+// an operator of the same arity, and a word in it must not be a keyword,
+// unit or currency code
+func (r *Registry) RegisterOperator(op Operator) error {
+	pieces, err := splitSymbol(op.Symbol)
+	if err != nil {
+		return err
+	}
+	if op.Precedence <= 0 {
+		return fmt.Errorf("operator %q: precedence must be positive", op.Symbol)
+	}
+
+	var table *operatorTable
+	switch {
+	case op.Arity == 1 && op.Unary != nil:
+		table = &r.prefix
+	case op.Arity == 2 && op.Binary != nil:
+		table = &r.infix
+	default:
+		return fmt.Errorf("operator %q: arity 1 needs Unary and arity 2 needs Binary", op.Symbol)
+	}
+
+	symbol := joinPieces(pieces)
+	if _, ok := table.bySymbol[symbol]; ok {
+		return fmt.Errorf("operator %q is already registered", op.Symbol)
+	}
+	op.Symbol = symbol
+	registered := &registeredOperator{Operator: op, pieces: pieces}
+	table.bySymbol[symbol] = registered
+	first := pieces[0].text
+	table.byFirst[first] = append(table.byFirst[first], registered)
+	sort.SliceStable(table.byFirst[first], func(i, j int) bool {
+		return len(table.byFirst[first][i].pieces) > len(table.byFirst[first][j].pieces)
+	})
+	r.operators = append(r.operators, registered)
+
+	for _, p := range pieces {
+		c := p.text[0]
+		if strings.IndexByte(builtinOperatorChars, c) < 0 && strings.IndexByte(r.chars, c) < 0 && !isIdentStart(c) {
+			r.chars += p.text
+		}
+	}
+	return nil
+}
+
+// RegisterFunction adds a function. The name must be a valid identifier that
+// is not a keyword and not already registered
+func (r *Registry) RegisterFunction(fn Function) error {
+	if err := ValidateVariableName(fn.Name); err != nil {
+		return fmt.Errorf("function %q: %w", fn.Name, err)
+	}
+	if fn.Arity < Variadic || fn.Call == nil {
+		return fmt.Errorf("function %q: needs a Call and an arity of 0 or more, or Variadic", fn.Name)
+	}
+	if _, ok := r.functions[fn.Name]; ok {
+		return fmt.Errorf("function %q is already registered", fn.Name)
+	}
+	r.functions[fn.Name] = fn
+	return nil
+}
+
+// Operators returns the registered operators in registration order
+func (r *Registry) Operators() []Operator {
+	ops := make([]Operator, len(r.operators))
+	for i, op := range r.operators {
+		ops[i] = op.Operator
+	}
+	return ops
+}
+
+// Functions returns the names of the registered functions in alphabetical order
+func (r *Registry) Functions() []string {
+	names := make([]string, 0, len(r.functions))
+	for name := range r.functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// clone returns a copy that can be modified independently
+func (r *Registry) clone() *Registry {
+	c := &Registry{
+		infix:     r.infix.clone(),
+		prefix:    r.prefix.clone(),
+		operators: append([]*registeredOperator(nil), r.operators...),
+		functions: make(map[string]Function, len(r.functions)),
+		chars:     r.chars,
+	}
+	for name, fn := range r.functions {
+		c.functions[name] = fn
+	}
+	return c
+}
+
+func (t operatorTable) clone() operatorTable {
+	c := newOperatorTable()
+	for symbol, op := range t.bySymbol {
+		c.bySymbol[symbol] = op
+	}
+	for first, ops := range t.byFirst {
+		c.byFirst[first] = append([]*registeredOperator(nil), ops...)
+	}
+	return c
+}
+
+// splitSymbol splits an operator symbol into the tokens the lexer produces
+// for it: words, and single punctuation characters
+func splitSymbol(symbol string) ([]symbolPiece, error) {
+	var pieces []symbolPiece
+	glued := false
+	for i := 0; i < len(symbol); {
+		c := symbol[i]
+		switch {
+		case c == ' ':
+			glued = false
+			i++
+			continue
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(symbol) && isIdentPart(symbol[j]) {
+				j++
+			}
+			word := symbol[i:j]
+			switch {
+			case word == "to" || word == "in" || word == "of" || word == "as":
+				return nil, fmt.Errorf("operator %q: %q is a keyword", symbol, word)
+			case isUnit(word) || isCurrency(word):
+				return nil, fmt.Errorf("operator %q: %q is a unit or currency", symbol, word)
+			}
+			pieces = append(pieces, symbolPiece{text: word, glued: glued})
+			i = j
+		case strings.IndexByte(operatorPunctuation, c) >= 0:
+			pieces = append(pieces, symbolPiece{text: symbol[i : i+1], glued: glued})
+			i++
+		default:
+			return nil, fmt.Errorf("operator %q: invalid character %q", symbol, c)
+		}
+		glued = true
+	}
+	if len(pieces) == 0 {
+		return nil, fmt.Errorf("operator symbol cannot be empty")
+	}
+	return pieces, nil
+}
+
+// joinPieces writes a split symbol back in its canonical spelling
+func joinPieces(pieces []symbolPiece) string {
+	var b strings.Builder
+	for i, p := range pieces {
+		if i > 0 && !p.glued {
+			b.WriteByte(' ')
+		}
+		b.WriteString(p.text)
+	}
+	return b.String()
+}
+
+// builtinOperators are the operators every registry starts with
+var builtinOperators = []Operator{
+	{Symbol: "+", Precedence: PrecedenceAdditive, Arity: 2, Binary: arithmetic("+")},
+	{Symbol: "-", Precedence: PrecedenceAdditive, Arity: 2, Binary: arithmetic("-")},
+	{Symbol: "*", Precedence: PrecedenceMultiplicative, Arity: 2, Binary: arithmetic("*")},
+	{Symbol: "/", Precedence: PrecedenceMultiplicative, Arity: 2, Binary: arithmetic("/")},
+	{Symbol: "^", Precedence: PrecedencePower, Associativity: RightAssociative, Arity: 2, Binary: arithmetic("^")},
+	{Symbol: "+", Precedence: PrecedencePrefix, Arity: 1, Unary: func(_ *CalculationEngine, v Value) (Value, error) { return v, nil }},
+	{Symbol: "-", Precedence: PrecedencePrefix, Arity: 1, Unary: func(_ *CalculationEngine, v Value) (Value, error) { return negate(v) }},
+}
+
+// arithmetic returns the implementation of a built-in arithmetic operator
+func arithmetic(op string) func(*CalculationEngine, Value, Value) (Value, error) {
+	return func(ce *CalculationEngine, left, right Value) (Value, error) {
+		ev := evaluator{engine: ce}
+		return ev.arithmetic(op, left, right)
+	}
+}
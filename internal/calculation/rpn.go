@@ -0,0 +1,197 @@
+package calculation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RPNStack evaluates Reverse Polish Notation input against a persistent operand stack
+//
+// Input is split on whitespace. Each token is either an operator (any infix
+// operator of the engine's registry, or "%"), which pops its operands and
+// pushes the result, a stack command, or an operand evaluated as an
+// expression (so 15%, 3+4i and 3h25m are all single operands). The stack commands are:
+//
+//	dup    duplicate the top value
+//	swap   exchange the top two values
+//	drop   discard the top value
+//	roll   move the top value to the bottom of the stack
+//	clear  empty the stack
+//	depth  push the number of values on the stack
+//
+// "%" pops a base and a percentage and pushes that percentage of the base,
+// so "200 15 %" leaves 30
+type RPNStack struct {
+	engine *CalculationEngine
+	values []Value
+}
+
+// NewRPNStack creates an empty RPN stack that evaluates with this engine's settings
+func (ce *CalculationEngine) NewRPNStack() *RPNStack {
+	return &RPNStack{engine: ce}
+}
+
+// Eval processes one line of RPN input. A line is applied atomically: if any
+// token fails, the stack is left as it was before the line
+func (s *RPNStack) Eval(line string) error {
+	saved := append([]Value(nil), s.values...)
+	for _, tok := range strings.Fields(line) {
+		if err := s.apply(tok); err != nil {
+			s.values = saved
+			return err
+		}
+	}
+	return nil
+}
+
+// Values returns a copy of the stack, bottom first
+func (s *RPNStack) Values() []Value {
+	return append([]Value(nil), s.values...)
+}
+
+// Depth returns the number of values on the stack
+func (s *RPNStack) Depth() int {
+	return len(s.values)
+}
+
+// Top returns the value on top of the stack
+func (s *RPNStack) Top() (Value, bool) {
+	if len(s.values) == 0 {
+		return nil, false
+	}
+	return s.values[len(s.values)-1], true
+}
+
+// Push places a value on top of the stack
+func (s *RPNStack) Push(v Value) {
+	s.values = append(s.values, v)
+}
+
+// Clear empties the stack
+func (s *RPNStack) Clear() {
+	s.values = nil
+}
+
+// Clone returns an independent copy of the stack
+func (s *RPNStack) Clone() *RPNStack {
+	return &RPNStack{engine: s.engine, values: s.Values()}
+}
+
+// apply handles a single token
+func (s *RPNStack) apply(tok string) error {
+	if s.isOperator(tok) {
+		operands, err := s.pop(tok, 2)
+		if err != nil {
+			return err
+		}
+		ev := &evaluator{engine: s.engine}
+		var result Value
+		if tok == "%" {
+			result, err = ev.rpnPercent(operands[0], operands[1])
+		} else {
+			result, err = ev.applyBinary(tok, operands[0], operands[1])
+		}
+		if err != nil {
+			return err
+		}
+		s.Push(result)
+		return nil
+	}
+
+	switch tok {
+	case "dup":
+		operands, err := s.pop(tok, 1)
+		if err != nil {
+			return err
+		}
+		s.values = append(s.values, operands[0], operands[0])
+	case "swap":
+		operands, err := s.pop(tok, 2)
+		if err != nil {
+			return err
+		}
+		s.values = append(s.values, operands[1], operands[0])
+	case "drop":
+		if _, err := s.pop(tok, 1); err != nil {
+			return err
+		}
+	case "roll":
+		operands, err := s.pop(tok, 1)
+		if err != nil {
+			return err
+		}
+		s.values = append([]Value{operands[0]}, s.values...)
+	case "clear":
+		s.Clear()
+	case "depth":
+		s.Push(Number{Value: floatFromInt(int64(len(s.values)))})
+	default:
+		value, err := s.engine.Evaluate(tok)
+		if err != nil {
+			return fmt.Errorf("invalid RPN token %q: %w", tok, err)
+		}
+		s.Push(value)
+	}
+	return nil
+}
+
+// isOperator reports whether a token is "%" or an infix operator of the
+// engine's registry, comparisons included
+func (s *RPNStack) isOperator(tok string) bool {
+	_, ok := s.engine.registry.infix.bySymbol[tok]
+	return ok || tok == "%"
+}
+
+// pop removes the top n values, returning them bottom first
+func (s *RPNStack) pop(name string, n int) ([]Value, error) {
+	if len(s.values) < n {
+		return nil, fmt.Errorf("stack underflow: %s needs %d value(s), stack has %d", name, n, len(s.values))
+	}
+	operands := append([]Value(nil), s.values[len(s.values)-n:]...)
+	s.values = s.values[:len(s.values)-n]
+	return operands, nil
+}
+
+// String renders the stack one level per line with the top value as level 1,
+// as HP calculators display it
+func (s *RPNStack) String() string {
+	if len(s.values) == 0 {
+		return "(empty)"
+	}
+	lines := make([]string, 0, len(s.values))
+	for i, v := range s.values {
+		lines = append(lines, fmt.Sprintf("%d: %s", len(s.values)-i, s.engine.FormatResult(v)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// rpnPercent evaluates the RPN "%" operator: base percentage % is percentage% of base
+func (ev *evaluator) rpnPercent(base, percentage Value) (Value, error) {
+	p, ok := percentage.(Number)
+	if !ok {
+		return nil, fmt.Errorf("%% expects a plain number as the percentage, got %s", percentage.Kind())
+	}
+	return ev.percentOf(Percent{Value: p.Value}, base)
+}
+
+// CalculateRPN evaluates a complete Reverse Polish Notation expression such as
+// "3 4 + 2 *" and returns its single real result
+func (ce *CalculationEngine) CalculateRPN(expression string) (float64, error) {
+	if strings.TrimSpace(expression) == "" {
+		return 0, fmt.Errorf("expression cannot be empty")
+	}
+
+	stack := ce.NewRPNStack()
+	if err := stack.Eval(expression); err != nil {
+		return 0, err
+	}
+	if stack.Depth() != 1 {
+		return 0, fmt.Errorf("RPN expression must leave exactly one value on the stack, got %d", stack.Depth())
+	}
+	top, _ := stack.Top()
+	number, ok := top.(Number)
+	if !ok {
+		return 0, fmt.Errorf("RPN result is a %s, not a real number", top.Kind())
+	}
+	return number.Float64(), nil
+}
@@ -0,0 +1,152 @@
+package calculation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TapeEntryKind distinguishes calculation steps from subtotal and total lines
+type TapeEntryKind string
+
+const (
+	// TapeStep is an operand entered on its own or applied with an operator
+	TapeStep TapeEntryKind = "step"
+	// TapeSubtotal records the running result without resetting it
+	TapeSubtotal TapeEntryKind = "subtotal"
+	// TapeTotal records the final result and starts a new calculation
+	TapeTotal TapeEntryKind = "total"
+)
+
+// TapeEntry is one printed line of a running-total tape
+type TapeEntry struct {
+	Kind       TapeEntryKind
+	Operator   string
+	Operand    string
+	Result     float64
+	Annotation string
+	Timestamp  time.Time
+}
+
+// String renders the entry as a tape line, e.g. "*  1.2             = 138",
+// followed by "  # annotation" when the entry has one
+func (e TapeEntry) String() string {
+	label := e.Operand
+	if e.Kind != TapeStep {
+		label = string(e.Kind)
+	}
+	line := fmt.Sprintf("%-2s %-16s = %s", e.Operator, label, formatFloat64(e.Result))
+	if e.Annotation != "" {
+		line += "  # " + e.Annotation
+	}
+	return line
+}
+
+// RunningTotal accumulates "operator operand" steps onto the previous result,
+// the way a four-function desk calculator is used. Each step goes through
+// Calculate as "total operator operand", so the operand may carry a
+// percentage suffix ("+ 10%") with the engine's percent mode applied
+type RunningTotal struct {
+	engine *CalculationEngine
+	total  float64
+	tape   []TapeEntry
+}
+
+// NewRunningTotal creates a running total starting at zero with an empty tape
+func (ce *CalculationEngine) NewRunningTotal() *RunningTotal {
+	return &RunningTotal{engine: ce}
+}
+
+// Apply processes one line. A line starting with +, -, * or / applies to the
+// running result ("* 1.2"); a bare number replaces it and starts a new chain
+func (rt *RunningTotal) Apply(line string) (TapeEntry, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return TapeEntry{}, fmt.Errorf("expression cannot be empty")
+	}
+
+	op := line[:1]
+	if !strings.Contains("+-*/", op) {
+		value, err := rt.engine.parseBigFloat(line)
+		if err != nil {
+			return TapeEntry{}, fmt.Errorf("invalid number: %w", err)
+		}
+		rt.total, _ = value.Float64()
+		return rt.record(TapeEntry{Kind: TapeStep, Operand: line, Result: rt.total}), nil
+	}
+
+	operand := strings.TrimSpace(line[1:])
+	result, err := rt.engine.Calculate(strconv.FormatFloat(rt.total, 'f', -1, 64) + " " + op + " " + operand)
+	if err != nil {
+		return TapeEntry{}, err
+	}
+	rt.total = result
+	return rt.record(TapeEntry{Kind: TapeStep, Operator: op, Operand: operand, Result: result}), nil
+}
+
+// Subtotal records the running result on the tape and keeps accumulating
+func (rt *RunningTotal) Subtotal() TapeEntry {
+	return rt.record(TapeEntry{Kind: TapeSubtotal, Operator: "◇", Result: rt.total})
+}
+
+// Total records the running result on the tape and resets it to zero
+func (rt *RunningTotal) Total() TapeEntry {
+	entry := rt.record(TapeEntry{Kind: TapeTotal, Operator: "*", Result: rt.total})
+	rt.total = 0
+	return entry
+}
+
+// Annotate attaches a note to the most recent tape entry
+func (rt *RunningTotal) Annotate(note string) error {
+	if len(rt.tape) == 0 {
+		return fmt.Errorf("tape is empty: nothing to annotate")
+	}
+	rt.tape[len(rt.tape)-1].Annotation = strings.TrimSpace(note)
+	return nil
+}
+
+// Result returns the current running result
+func (rt *RunningTotal) Result() float64 {
+	return rt.total
+}
+
+// Entries returns a copy of the tape
+func (rt *RunningTotal) Entries() []TapeEntry {
+	return append([]TapeEntry(nil), rt.tape...)
+}
+
+// Clear resets the running result and discards the tape
+func (rt *RunningTotal) Clear() {
+	rt.total = 0
+	rt.tape = nil
+}
+
+// Clone returns an independent copy of the running total and its tape
+func (rt *RunningTotal) Clone() *RunningTotal {
+	return &RunningTotal{engine: rt.engine, total: rt.total, tape: rt.Entries()}
+}
+
+// String renders the whole tape, one entry per line
+func (rt *RunningTotal) String() string {
+	if len(rt.tape) == 0 {
+		return "(empty tape)"
+	}
+	lines := make([]string, 0, len(rt.tape))
+	for _, entry := range rt.tape {
+		lines = append(lines, entry.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// record timestamps an entry, appends it to the tape and returns it
+func (rt *RunningTotal) record(entry TapeEntry) TapeEntry {
+	entry.Timestamp = rt.engine.clock()
+	rt.tape = append(rt.tape, entry)
+	return entry
+}
+
+// formatFloat64 renders a float64 result with the engine's display precision
+func formatFloat64(f float64) string {
+	return formatFloat(newFloat().SetFloat64(f))
+}
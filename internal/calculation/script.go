@@ -0,0 +1,614 @@
+package calculation
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+// Script is a program of statements parsed once by CompileScript. Scripts
+// assign variables, branch and loop, and can only produce output through
+// print, so running an untrusted script reads and writes nothing else. Loops
+// are bounded by Limits.MaxIterations and each expression by the other Limits
+//
+// Grammar:
+//
+//	script     = { statement ( newline | ";" ) }
+//	statement  = assignment | definition | if | while | for | print | assert | expression
+//	assignment = ident "=" expression
+//	definition = ident "(" [ ident { "," ident } ] ")" "=" expression
+//	if         = "if" expression block [ "else" ( if | block ) ]
+//	while      = "while" expression block
+//	for        = "for" ident "=" operators "to" operators [ "step" operators ] block
+//	print      = "print" [ item { "," item } ]
+//	item       = string | expression
+//	assert     = "assert" expression
+//	block      = "{" script "}"
+//
+// A line break inside parentheses does not end a statement, and "#" starts a
+// comment that runs to the end of the line. A bare expression stores its
+// value in ans, and an assert whose expression is false stops the script with
+// an *AssertionError
+type Script struct {
+	engine *CalculationEngine
+	body   []statement
+}
+
+// ScriptError reports the line of a script where parsing or running failed
+type ScriptError struct {
+	Line int
+	Err  error
+}
+
+func (e *ScriptError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+// statement is an element of a parsed script
+type statement interface{}
+
+// assignStatement sets a variable, e.g. rate = 0.05 / 12
+type assignStatement struct {
+	line  int
+	name  string
+	value node
+}
+
+// defineStatement stores a user-defined function, e.g. sq(x) = x^2
+type defineStatement struct {
+	line int
+	fn   *UserFunction
+}
+
+// expressionStatement evaluates an expression into ans
+type expressionStatement struct {
+	line  int
+	value node
+}
+
+// printStatement writes its items separated by spaces on one line
+type printStatement struct {
+	line  int
+	items []printItem
+}
+
+// printItem is a string label or an expression to print
+type printItem struct {
+	text  string
+	value node
+}
+
+// assertStatement stops the script unless its expression is true
+type assertStatement struct {
+	line  int
+	value node
+	text  string
+}
+
+// ifStatement runs then when cond is true and otherwise, if present, els
+type ifStatement struct {
+	line      int
+	cond      node
+	then, els []statement
+}
+
+// whileStatement runs body as long as cond is true
+type whileStatement struct {
+	line int
+	cond node
+	body []statement
+}
+
+// forStatement runs body with name counting from from to to by step
+type forStatement struct {
+	line           int
+	name           string
+	from, to, step node
+	body           []statement
+}
+
+// scriptParser parses statements around the expression parser, which stops
+// at the newline, brace and string tokens only scripts have
+type scriptParser struct {
+	parser
+	ev *evaluator
+}
+
+// CompileScript parses a script and compiles its expressions. Failures are
+// returned as *ScriptError giving the line, wrapping a *SyntaxError when the
+// script could not be parsed
+func (ce *CalculationEngine) CompileScript(source string) (*Script, error) {
+	tokens, err := tokenizeScript(source, ce.registry.chars)
+	if err != nil {
+		return nil, err
+	}
+	// The functions the script defines, so later expressions can call them
+	functions := make(map[string]Value)
+	p := &scriptParser{parser: parser{tokens: tokens, registry: ce.registry}, ev: &evaluator{engine: ce, vars: functions}}
+	body, err := p.parseBlock(tokenEOF)
+	if err != nil {
+		return nil, err
+	}
+	return &Script{engine: ce, body: body}, nil
+}
+
+// parseBlock parses statements up to the given closing token, which it
+// leaves unconsumed
+func (p *scriptParser) parseBlock(end tokenKind) ([]statement, error) {
+	var block []statement
+	for {
+		for p.peek().kind == tokenNewline {
+			p.advance()
+		}
+		switch tok := p.peek(); {
+		case tok.kind == end:
+			return block, nil
+		case tok.kind == tokenEOF:
+			return nil, p.syntaxError(fmt.Errorf("missing closing brace"))
+		case tok.kind == tokenRBrace:
+			return nil, p.syntaxError(fmt.Errorf("unexpected \"}\" at position %d", tok.pos+1))
+		}
+
+		st, err := p.parseStatement()
+		if err != nil {
+			return nil, err
+		}
+		block = append(block, st)
+
+		switch tok := p.peek(); tok.kind {
+		case tokenNewline, tokenRBrace, tokenEOF:
+		default:
+			return nil, p.syntaxError(fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos+1))
+		}
+	}
+}
+
+// parseStatement parses one statement, recognized by its first word
+func (p *scriptParser) parseStatement() (statement, error) {
+	tok := p.peek()
+	if tok.kind == tokenIdent {
+		switch tok.text {
+		case "if":
+			if st, ok := p.conditionalCall(); ok {
+				return st, nil
+			}
+			return p.parseIf()
+		case "while":
+			p.advance()
+			cond, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			body, err := p.parseBraces(tok.text)
+			if err != nil {
+				return nil, err
+			}
+			return whileStatement{line: tok.line, cond: cond, body: body}, nil
+		case "for":
+			return p.parseFor()
+		case "print":
+			return p.parsePrint()
+		case "assert":
+			p.advance()
+			start := p.pos
+			value, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			return assertStatement{line: tok.line, value: value, text: p.sourceText(start, p.pos)}, nil
+		case "else":
+			return nil, p.syntaxError(fmt.Errorf("else without if"))
+		}
+		if st, ok, err := p.parseDefinition(); ok || err != nil {
+			return st, err
+		}
+		if p.isAssignment() {
+			if err := ValidateVariableName(tok.text); err != nil {
+				return nil, &ScriptError{Line: tok.line, Err: err}
+			}
+			p.pos += 2
+			value, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			return assignStatement{line: tok.line, name: tok.text, value: value}, nil
+		}
+	}
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	return expressionStatement{line: tok.line, value: value}, nil
+}
+
+// conditionalCall parses a statement that is the expression if(cond, a, b)
+// rather than an if statement. ok is false, with the position unchanged,
+// when the tokens are not such an expression
+func (p *scriptParser) conditionalCall() (statement, bool) {
+	if p.tokens[p.pos+1].kind != tokenLParen {
+		return nil, false
+	}
+	start := p.pos
+	line := p.peek().line
+	value, err := p.expression()
+	if err == nil {
+		switch p.peek().kind {
+		case tokenNewline, tokenRBrace, tokenEOF:
+			return expressionStatement{line: line, value: value}, true
+		}
+	}
+	p.pos = start
+	return nil, false
+}
+
+// parseDefinition parses a function definition. ok is false, with the
+// position unchanged, when the statement is not one
+func (p *scriptParser) parseDefinition() (statement, bool, error) {
+	line := p.peek().line
+	name, params, ok := p.parseDefinitionHead()
+	if !ok {
+		return nil, false, nil
+	}
+	fn := &UserFunction{Name: name, Params: params}
+	if err := p.ev.engine.checkDefinition(fn); err != nil {
+		return nil, true, &ScriptError{Line: line, Err: err}
+	}
+	// Registered before the body is compiled so it can call itself
+	p.ev.vars[name] = fn
+	start := p.pos
+	tree, err := p.expression()
+	if err != nil {
+		return nil, true, err
+	}
+	fn.Body = p.sourceText(start, p.pos)
+	fn.tree = tree
+	return defineStatement{line: line, fn: fn}, true, nil
+}
+
+// isAssignment reports whether the tokens at the current position are a
+// name followed by a single "=", as opposed to the comparison "=="
+func (p *scriptParser) isAssignment() bool {
+	eq := p.tokens[p.pos+1]
+	if eq.kind != tokenOperator || eq.text != "=" {
+		return false
+	}
+	next := p.tokens[p.pos+2]
+	return !(next.kind == tokenOperator && next.text == "=" && next.pos == eq.pos+1)
+}
+
+// parseIf parses an if statement with any else and else if branches
+func (p *scriptParser) parseIf() (statement, error) {
+	line := p.advance().line
+	cond, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	then, err := p.parseBraces("if")
+	if err != nil {
+		return nil, err
+	}
+	st := ifStatement{line: line, cond: cond, then: then}
+
+	// else may start the line after the closing brace
+	save := p.pos
+	for p.peek().kind == tokenNewline {
+		p.advance()
+	}
+	if !p.isKeyword("else") {
+		p.pos = save
+		return st, nil
+	}
+	p.advance()
+	if p.isKeyword("if") {
+		nested, err := p.parseIf()
+		if err != nil {
+			return nil, err
+		}
+		st.els = []statement{nested}
+		return st, nil
+	}
+	if st.els, err = p.parseBraces("else"); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// parseFor parses a counting loop
+func (p *scriptParser) parseFor() (statement, error) {
+	line := p.advance().line
+	name := p.advance()
+	if name.kind != tokenIdent || !p.isOperator("=") {
+		return nil, p.syntaxError(fmt.Errorf("expected name = start after for"))
+	}
+	if err := ValidateVariableName(name.text); err != nil {
+		return nil, &ScriptError{Line: line, Err: err}
+	}
+	p.advance()
+	st := forStatement{line: line, name: name.text}
+
+	var err error
+	if st.from, err = p.operand(); err != nil {
+		return nil, err
+	}
+	if !p.isKeyword("to") {
+		return nil, p.syntaxError(fmt.Errorf("expected to after the start of the for loop"))
+	}
+	p.advance()
+	if st.to, err = p.operand(); err != nil {
+		return nil, err
+	}
+	if p.isKeyword("step") {
+		p.advance()
+		if st.step, err = p.operand(); err != nil {
+			return nil, err
+		}
+	}
+	if st.body, err = p.parseBraces("for"); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// parsePrint parses the items of a print statement
+func (p *scriptParser) parsePrint() (statement, error) {
+	st := printStatement{line: p.advance().line}
+	switch p.peek().kind {
+	case tokenNewline, tokenRBrace, tokenEOF:
+		return st, nil
+	}
+	for {
+		if tok := p.peek(); tok.kind == tokenString {
+			p.advance()
+			st.items = append(st.items, printItem{text: tok.text})
+		} else {
+			value, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			st.items = append(st.items, printItem{value: value})
+		}
+		if p.peek().kind != tokenComma {
+			return st, nil
+		}
+		p.advance()
+	}
+}
+
+// sourceText rebuilds the text of the tokens from start up to end, with a
+// space wherever the source had one
+func (p *scriptParser) sourceText(start, end int) string {
+	var b strings.Builder
+	for i := start; i < end; i++ {
+		tok := p.tokens[i]
+		if i > start {
+			if prev := p.tokens[i-1]; tok.line != prev.line || tok.pos != prev.pos+len(prev.text) {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(tok.text)
+	}
+	return b.String()
+}
+
+// parseBraces parses the block of the given keyword
+func (p *scriptParser) parseBraces(keyword string) ([]statement, error) {
+	if p.peek().kind != tokenLBrace {
+		return nil, p.syntaxError(fmt.Errorf("expected { after %s", keyword))
+	}
+	p.advance()
+	body, err := p.parseBlock(tokenRBrace)
+	if err != nil {
+		return nil, err
+	}
+	p.advance()
+	return body, nil
+}
+
+// expression parses and compiles an expression, conversions included
+func (p *scriptParser) expression() (node, error) {
+	return p.compiled(p.parseConversion)
+}
+
+// operand parses and compiles an expression that stops before "to", as in
+// the bounds of a for loop
+func (p *scriptParser) operand() (node, error) {
+	return p.compiled(func() (node, error) { return p.parseOperators(0) })
+}
+
+func (p *scriptParser) compiled(parse func() (node, error)) (node, error) {
+	line := p.peek().line
+	tree, err := parse()
+	if err != nil {
+		// The expression parser stops after the token it could not use,
+		// which may be the line break
+		if p.pos > 0 {
+			line = p.tokens[p.pos-1].line
+		}
+		return nil, &ScriptError{Line: line, Err: &SyntaxError{Err: err}}
+	}
+	if tree, err = p.ev.compile(tree, 1); err != nil {
+		return nil, &ScriptError{Line: line, Err: err}
+	}
+	return tree, nil
+}
+
+// syntaxError reports a parse failure at the current line
+func (p *scriptParser) syntaxError(err error) error {
+	return &ScriptError{Line: p.peek().line, Err: &SyntaxError{Err: err}}
+}
+
+// scriptRunner holds the state of one run of a script
+type scriptRunner struct {
+	ev         evaluator
+	out        io.Writer
+	iterations int
+}
+
+// Run executes the script, writing what it prints to out. The script starts
+// with a copy of vars and the variables it ends with are returned. Failures
+// are returned as *ScriptError; a run stops with ctx.Err() once ctx is done
+func (s *Script) Run(ctx context.Context, out io.Writer, vars map[string]Value) (map[string]Value, error) {
+	variables := make(map[string]Value, len(vars))
+	for name, v := range vars {
+		variables[name] = v
+	}
+	r := &scriptRunner{ev: evaluator{engine: s.engine, vars: variables, ctx: ctx}, out: out}
+	if err := r.run(s.body); err != nil {
+		return nil, err
+	}
+	return variables, nil
+}
+
+// run executes a block of statements
+func (r *scriptRunner) run(block []statement) error {
+	for _, st := range block {
+		if err := r.exec(st); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exec executes one statement, reporting failures with its line
+func (r *scriptRunner) exec(st statement) error {
+	switch st := st.(type) {
+	case assignStatement:
+		value, err := r.eval(st.value)
+		if err != nil {
+			return fail(st.line, err)
+		}
+		r.ev.vars[st.name] = value
+	case defineStatement:
+		r.ev.vars[st.fn.Name] = st.fn
+	case expressionStatement:
+		value, err := r.eval(st.value)
+		if err != nil {
+			return fail(st.line, err)
+		}
+		r.ev.vars["ans"] = value
+	case printStatement:
+		parts := make([]string, len(st.items))
+		for i, item := range st.items {
+			if item.value == nil {
+				parts[i] = item.text
+				continue
+			}
+			value, err := r.eval(item.value)
+			if err != nil {
+				return fail(st.line, err)
+			}
+			parts[i] = r.ev.engine.FormatResult(value)
+		}
+		if _, err := fmt.Fprintln(r.out, strings.Join(parts, " ")); err != nil {
+			return fail(st.line, err)
+		}
+	case assertStatement:
+		r.ev.steps = 0
+		if err := r.ev.assert(st.value, st.text); err != nil {
+			return fail(st.line, err)
+		}
+	case ifStatement:
+		ok, err := r.condition(st.cond)
+		if err != nil {
+			return fail(st.line, err)
+		}
+		if ok {
+			return r.run(st.then)
+		}
+		return r.run(st.els)
+	case whileStatement:
+		for {
+			ok, err := r.condition(st.cond)
+			if err != nil {
+				return fail(st.line, err)
+			}
+			if !ok {
+				return nil
+			}
+			if err := r.iterate(); err != nil {
+				return fail(st.line, err)
+			}
+			if err := r.run(st.body); err != nil {
+				return err
+			}
+		}
+	case forStatement:
+		return r.count(st)
+	}
+	return nil
+}
+
+// count runs a for loop. The bounds and step are evaluated once, and
+// assigning to the loop variable in the body does not change the count
+func (r *scriptRunner) count(st forStatement) error {
+	bounds := [3]*big.Float{nil, nil, floatFromInt(1)}
+	for i, n := range []node{st.from, st.to, st.step} {
+		if n == nil {
+			continue
+		}
+		value, err := r.eval(n)
+		if err != nil {
+			return fail(st.line, err)
+		}
+		number, ok := value.(Number)
+		if !ok {
+			return fail(st.line, fmt.Errorf("for loop bounds must be numbers, got %s", value.Kind()))
+		}
+		bounds[i] = number.Value
+	}
+	from, to, step := bounds[0], bounds[1], bounds[2]
+	if step.Sign() == 0 {
+		return fail(st.line, fmt.Errorf("for loop step cannot be zero"))
+	}
+
+	for i := newFloat().Set(from); i.Cmp(to)*step.Sign() <= 0; i.Add(i, step) {
+		if err := r.iterate(); err != nil {
+			return fail(st.line, err)
+		}
+		r.ev.vars[st.name] = Number{Value: newFloat().Set(i)}
+		if err := r.run(st.body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eval evaluates one expression, with the evaluation step limit applying to
+// each expression separately
+func (r *scriptRunner) eval(n node) (Value, error) {
+	r.ev.steps = 0
+	return r.ev.eval(n)
+}
+
+// condition evaluates the condition of an if or while statement
+func (r *scriptRunner) condition(n node) (bool, error) {
+	value, err := r.eval(n)
+	if err != nil {
+		return false, err
+	}
+	return truth(value)
+}
+
+// iterate counts one loop pass against MaxIterations and checks for
+// cancellation
+func (r *scriptRunner) iterate() error {
+	r.iterations++
+	if max := r.ev.engine.limits.MaxIterations; max > 0 && r.iterations > max {
+		return &LimitError{Limit: LimitIterations, Max: max}
+	}
+	return r.ev.ctx.Err()
+}
+
+// fail attributes an error to a line unless a nested statement already has
+func fail(line int, err error) error {
+	if _, ok := err.(*ScriptError); ok {
+		return err
+	}
+	return &ScriptError{Line: line, Err: err}
+}
@@ -0,0 +1,173 @@
+package calculation
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// statsFunctions are the aggregate functions. Each takes a list, several
+// numbers or a mix of both, so sum([1, 2], 3) and sum(1, 2, 3) agree, and
+// accumulates in big.Float so long columns do not drift
+var statsFunctions = []Function{
+	{Name: "count", Arity: Variadic, Call: aggregate("count", 0, func(xs []*big.Float) *big.Float {
+		return floatFromInt(int64(len(xs)))
+	})},
+	{Name: "sum", Arity: Variadic, Call: aggregate("sum", 0, sum)},
+	{Name: "mean", Arity: Variadic, Call: aggregate("mean", 1, mean)},
+	{Name: "median", Arity: Variadic, Call: aggregate("median", 1, func(xs []*big.Float) *big.Float {
+		return quantile(sorted(xs), newFloat().SetFloat64(0.5))
+	})},
+	{Name: "mode", Arity: Variadic, Call: aggregate("mode", 1, mode)},
+	{Name: "min", Arity: Variadic, Call: aggregate("min", 1, func(xs []*big.Float) *big.Float {
+		return sorted(xs)[0]
+	})},
+	{Name: "max", Arity: Variadic, Call: aggregate("max", 1, func(xs []*big.Float) *big.Float {
+		return sorted(xs)[len(xs)-1]
+	})},
+	{Name: "var", Arity: Variadic, Call: aggregate("var", 2, func(xs []*big.Float) *big.Float {
+		return variance(xs, len(xs)-1)
+	})},
+	{Name: "varp", Arity: Variadic, Call: aggregate("varp", 1, func(xs []*big.Float) *big.Float {
+		return variance(xs, len(xs))
+	})},
+	{Name: "stdev", Arity: Variadic, Call: aggregate("stdev", 2, func(xs []*big.Float) *big.Float {
+		return bigSqrt(variance(xs, len(xs)-1))
+	})},
+	{Name: "stdevp", Arity: Variadic, Call: aggregate("stdevp", 1, func(xs []*big.Float) *big.Float {
+		return bigSqrt(variance(xs, len(xs)))
+	})},
+	{Name: "percentile", Arity: 2, Call: fnPercentile},
+}
+
+// aggregate returns the implementation of a statistics function that needs
+// at least minimum values
+func aggregate(name string, minimum int, f func([]*big.Float) *big.Float) func(*CalculationEngine, []Value) (Value, error) {
+	return func(_ *CalculationEngine, args []Value) (Value, error) {
+		xs, err := statsValues(name, args, minimum)
+		if err != nil {
+			return nil, err
+		}
+		return Number{Value: newFloat().Set(f(xs))}, nil
+	}
+}
+
+// statsValues flattens the arguments of a statistics function into numbers
+func statsValues(name string, args []Value, minimum int) ([]*big.Float, error) {
+	var xs []*big.Float
+	var add func(v Value) error
+	add = func(v Value) error {
+		switch v := v.(type) {
+		case Number:
+			xs = append(xs, v.Value)
+		case List:
+			for _, item := range v.Items {
+				if err := add(item); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("%s expects numbers, got %s", name, v.Kind())
+		}
+		return nil
+	}
+	for _, arg := range args {
+		if err := add(arg); err != nil {
+			return nil, err
+		}
+	}
+	if len(xs) < minimum {
+		if minimum == 1 {
+			return nil, fmt.Errorf("%s of an empty list", name)
+		}
+		return nil, fmt.Errorf("%s needs at least %d values, got %d", name, minimum, len(xs))
+	}
+	return xs, nil
+}
+
+func sum(xs []*big.Float) *big.Float {
+	total := newFloat()
+	for _, x := range xs {
+		total.Add(total, x)
+	}
+	return total
+}
+
+func mean(xs []*big.Float) *big.Float {
+	total := sum(xs)
+	return total.Quo(total, floatFromInt(int64(len(xs))))
+}
+
+// variance returns the sum of squared deviations from the mean divided by
+// divisor: n for a population, n - 1 for a sample
+func variance(xs []*big.Float, divisor int) *big.Float {
+	m := mean(xs)
+	total := newFloat()
+	d := newFloat()
+	for _, x := range xs {
+		d.Sub(x, m)
+		total.Add(total, d.Mul(d, d))
+	}
+	return total.Quo(total, floatFromInt(int64(divisor)))
+}
+
+// mode returns the most frequent value, the smallest of them on a tie
+func mode(xs []*big.Float) *big.Float {
+	xs = sorted(xs)
+	best, bestRun := xs[0], 0
+	for i := 0; i < len(xs); {
+		j := i
+		for j < len(xs) && xs[j].Cmp(xs[i]) == 0 {
+			j++
+		}
+		if j-i > bestRun {
+			best, bestRun = xs[i], j-i
+		}
+		i = j
+	}
+	return best
+}
+
+// sorted returns the values in ascending order, leaving xs unchanged
+func sorted(xs []*big.Float) []*big.Float {
+	s := append([]*big.Float(nil), xs...)
+	sort.Slice(s, func(i, j int) bool { return s[i].Cmp(s[j]) < 0 })
+	return s
+}
+
+// quantile returns the value at fraction q (0.5 for the median) of sorted
+// values, interpolating linearly between neighbours like a spreadsheet's
+// PERCENTILE.INC
+func quantile(s []*big.Float, q *big.Float) *big.Float {
+	rank := newFloat().Mul(q, floatFromInt(int64(len(s)-1)))
+	whole, _ := rank.Int64()
+	if int(whole) >= len(s)-1 {
+		return newFloat().Set(s[len(s)-1])
+	}
+	frac := rank.Sub(rank, floatFromInt(whole))
+	step := newFloat().Sub(s[whole+1], s[whole])
+	return step.Add(s[whole], step.Mul(step, frac))
+}
+
+// fnPercentile returns the p-th percentile of a list, with p from 0 to 100
+// or a percentage, e.g. percentile(times, 95) or percentile(times, 95%)
+func fnPercentile(_ *CalculationEngine, args []Value) (Value, error) {
+	xs, err := statsValues("percentile", args[:1], 1)
+	if err != nil {
+		return nil, err
+	}
+	var p *big.Float
+	switch v := args[1].(type) {
+	case Number:
+		p = v.Value
+	case Percent:
+		p = v.Value
+	default:
+		return nil, fmt.Errorf("percentile expects a number from 0 to 100, got %s", v.Kind())
+	}
+	if p.Sign() < 0 || p.Cmp(floatFromInt(100)) > 0 {
+		return nil, fmt.Errorf("percentile must be between 0 and 100, got %s", formatFloat(p))
+	}
+	q := newFloat().Quo(p, floatFromInt(100))
+	return Number{Value: quantile(sorted(xs), q)}, nil
+}
@@ -0,0 +1,397 @@
+package calculation
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// baseDimension indexes the exponents of a dimension vector
+type baseDimension int
+
+const (
+	dimLength baseDimension = iota
+	dimMass
+	dimTime
+	dimCurrent
+	dimTemperature
+	dimAmount
+	dimData
+	dimCount
+)
+
+// dimension holds the exponent of each base dimension, e.g. m/s^2 is {1, 0, -2, ...}
+type dimension [dimCount]int
+
+// isZero reports whether the dimension is dimensionless
+func (d dimension) isZero() bool {
+	return d == dimension{}
+}
+
+// add returns the dimension of a product
+func (d dimension) add(other dimension, sign int) dimension {
+	var result dimension
+	for i := range d {
+		result[i] = d[i] + sign*other[i]
+	}
+	return result
+}
+
+// unitDefinition is a registry entry: a symbol's factor relative to the SI base and its dimension
+type unitDefinition struct {
+	factor *big.Float
+	dim    dimension
+}
+
+// unitRegistry holds every built-in unit, keyed by symbol
+var unitRegistry = map[string]unitDefinition{}
+
+// registerUnit adds a unit defined as factor times a base unit, e.g. ("km", "1000", "m");
+// factors may be decimals or exact fractions such as "1000/3600"
+func registerUnit(symbol, factor, base string, dim dimension) {
+	r, ok := new(big.Rat).SetString(factor)
+	if !ok {
+		panic(fmt.Sprintf("invalid factor for unit %s: %s", symbol, factor))
+	}
+	f := newFloat().SetRat(r)
+	if base != "" {
+		def := unitRegistry[base]
+		f.Mul(f, def.factor)
+		dim = def.dim
+	}
+	unitRegistry[symbol] = unitDefinition{factor: f, dim: dim}
+}
+
+// registerAliases registers several symbols for the same definition
+func registerAliases(symbols []string, factor, base string) {
+	for _, symbol := range symbols {
+		registerUnit(symbol, factor, base, dimension{})
+	}
+}
+
+func init() {
+	// SI base units
+	registerUnit("m", "1", "", dimension{dimLength: 1})
+	registerUnit("kg", "1", "", dimension{dimMass: 1})
+	registerUnit("s", "1", "", dimension{dimTime: 1})
+	registerUnit("A", "1", "", dimension{dimCurrent: 1})
+	registerUnit("K", "1", "", dimension{dimTemperature: 1})
+	registerUnit("mol", "1", "", dimension{dimAmount: 1})
+	registerUnit("B", "1", "", dimension{dimData: 1})
+
+	// Length
+	registerAliases([]string{"km"}, "1000", "m")
+	registerAliases([]string{"cm"}, "0.01", "m")
+	registerAliases([]string{"mm"}, "0.001", "m")
+	registerAliases([]string{"um"}, "0.000001", "m")
+	registerAliases([]string{"nm"}, "0.000000001", "m")
+	registerAliases([]string{"in", "inch", "inches"}, "0.0254", "m")
+	registerAliases([]string{"ft", "foot", "feet"}, "0.3048", "m")
+	registerAliases([]string{"yd", "yard", "yards"}, "0.9144", "m")
+	registerAliases([]string{"mi", "mile", "miles"}, "1609.344", "m")
+	registerAliases([]string{"nmi"}, "1852", "m")
+
+	// Mass
+	registerAliases([]string{"g", "gram", "grams"}, "0.001", "kg")
+	registerAliases([]string{"mg"}, "0.000001", "kg")
+	registerAliases([]string{"t", "tonne", "tonnes"}, "1000", "kg")
+	registerAliases([]string{"lb", "lbs"}, "0.45359237", "kg")
+	registerAliases([]string{"oz"}, "0.028349523125", "kg")
+
+	// Time
+	registerAliases([]string{"sec", "second", "seconds"}, "1", "s")
+	registerAliases([]string{"ms"}, "0.001", "s")
+	registerAliases([]string{"us"}, "0.000001", "s")
+	registerAliases([]string{"ns"}, "0.000000001", "s")
+	registerAliases([]string{"min", "minute", "minutes"}, "60", "s")
+	registerAliases([]string{"h", "hr", "hour", "hours"}, "3600", "s")
+	registerAliases([]string{"day", "days"}, "86400", "s")
+	registerAliases([]string{"week", "weeks"}, "604800", "s")
+
+	// Speed
+	registerUnit("mph", "1609344/3600000", "", dimension{dimLength: 1, dimTime: -1})
+	registerUnit("kph", "1000/3600", "", dimension{dimLength: 1, dimTime: -1})
+	registerUnit("kn", "1852/3600", "", dimension{dimLength: 1, dimTime: -1})
+
+	// Area and volume
+	registerUnit("ha", "10000", "", dimension{dimLength: 2})
+	registerUnit("acre", "4046.8564224", "", dimension{dimLength: 2})
+	registerUnit("L", "0.001", "", dimension{dimLength: 3})
+	registerAliases([]string{"l"}, "1", "L")
+	registerAliases([]string{"mL", "ml"}, "0.001", "L")
+	registerAliases([]string{"gal"}, "3.785411784", "L")
+
+	// Frequency, force, energy, power, pressure
+	registerUnit("Hz", "1", "", dimension{dimTime: -1})
+	registerAliases([]string{"kHz"}, "1000", "Hz")
+	registerAliases([]string{"MHz"}, "1000000", "Hz")
+	registerAliases([]string{"GHz"}, "1000000000", "Hz")
+	registerUnit("N", "1", "", dimension{dimMass: 1, dimLength: 1, dimTime: -2})
+	registerUnit("J", "1", "", dimension{dimMass: 1, dimLength: 2, dimTime: -2})
+	registerAliases([]string{"kJ"}, "1000", "J")
+	registerAliases([]string{"cal"}, "4.184", "J")
+	registerAliases([]string{"kcal"}, "4184", "J")
+	registerAliases([]string{"kWh"}, "3600000", "J")
+	registerUnit("W", "1", "", dimension{dimMass: 1, dimLength: 2, dimTime: -3})
+	registerAliases([]string{"mW"}, "0.001", "W")
+	registerAliases([]string{"kW"}, "1000", "W")
+	registerAliases([]string{"hp"}, "745.69987158227022", "W")
+	registerUnit("Pa", "1", "", dimension{dimMass: 1, dimLength: -1, dimTime: -2})
+	registerAliases([]string{"kPa"}, "1000", "Pa")
+	registerAliases([]string{"bar"}, "100000", "Pa")
+	registerAliases([]string{"atm"}, "101325", "Pa")
+	registerAliases([]string{"psi"}, "6894.757293168", "Pa")
+
+	// Electrical
+	registerAliases([]string{"mA"}, "0.001", "A")
+	registerUnit("V", "1", "", dimension{dimMass: 1, dimLength: 2, dimTime: -3, dimCurrent: -1})
+	registerAliases([]string{"mV"}, "0.001", "V")
+	registerAliases([]string{"kV"}, "1000", "V")
+	registerUnit("ohm", "1", "", dimension{dimMass: 1, dimLength: 2, dimTime: -3, dimCurrent: -2})
+
+	// Data sizes: decimal (kB, MB, GB, TB) and binary (KiB, MiB, GiB, TiB)
+	registerAliases([]string{"bit", "bits"}, "0.125", "B")
+	registerAliases([]string{"byte", "bytes"}, "1", "B")
+	registerAliases([]string{"kB", "KB"}, "1000", "B")
+	registerAliases([]string{"MB"}, "1000000", "B")
+	registerAliases([]string{"GB"}, "1000000000", "B")
+	registerAliases([]string{"TB"}, "1000000000000", "B")
+	registerAliases([]string{"KiB"}, "1024", "B")
+	registerAliases([]string{"MiB"}, "1048576", "B")
+	registerAliases([]string{"GiB"}, "1073741824", "B")
+	registerAliases([]string{"TiB"}, "1099511627776", "B")
+}
+
+// isUnit reports whether a symbol names a registered unit
+func isUnit(symbol string) bool {
+	_, ok := unitRegistry[symbol]
+	return ok
+}
+
+// SupportedUnits returns the symbols of all registered units in sorted order
+func SupportedUnits() []string {
+	symbols := make([]string, 0, len(unitRegistry))
+	for symbol := range unitRegistry {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// unitTerm is one symbol of a compound unit raised to a power, e.g. s^-2
+type unitTerm struct {
+	symbol string
+	power  int
+}
+
+// Unit is a possibly compound unit such as km/h or kg*m/s^2
+type Unit struct {
+	terms  []unitTerm
+	factor *big.Float
+	dim    dimension
+}
+
+// resolveUnit looks up every term of a unit expression in the registry
+func resolveUnit(terms []unitTerm) (Unit, error) {
+	u := Unit{factor: floatFromInt(1)}
+	for _, term := range terms {
+		def, ok := unitRegistry[term.symbol]
+		if !ok {
+			return Unit{}, fmt.Errorf("unknown unit: %s", term.symbol)
+		}
+		factor, ok := bigPowInt(def.factor, int64(term.power), maxSafeExp)
+		if !ok {
+			return Unit{}, fmt.Errorf("unit %s^%d is too large", term.symbol, term.power)
+		}
+		u = u.mul(Unit{
+			terms:  []unitTerm{term},
+			factor: factor,
+			dim:    dimension{}.add(def.dim, term.power),
+		}, 1)
+	}
+	return u, nil
+}
+
+// mul returns u*other (sign 1) or u/other (sign -1), merging repeated symbols
+func (u Unit) mul(other Unit, sign int) Unit {
+	terms := make([]unitTerm, len(u.terms))
+	copy(terms, u.terms)
+	for _, term := range other.terms {
+		merged := false
+		for i := range terms {
+			if terms[i].symbol == term.symbol {
+				terms[i].power += sign * term.power
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			terms = append(terms, unitTerm{symbol: term.symbol, power: sign * term.power})
+		}
+	}
+
+	kept := terms[:0]
+	for _, term := range terms {
+		if term.power != 0 {
+			kept = append(kept, term)
+		}
+	}
+
+	factor := newFloat()
+	if sign > 0 {
+		factor.Mul(u.factor, other.factor)
+	} else {
+		factor.Quo(u.factor, other.factor)
+	}
+	return Unit{terms: kept, factor: factor, dim: u.dim.add(other.dim, sign)}
+}
+
+// pow raises every term of the unit to an integer power
+func (u Unit) pow(n int) (Unit, error) {
+	terms := make([]unitTerm, len(u.terms))
+	for i, term := range u.terms {
+		terms[i] = unitTerm{symbol: term.symbol, power: term.power * n}
+	}
+	var dim dimension
+	for i := range u.dim {
+		dim[i] = u.dim[i] * n
+	}
+	factor, ok := bigPowInt(u.factor, int64(n), maxSafeExp)
+	if !ok {
+		return Unit{}, errOverflow
+	}
+	return Unit{terms: terms, factor: factor, dim: dim}, nil
+}
+
+// String renders the unit as numerator/denominator, e.g. kg*m/s^2
+func (u Unit) String() string {
+	var num, den []string
+	for _, term := range u.terms {
+		power := term.power
+		target := &num
+		if power < 0 {
+			power = -power
+			target = &den
+		}
+		if power == 1 {
+			*target = append(*target, term.symbol)
+		} else {
+			*target = append(*target, fmt.Sprintf("%s^%d", term.symbol, power))
+		}
+	}
+
+	if len(num) == 0 {
+		var inverse []string
+		for _, term := range u.terms {
+			inverse = append(inverse, fmt.Sprintf("%s^%d", term.symbol, term.power))
+		}
+		return strings.Join(inverse, "*")
+	}
+
+	text := strings.Join(num, "*")
+	for _, d := range den {
+		text += "/" + d
+	}
+	return text
+}
+
+// Quantity is a number tagged with a unit of measure
+type Quantity struct {
+	Value *big.Float
+	Unit  Unit
+}
+
+// Kind implements Value
+func (q Quantity) Kind() string {
+	return "quantity"
+}
+
+// String formats the quantity as "value unit"
+func (q Quantity) String() string {
+	return formatFloat(q.Value) + " " + q.Unit.String()
+}
+
+// newQuantity builds a quantity, collapsing dimensionless results to a plain Number
+func newQuantity(value *big.Float, u Unit) Value {
+	if u.dim.isZero() {
+		return Number{Value: newFloat().Mul(value, u.factor)}
+	}
+	return Quantity{Value: value, Unit: u}
+}
+
+// convertQuantity expresses q in the target unit, rejecting incompatible dimensions
+func convertQuantity(q Quantity, target Unit) (Quantity, error) {
+	if q.Unit.dim != target.dim {
+		return Quantity{}, fmt.Errorf("dimension mismatch: cannot convert %s to %s", q.Unit, target)
+	}
+	value := newFloat().Mul(q.Value, q.Unit.factor)
+	if value.Quo(value, target.factor); value.IsInf() {
+		return Quantity{}, fmt.Errorf("result overflow converting %s to %s", q.Unit, target)
+	}
+	return Quantity{Value: value, Unit: target}, nil
+}
+
+// quantityBinary applies an arithmetic operator where at least one side carries a unit
+func quantityBinary(op string, left, right Value) (Value, error) {
+	lq, lIsQ := toQuantity(left)
+	rq, rIsQ := toQuantity(right)
+	if lq == nil || rq == nil {
+		return nil, fmt.Errorf("cannot combine %s and %s", left.Kind(), right.Kind())
+	}
+
+	switch op {
+	case "+", "-":
+		if !lIsQ || !rIsQ {
+			return nil, fmt.Errorf("dimension mismatch: cannot %s a plain number and %s", verbFor(op), unitOf(left, right))
+		}
+		converted, err := convertQuantity(*rq, lq.Unit)
+		if err != nil {
+			return nil, fmt.Errorf("dimension mismatch: cannot %s %s and %s", verbFor(op), lq.Unit, rq.Unit)
+		}
+		result := newFloat()
+		if op == "+" {
+			result.Add(lq.Value, converted.Value)
+		} else {
+			result.Sub(lq.Value, converted.Value)
+		}
+		return Quantity{Value: result, Unit: lq.Unit}, nil
+	case "*":
+		return newQuantity(newFloat().Mul(lq.Value, rq.Value), lq.Unit.mul(rq.Unit, 1)), nil
+	case "/":
+		if rq.Value.Sign() == 0 {
+			return nil, ErrDivisionByZero
+		}
+		return newQuantity(newFloat().Quo(lq.Value, rq.Value), lq.Unit.mul(rq.Unit, -1)), nil
+	default:
+		return nil, fmt.Errorf("unsupported operator for quantities: %s", op)
+	}
+}
+
+// toQuantity views a value as a quantity; plain numbers become dimensionless quantities
+func toQuantity(v Value) (*Quantity, bool) {
+	switch v := v.(type) {
+	case Quantity:
+		return &v, true
+	case Number:
+		return &Quantity{Value: v.Value, Unit: Unit{factor: floatFromInt(1)}}, false
+	default:
+		return nil, false
+	}
+}
+
+// unitOf returns the unit of whichever operand is a quantity
+func unitOf(left, right Value) Unit {
+	if q, ok := left.(Quantity); ok {
+		return q.Unit
+	}
+	return right.(Quantity).Unit
+}
+
+// verbFor names an additive operator for error messages
+func verbFor(op string) string {
+	if op == "-" {
+		return "subtract"
+	}
+	return "add"
+}
@@ -0,0 +1,172 @@
+package calculation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UserFunction is a function defined from an expression, e.g.
+// tax(x) = x <= 10000 ? 0 : (x - 10000) * 0.2. It is stored among the
+// variables under its name and called like a built-in function
+type UserFunction struct {
+	Name   string
+	Params []string
+	// Body is the source text of the expression after "="
+	Body string
+	tree node
+}
+
+// Kind implements Value
+func (f *UserFunction) Kind() string {
+	return "function"
+}
+
+// String formats the function as its definition
+func (f *UserFunction) String() string {
+	return fmt.Sprintf("%s(%s) = %s", f.Name, strings.Join(f.Params, ", "), f.Body)
+}
+
+// DefineFunction parses a definition such as "area(w, h) = w * h". The body
+// may call the functions in vars and the function itself; variables it reads
+// besides its parameters are looked up when it is called. Parse failures are
+// returned as *SyntaxError
+func (ce *CalculationEngine) DefineFunction(definition string, vars map[string]Value) (*UserFunction, error) {
+	if err := ce.limits.checkLength(definition); err != nil {
+		return nil, err
+	}
+	tokens, err := tokenize(definition, ce.registry.chars+"=", nil)
+	if err != nil {
+		return nil, &SyntaxError{Err: err}
+	}
+	p := &parser{tokens: tokens, registry: ce.registry}
+	name, params, ok := p.parseDefinitionHead()
+	if !ok {
+		return nil, &SyntaxError{Err: fmt.Errorf("expected a definition such as f(x) = x^2")}
+	}
+	if p.peek().kind == tokenEOF {
+		return nil, &SyntaxError{Err: fmt.Errorf("function %s has no body", name)}
+	}
+	start := p.peek().pos
+	tree, err := p.parseConversion()
+	if err != nil {
+		return nil, &SyntaxError{Err: err}
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, &SyntaxError{Err: fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos+1)}
+	}
+
+	fn := &UserFunction{Name: name, Params: params, Body: strings.TrimSpace(definition[start:])}
+	if err := ce.checkDefinition(fn); err != nil {
+		return nil, err
+	}
+	scope := make(map[string]Value, len(vars)+1)
+	for n, v := range vars {
+		scope[n] = v
+	}
+	scope[name] = fn
+	ev := &evaluator{engine: ce, vars: scope}
+	if fn.tree, err = ev.compile(tree, 1); err != nil {
+		return nil, err
+	}
+	return fn, nil
+}
+
+// checkDefinition rejects function and parameter names that cannot be used
+func (ce *CalculationEngine) checkDefinition(fn *UserFunction) error {
+	if err := ValidateVariableName(fn.Name); err != nil {
+		return err
+	}
+	if _, ok := ce.registry.functions[fn.Name]; ok {
+		return fmt.Errorf("cannot redefine built-in function %s", fn.Name)
+	}
+	for i, param := range fn.Params {
+		if err := ValidateVariableName(param); err != nil {
+			return err
+		}
+		for _, earlier := range fn.Params[:i] {
+			if param == earlier {
+				return fmt.Errorf("duplicate parameter %s in %s", param, fn.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// parseDefinitionHead parses name "(" [ param { "," param } ] ")" "=". When
+// the tokens are not a definition head, for instance the comparison
+// f(x) == 3, the position is restored and ok is false
+func (p *parser) parseDefinitionHead() (name string, params []string, ok bool) {
+	start := p.pos
+	defer func() {
+		if !ok {
+			p.pos = start
+		}
+	}()
+	tok := p.advance()
+	if tok.kind != tokenIdent || p.advance().kind != tokenLParen {
+		return "", nil, false
+	}
+	if p.peek().kind != tokenRParen {
+		for {
+			param := p.advance()
+			if param.kind != tokenIdent {
+				return "", nil, false
+			}
+			params = append(params, param.text)
+			if p.peek().kind != tokenComma {
+				break
+			}
+			p.advance()
+		}
+	}
+	if p.advance().kind != tokenRParen {
+		return "", nil, false
+	}
+	eq := p.advance()
+	if eq.kind != tokenOperator || eq.text != "=" {
+		return "", nil, false
+	}
+	if next := p.peek(); next.kind == tokenOperator && next.text == "=" && next.pos == eq.pos+1 {
+		return "", nil, false
+	}
+	return tok.text, params, true
+}
+
+// callUserFunction evaluates a call of a function defined with
+// DefineFunction. The arguments are evaluated in the caller's scope and the
+// body in a copy of it with the parameters bound
+func (ev *evaluator) callUserFunction(n callNode) (Value, error) {
+	v, ok := ev.vars[n.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function: %s", n.name)
+	}
+	fn, ok := v.(*UserFunction)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a function", n.name)
+	}
+	if len(n.args) != len(fn.Params) {
+		return nil, fmt.Errorf("%s expects %d argument(s), got %d", n.name, len(fn.Params), len(n.args))
+	}
+
+	scope := make(map[string]Value, len(ev.vars)+len(fn.Params))
+	for name, v := range ev.vars {
+		scope[name] = v
+	}
+	for i, argNode := range n.args {
+		arg, err := ev.eval(argNode)
+		if err != nil {
+			return nil, err
+		}
+		scope[fn.Params[i]] = arg
+	}
+
+	ev.calls++
+	defer func() { ev.calls-- }()
+	if err := ev.engine.limits.checkDepth(ev.calls); err != nil {
+		return nil, err
+	}
+	caller := ev.vars
+	ev.vars = scope
+	defer func() { ev.vars = caller }()
+	return ev.eval(fn.tree)
+}
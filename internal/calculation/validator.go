@@ -3,7 +3,6 @@ package calculation
 import (
 	"fmt"
 	"math/big"
-	"regexp"
 	"strings"
 )
 
@@ -21,6 +20,9 @@ func ValidateExpression(expression string) error {
 		return err
 	}
 
+	// The second number may be a percentage such as 15%
+	num2 = strings.TrimSuffix(num2, "%")
+
 	// Validate numbers
 	if err := validateNumber(num1); err != nil {
 		return fmt.Errorf("first number validation failed: %w", err)
@@ -41,7 +43,7 @@ func ValidateExpression(expression string) error {
 			return fmt.Errorf("failed to parse second number for division check: %w", err)
 		}
 		if isZero(num2Float) {
-			return fmt.Errorf("division by zero detected")
+			return fmt.Errorf("%w detected", ErrDivisionByZero)
 		}
 	}
 
@@ -54,9 +56,8 @@ func validateBasicFormat(expression string) error {
 		return fmt.Errorf("expression cannot be empty")
 	}
 
-	parts := strings.Fields(expression)
-	if len(parts) != 3 {
-		return fmt.Errorf("invalid format: expected 'number operator number', got %d parts", len(parts))
+	if n := splitFields(expression, nil); n != 3 {
+		return fmt.Errorf("invalid format: expected 'number operator number', got %d parts", n)
 	}
 
 	return nil
@@ -64,8 +65,8 @@ func validateBasicFormat(expression string) error {
 
 // parseExpressionComponents breaks down the expression into components
 func parseExpressionComponents(expression string) (string, string, string, error) {
-	parts := strings.Fields(expression)
-	if len(parts) != 3 {
+	var parts [3]string
+	if splitFields(expression, parts[:]) != 3 {
 		return "", "", "", fmt.Errorf("invalid expression format")
 	}
 	return parts[0], parts[1], parts[2], nil
@@ -77,7 +78,7 @@ func validateNumber(numStr string) error {
 	numStr = strings.TrimSpace(numStr)
 
 	// Check for basic numeric format
-	if matched, _ := regexp.MatchString(`^-?\d+(\.\d+)?$`, numStr); !matched {
+	if !isDecimal(numStr) {
 		return fmt.Errorf("invalid number format: %s", numStr)
 	}
 
@@ -95,15 +96,13 @@ func validateNumber(numStr string) error {
 	return nil
 }
 
-// validateOperator checks if the operator is supported
+// validateOperator checks that the operator is a built-in arithmetic infix
+// operator
 func validateOperator(op string) error {
-	validOps := []string{"+", "-", "*", "/"}
-	for _, validOp := range validOps {
-		if op == validOp {
-			return nil
-		}
+	if _, ok := defaultRegistry.infix.bySymbol[op]; !ok || isLogicOperator(op) {
+		return fmt.Errorf("unsupported operator: %s", op)
 	}
-	return fmt.Errorf("unsupported operator: %s", op)
+	return nil
 }
 
 // isZero checks if a big.Float represents zero
@@ -117,7 +116,7 @@ func parseBigFloat(s string) (*big.Float, error) {
 	s = strings.TrimSpace(s)
 
 	// Basic validation for numeric format
-	if matched, _ := regexp.MatchString(`^-?\d+(\.\d+)?$`, s); !matched {
+	if !isDecimal(s) {
 		return nil, fmt.Errorf("invalid number format: %s", s)
 	}
 
@@ -135,8 +134,12 @@ func parseBigFloat(s string) (*big.Float, error) {
 // Source: docs/architecture/security-and-performance.md - Input sanitization
 func SanitizeExpression(expression string) string {
 	// Remove any non-numeric, non-operator, non-whitespace characters
-	reg := regexp.MustCompile(`[^0-9+\-*/.\s]`)
-	return reg.ReplaceAllString(expression, "")
+	return strings.Map(func(r rune) rune {
+		if (r >= '0' && r <= '9') || strings.ContainsRune("+-*/. \t\n\f\r", r) {
+			return r
+		}
+		return -1
+	}, expression)
 }
 
 // ValidatePrecision checks if a calculation result meets precision requirements
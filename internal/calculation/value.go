@@ -0,0 +1,161 @@
+package calculation
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// displayDigits is the number of significant digits shown when formatting results
+// Source: docs/architecture/tech-stack.md - 15-digit precision requirement
+const displayDigits = 15
+
+// exactTextExp is the largest binary exponent formatFloat converts to decimal
+// directly. big.Float.Text takes time quadratic in the exponent, seconds for
+// 2^1000000, so larger magnitudes are first scaled by a power of ten
+const exactTextExp = 1 << 12
+
+// Value is the typed result of evaluating an expression
+type Value interface {
+	// Kind names the value type, e.g. "number" or "complex"
+	Kind() string
+	// String formats the value for display
+	String() string
+}
+
+// Number is a real-valued result backed by big.Float
+type Number struct {
+	Value *big.Float
+}
+
+// NewNumber wraps a big.Float as a Number value
+func NewNumber(f *big.Float) Number {
+	return Number{Value: f}
+}
+
+// Kind implements Value
+func (n Number) Kind() string {
+	return "number"
+}
+
+// String formats the number with 15 significant digits
+func (n Number) String() string {
+	return formatFloat(n.Value)
+}
+
+// Float64 converts the number to float64
+func (n Number) Float64() float64 {
+	f, _ := n.Value.Float64()
+	return f
+}
+
+// formatFloat renders a big.Float with displayDigits significant digits,
+// switching to exponent notation only for very large or small magnitudes
+func formatFloat(f *big.Float) string {
+	if f.Sign() == 0 {
+		return "0"
+	}
+
+	// Text('e') yields the correctly rounded digits, e.g. "-1.23450000000000e+03"
+	text := scientificText(f)
+	sign := ""
+	if strings.HasPrefix(text, "-") {
+		sign, text = "-", text[1:]
+	}
+	mantissa, expText, _ := strings.Cut(text, "e")
+	exp, err := strconv.Atoi(expText)
+	if err != nil {
+		return f.Text('g', displayDigits)
+	}
+
+	digits := strings.TrimRight(strings.Replace(mantissa, ".", "", 1), "0")
+	if digits == "" {
+		return "0"
+	}
+
+	if exp >= 21 || exp < -7 {
+		if len(digits) == 1 {
+			return fmt.Sprintf("%s%se%+03d", sign, digits, exp)
+		}
+		return fmt.Sprintf("%s%s.%se%+03d", sign, digits[:1], digits[1:], exp)
+	}
+
+	if exp < 0 {
+		return sign + "0." + strings.Repeat("0", -exp-1) + digits
+	}
+	if len(digits) <= exp+1 {
+		return sign + digits + strings.Repeat("0", exp+1-len(digits))
+	}
+	return sign + digits[:exp+1] + "." + digits[exp+1:]
+}
+
+// scientificText returns f.Text('e', displayDigits-1) in time independent of
+// the size of f's exponent
+func scientificText(f *big.Float) string {
+	exp := f.MantExp(nil)
+	if exp <= exactTextExp && -exp <= exactTextExp {
+		return f.Text('e', displayDigits-1)
+	}
+
+	// f = m * 10^shift, where m is small enough to convert directly
+	shift := int64(float64(exp) * 0.30103)
+	scale, _ := bigPowInt(floatFromInt(10), shift, big.MaxExp)
+	text := newFloat().Quo(f, scale).Text('e', displayDigits-1)
+	mantissa, expText, _ := strings.Cut(text, "e")
+	mexp, err := strconv.ParseInt(expText, 10, 64)
+	if err != nil {
+		return text
+	}
+	return fmt.Sprintf("%se%+d", mantissa, mexp+shift)
+}
+
+// List is an ordered list of values, written [1, 2, 3], as passed to the
+// statistics functions
+type List struct {
+	Items []Value
+}
+
+// Kind implements Value
+func (l List) Kind() string {
+	return "list"
+}
+
+// String formats the list as a list literal
+func (l List) String() string {
+	items := make([]string, len(l.Items))
+	for i, item := range l.Items {
+		items[i] = item.String()
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// Text is a textual result such as the name of a weekday
+type Text struct {
+	Value string
+}
+
+// Kind implements Value
+func (t Text) Kind() string {
+	return "text"
+}
+
+// String returns the text unchanged
+func (t Text) String() string {
+	return t.Value
+}
+
+// Bool is the result of a comparison or logical operator
+type Bool struct {
+	Value bool
+}
+
+// Kind implements Value
+func (b Bool) Kind() string {
+	return "boolean"
+}
+
+// String formats the value as true or false
+func (b Bool) String() string {
+	return strconv.FormatBool(b.Value)
+}
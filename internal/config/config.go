@@ -0,0 +1,74 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Configuration stores application configuration and user preferences
+// Source: docs/architecture/data-models.md - Configuration
+type Configuration struct {
+	Precision         int    `yaml:"precision" json:"precision"`
+	MaxHistory        int    `yaml:"max_history" json:"max_history"`
+	AutoSave          bool   `yaml:"auto_save" json:"auto_save"`
+	Theme             string `yaml:"theme" json:"theme"`
+	DebugMode         bool   `yaml:"debug_mode" json:"debug_mode"`
+	BatchMode         bool   `yaml:"batch_mode" json:"batch_mode"`
+	OutputFormat      string `yaml:"output_format" json:"output_format"`
+	ScientificMode    bool   `yaml:"scientific_mode" json:"scientific_mode"`
+	CurrencyRatesFile string `yaml:"currency_rates_file" json:"currency_rates_file"`
+	PercentMode       string `yaml:"percent_mode" json:"percent_mode"`
+	ComplexMode       bool   `yaml:"complex_mode" json:"complex_mode"`
+	ComplexForm       string `yaml:"complex_form" json:"complex_form"`
+	UndoDepth         int    `yaml:"undo_depth" json:"undo_depth"`
+
+	// Resource limits for one expression; 0 removes a limit
+	MaxExpressionLength int `yaml:"max_expression_length" json:"max_expression_length"`
+	MaxDepth            int `yaml:"max_depth" json:"max_depth"`
+	MaxDigits           int `yaml:"max_digits" json:"max_digits"`
+	MaxExponent         int `yaml:"max_exponent" json:"max_exponent"`
+	MaxSteps            int `yaml:"max_steps" json:"max_steps"`
+	// MaxIterations bounds the loop iterations of one script
+	MaxIterations int `yaml:"max_iterations" json:"max_iterations"`
+	// Tolerance is the relative tolerance of the ~= operator
+	Tolerance float64 `yaml:"tolerance" json:"tolerance"`
+	// CacheSize is the number of results memoized by the engine; 0 disables
+	// the cache
+	CacheSize int `yaml:"cache_size" json:"cache_size"`
+}
+
+// DefaultConfig returns the configuration used when no config file exists
+func DefaultConfig() *Configuration {
+	return &Configuration{
+		Precision:    15,
+		MaxHistory:   100,
+		Theme:        "default",
+		OutputFormat: "text",
+		PercentMode:  "desk",
+		ComplexForm:  "rectangular",
+		UndoDepth:    50,
+
+		MaxExpressionLength: 10000,
+		MaxDepth:            100,
+		MaxDigits:           10000,
+		MaxExponent:         1000000,
+		MaxSteps:            100000,
+		MaxIterations:       1000000,
+		Tolerance:           1e-9,
+	}
+}
+
+// DefaultDir returns the per-user application directory, ~/.calculator
+// Source: docs/architecture/data-storage.md - Storage Structure
+func DefaultDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".calculator"
+	}
+	return filepath.Join(home, ".calculator")
+}
+
+// DefaultConfigPath returns the location of the user's config.yaml
+func DefaultConfigPath() string {
+	return filepath.Join(DefaultDir(), "config.yaml")
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// LoadConfig reads a configuration file, starting from the defaults so that
+// keys missing from the file keep their default values. Relative file paths
+// inside the config are resolved against the config file's directory
+// Source: docs/architecture/data-storage.md - Configuration File Format (YAML)
+func LoadConfig(path string) (*Configuration, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config: %w", err)
+	}
+	defer file.Close()
+
+	cfg := DefaultConfig()
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := stripComment(scanner.Text())
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("config line %d: expected 'key: value'", lineNumber)
+		}
+		if err := cfg.set(strings.TrimSpace(key), unquote(strings.TrimSpace(value))); err != nil {
+			return nil, fmt.Errorf("config line %d: %w", lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	cfg.CurrencyRatesFile = resolvePath(cfg.CurrencyRatesFile, filepath.Dir(path))
+	return cfg, nil
+}
+
+// LoadConfigOrDefault loads the config file if it exists and falls back to the defaults otherwise
+func LoadConfigOrDefault(path string) (*Configuration, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return DefaultConfig(), nil
+	}
+	return LoadConfig(path)
+}
+
+// SaveConfig writes the configuration as flat YAML, one key per line
+func SaveConfig(cfg *Configuration, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	var b strings.Builder
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := t.Field(i).Tag.Get("yaml")
+		if v.Field(i).Kind() == reflect.String {
+			fmt.Fprintf(&b, "%s: \"%s\"\n", key, v.Field(i).String())
+		} else {
+			fmt.Fprintf(&b, "%s: %v\n", key, v.Field(i).Interface())
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// set assigns a raw string value to the field tagged with the given YAML key
+func (c *Configuration) set(key, value string) error {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("yaml") != key {
+			continue
+		}
+
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Int:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s must be an integer, got %q", key, value)
+			}
+			field.SetInt(int64(n))
+		case reflect.Float64:
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("%s must be a number, got %q", key, value)
+			}
+			field.SetFloat(f)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s must be true or false, got %q", key, value)
+			}
+			field.SetBool(b)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown configuration key: %s", key)
+}
+
+// stripComment removes a trailing "# comment" that is not inside quotes
+func stripComment(line string) string {
+	inQuote := rune(0)
+	for i, r := range line {
+		switch {
+		case inQuote != 0 && r == inQuote:
+			inQuote = 0
+		case inQuote == 0 && (r == '"' || r == '\''):
+			inQuote = r
+		case inQuote == 0 && r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquote removes matching single or double quotes around a value
+func unquote(value string) string {
+	if len(value) >= 2 {
+		first, last := value[0], value[len(value)-1]
+		if (first == '"' || first == '\'') && first == last {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
+
+// resolvePath expands a leading ~ and makes relative paths relative to baseDir
+func resolvePath(path, baseDir string) string {
+	if path == "" {
+		return ""
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+	return path
+}
@@ -0,0 +1,112 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"calculator/internal/models"
+)
+
+// HistoryManager keeps the calculations performed in a session, oldest first,
+// dropping the oldest once the configured maximum is reached
+// Source: docs/architecture/components.md - HistoryManager
+type HistoryManager struct {
+	calculations []models.Calculation
+	maxSize      int
+	nextID       int
+}
+
+// NewHistoryManager creates an empty history holding at most maxSize
+// calculations; zero or less means no limit
+func NewHistoryManager(maxSize int) *HistoryManager {
+	return &HistoryManager{maxSize: maxSize, nextID: 1}
+}
+
+// AddCalculation appends a calculation, assigning the next calc-N ID if it has none
+func (h *HistoryManager) AddCalculation(calc models.Calculation) error {
+	if calc.Expression == "" {
+		return fmt.Errorf("calculation has no expression")
+	}
+	if calc.ID == "" {
+		calc.ID = fmt.Sprintf("calc-%d", h.nextID)
+	}
+	h.nextID++
+	h.calculations = append(h.calculations, calc)
+	if h.maxSize > 0 && len(h.calculations) > h.maxSize {
+		h.calculations = append([]models.Calculation(nil), h.calculations[len(h.calculations)-h.maxSize:]...)
+	}
+	return nil
+}
+
+// GetHistory returns a copy of the calculations, oldest first
+func (h *HistoryManager) GetHistory() []models.Calculation {
+	return append([]models.Calculation(nil), h.calculations...)
+}
+
+// ClearHistory removes all calculations
+func (h *HistoryManager) ClearHistory() {
+	h.calculations = nil
+}
+
+// Clone returns an independent copy of the history
+func (h *HistoryManager) Clone() *HistoryManager {
+	return &HistoryManager{calculations: h.GetHistory(), maxSize: h.maxSize, nextID: h.nextID}
+}
+
+// SaveHistory writes the history as JSON in the format from
+// docs/architecture/data-storage.md, creating the directory if needed
+func (h *HistoryManager) SaveHistory(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	now := time.Now()
+	record := models.History{
+		ID:           "history",
+		SessionID:    "session",
+		Calculations: h.GetHistory(),
+		CreatedAt:    now,
+		LastUpdated:  now,
+		Size:         len(h.calculations),
+	}
+	if len(h.calculations) > 0 {
+		record.CreatedAt = h.calculations[0].Timestamp
+	}
+	if record.Calculations == nil {
+		record.Calculations = []models.Calculation{}
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode history: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// LoadHistory replaces the history with the calculations stored in a JSON history file
+func (h *HistoryManager) LoadHistory(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var record models.History
+	if err := json.Unmarshal(data, &record); err != nil {
+		return fmt.Errorf("invalid history file %s: %w", path, err)
+	}
+
+	h.calculations = nil
+	h.nextID = 1
+	for _, calc := range record.Calculations {
+		if err := h.AddCalculation(calc); err != nil {
+			return fmt.Errorf("invalid history file %s: %w", path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,406 @@
+package history
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"calculator/internal/calculation"
+	"calculator/internal/models"
+)
+
+// Format is a tape export format
+type Format string
+
+const (
+	// FormatText is the printed tape, one line per step
+	FormatText Format = "text"
+	// FormatCSV has one row per step with a header row
+	FormatCSV Format = "csv"
+	// FormatMarkdown is a Markdown table, one row per step
+	FormatMarkdown Format = "markdown"
+	// FormatJSON is the history file format from docs/architecture/data-storage.md
+	FormatJSON Format = "json"
+)
+
+// FormatFromPath picks a tape format from a file extension
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".txt", ".tape":
+		return FormatText, nil
+	case ".csv":
+		return FormatCSV, nil
+	case ".md", ".markdown":
+		return FormatMarkdown, nil
+	case ".json":
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported tape format: %s (expected .txt, .csv, .md or .json)", filepath.Ext(path))
+	}
+}
+
+// operations maps tape operators to Calculation operation names
+var operations = map[string]string{
+	"":  "enter",
+	"+": "add",
+	"-": "subtract",
+	"*": "multiply",
+	"/": "divide",
+}
+
+// csvHeader is the first row of a CSV tape
+var csvHeader = []string{"step", "operator", "operand", "result", "annotation", "timestamp"}
+
+// Tape is a running-total tape stored as history Calculation records
+type Tape struct {
+	Calculations []models.Calculation
+}
+
+// NewTape converts running-total entries into Calculation records. Operands
+// hold the previous result and the entered number, so the record stands on
+// its own in an audit
+func NewTape(entries []calculation.TapeEntry) *Tape {
+	tape := &Tape{Calculations: make([]models.Calculation, 0, len(entries))}
+	previous := 0.0
+	for i, entry := range entries {
+		calc := models.Calculation{
+			ID:         fmt.Sprintf("calc-%d", i+1),
+			Result:     entry.Result,
+			Timestamp:  entry.Timestamp,
+			Annotation: entry.Annotation,
+			Operands:   []float64{},
+		}
+
+		operand, _ := strconv.ParseFloat(strings.TrimSuffix(entry.Operand, "%"), 64)
+		switch {
+		case entry.Kind != calculation.TapeStep:
+			calc.Expression = string(entry.Kind)
+			calc.Operation = string(entry.Kind)
+		case entry.Operator == "":
+			calc.Expression = entry.Operand
+			calc.Operation = operations[""]
+			calc.Operands = []float64{operand}
+		default:
+			calc.Expression = entry.Operator + " " + entry.Operand
+			calc.Operation = operations[entry.Operator]
+			calc.Operands = []float64{previous, operand}
+		}
+
+		tape.Calculations = append(tape.Calculations, calc)
+		previous = entry.Result
+		if entry.Kind == calculation.TapeTotal {
+			previous = 0
+		}
+	}
+	return tape
+}
+
+// Entries converts the Calculation records back into tape entries
+func (t *Tape) Entries() ([]calculation.TapeEntry, error) {
+	entries := make([]calculation.TapeEntry, 0, len(t.Calculations))
+	for i, calc := range t.Calculations {
+		entry := calculation.TapeEntry{
+			Kind:       calculation.TapeStep,
+			Result:     calc.Result,
+			Annotation: calc.Annotation,
+			Timestamp:  calc.Timestamp,
+		}
+
+		switch calc.Operation {
+		case string(calculation.TapeSubtotal):
+			entry.Kind, entry.Operator = calculation.TapeSubtotal, "◇"
+		case string(calculation.TapeTotal):
+			entry.Kind, entry.Operator = calculation.TapeTotal, "*"
+		case operations[""]:
+			entry.Operand = strings.TrimSpace(calc.Expression)
+		default:
+			op, operand, _ := strings.Cut(strings.TrimSpace(calc.Expression), " ")
+			if operations[op] != calc.Operation || op == "" {
+				return nil, fmt.Errorf("record %d: expression %q does not match operation %q", i+1, calc.Expression, calc.Operation)
+			}
+			entry.Operator, entry.Operand = op, strings.TrimSpace(operand)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Replay re-applies the tape to a running total, checking every step against
+// the recorded result so an edited or mismatched tape is caught
+func (t *Tape) Replay(rt *calculation.RunningTotal) error {
+	entries, err := t.Entries()
+	if err != nil {
+		return err
+	}
+
+	for i, recorded := range entries {
+		var replayed calculation.TapeEntry
+		switch recorded.Kind {
+		case calculation.TapeSubtotal:
+			replayed = rt.Subtotal()
+		case calculation.TapeTotal:
+			replayed = rt.Total()
+		default:
+			line := strings.TrimSpace(recorded.Operator + " " + recorded.Operand)
+			replayed, err = rt.Apply(line)
+			if err != nil {
+				return fmt.Errorf("step %d (%s): %w", i+1, line, err)
+			}
+		}
+		if recorded.Annotation != "" {
+			if err := rt.Annotate(recorded.Annotation); err != nil {
+				return err
+			}
+		}
+		if !sameResult(replayed.Result, recorded.Result) {
+			return fmt.Errorf("step %d (%s): replay gave %v, tape recorded %v", i+1, strings.TrimSpace(recorded.String()), replayed.Result, recorded.Result)
+		}
+	}
+	return nil
+}
+
+// sameResult compares a replayed result with one read back from an export,
+// which may have been rounded to the 15 displayed digits
+func sameResult(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-12*math.Max(1, math.Abs(b))
+}
+
+// Export writes the tape in the given format
+func (t *Tape) Export(w io.Writer, format Format) error {
+	entries, err := t.Entries()
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatText:
+		for _, entry := range entries {
+			if _, err := fmt.Fprintln(w, entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(csvHeader); err != nil {
+			return err
+		}
+		for i, entry := range entries {
+			timestamp := ""
+			if !entry.Timestamp.IsZero() {
+				timestamp = entry.Timestamp.Format(time.RFC3339)
+			}
+			row := []string{strconv.Itoa(i + 1), entry.Operator, label(entry), formatResult(entry.Result), entry.Annotation, timestamp}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	case FormatMarkdown:
+		fmt.Fprintln(w, "| Step | Operator | Operand | Result | Annotation |")
+		fmt.Fprintln(w, "|-----:|:--------:|--------:|-------:|------------|")
+		for i, entry := range entries {
+			operator := ""
+			if entry.Operator != "" {
+				operator = "`" + entry.Operator + "`"
+			}
+			annotation := strings.ReplaceAll(entry.Annotation, "|", `\|`)
+			if _, err := fmt.Fprintf(w, "| %d | %s | %s | %s | %s |\n", i+1, operator, label(entry), formatResult(entry.Result), annotation); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatJSON:
+		history := models.History{
+			Calculations: t.Calculations,
+			Size:         len(t.Calculations),
+		}
+		if len(t.Calculations) > 0 {
+			history.CreatedAt = t.Calculations[0].Timestamp
+			history.LastUpdated = t.Calculations[len(t.Calculations)-1].Timestamp
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(history)
+	default:
+		return fmt.Errorf("unsupported tape format: %s", format)
+	}
+}
+
+// ImportTape reads a tape previously written by Export
+func ImportTape(r io.Reader, format Format) (*Tape, error) {
+	var entries []calculation.TapeEntry
+	var err error
+	switch format {
+	case FormatText:
+		entries, err = parseText(r)
+	case FormatCSV:
+		entries, err = parseCSV(r)
+	case FormatMarkdown:
+		entries, err = parseMarkdown(r)
+	case FormatJSON:
+		var history models.History
+		if err := json.NewDecoder(r).Decode(&history); err != nil {
+			return nil, fmt.Errorf("invalid JSON tape: %w", err)
+		}
+		return &Tape{Calculations: history.Calculations}, nil
+	default:
+		return nil, fmt.Errorf("unsupported tape format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewTape(entries), nil
+}
+
+// parseText reads the printed tape format produced by RunningTotal.String
+func parseText(r io.Reader) ([]calculation.TapeEntry, error) {
+	var entries []calculation.TapeEntry
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		body, annotation, _ := strings.Cut(text, "  # ")
+		cut := strings.LastIndex(body, " = ")
+		if cut < 0 {
+			return nil, fmt.Errorf("line %d: expected 'operator operand = result'", line)
+		}
+
+		fields := strings.Fields(body[:cut])
+		var operator, operand string
+		switch len(fields) {
+		case 1:
+			operand = fields[0]
+		case 2:
+			operator, operand = fields[0], fields[1]
+		default:
+			return nil, fmt.Errorf("line %d: expected 'operator operand = result'", line)
+		}
+
+		entry, err := newEntry(operator, operand, body[cut+3:], annotation)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// parseCSV reads the CSV tape format
+func parseCSV(r io.Reader) ([]calculation.TapeEntry, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV tape: %w", err)
+	}
+	if len(rows) == 0 || strings.Join(rows[0], ",") != strings.Join(csvHeader, ",") {
+		return nil, fmt.Errorf("invalid CSV tape: expected header %s", strings.Join(csvHeader, ","))
+	}
+
+	entries := make([]calculation.TapeEntry, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		entry, err := newEntry(row[1], row[2], row[3], row[4])
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+2, err)
+		}
+		if row[5] != "" {
+			if entry.Timestamp, err = time.Parse(time.RFC3339, row[5]); err != nil {
+				return nil, fmt.Errorf("row %d: invalid timestamp %q", i+2, row[5])
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// parseMarkdown reads the Markdown table tape format
+func parseMarkdown(r io.Reader) ([]calculation.TapeEntry, error) {
+	var entries []calculation.TapeEntry
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if line <= 2 || text == "" {
+			continue
+		}
+		cells := splitMarkdownRow(text)
+		if len(cells) != 5 {
+			return nil, fmt.Errorf("line %d: expected 5 table columns, got %d", line, len(cells))
+		}
+		entry, err := newEntry(strings.Trim(cells[1], "`"), cells[2], cells[3], cells[4])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// splitMarkdownRow splits "| a | b |" into trimmed cells, honouring \| escapes
+func splitMarkdownRow(row string) []string {
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(row); i++ {
+		switch {
+		case row[i] == '\\' && i+1 < len(row) && row[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case row[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(row[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// newEntry builds a tape entry from the operator, operand label, result and
+// annotation columns shared by the text, CSV and Markdown formats
+func newEntry(operator, operand, result, annotation string) (calculation.TapeEntry, error) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(result), 64)
+	if err != nil {
+		return calculation.TapeEntry{}, fmt.Errorf("invalid result %q", result)
+	}
+	entry := calculation.TapeEntry{
+		Kind:       calculation.TapeStep,
+		Operator:   strings.TrimSpace(operator),
+		Operand:    strings.TrimSpace(operand),
+		Result:     value,
+		Annotation: strings.TrimSpace(annotation),
+	}
+
+	switch {
+	case entry.Operator == "◇" && entry.Operand == string(calculation.TapeSubtotal):
+		entry.Kind, entry.Operand = calculation.TapeSubtotal, ""
+	case entry.Operator == "*" && entry.Operand == string(calculation.TapeTotal):
+		entry.Kind, entry.Operand = calculation.TapeTotal, ""
+	default:
+		if _, ok := operations[entry.Operator]; !ok {
+			return calculation.TapeEntry{}, fmt.Errorf("unknown operator %q", entry.Operator)
+		}
+	}
+	return entry, nil
+}
+
+// label is the operand column of a tape row: the operand, or subtotal/total
+func label(entry calculation.TapeEntry) string {
+	if entry.Kind != calculation.TapeStep {
+		return string(entry.Kind)
+	}
+	return entry.Operand
+}
+
+// formatResult renders a result at full float64 precision without exponent noise
+func formatResult(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
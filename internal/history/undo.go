@@ -0,0 +1,73 @@
+package history
+
+// DefaultUndoDepth is the number of changes kept for undo when none is configured
+const DefaultUndoDepth = 50
+
+// undoEntry is a saved state together with a description of the change that left it
+type undoEntry[T any] struct {
+	label string
+	state T
+}
+
+// UndoStack keeps bounded undo and redo histories of snapshots of some state.
+// The caller records the state from before each change; Undo and Redo then
+// trade the current state for a saved one
+type UndoStack[T any] struct {
+	undo  []undoEntry[T]
+	redo  []undoEntry[T]
+	depth int
+}
+
+// NewUndoStack creates an undo stack that remembers at most depth changes
+func NewUndoStack[T any](depth int) *UndoStack[T] {
+	if depth <= 0 {
+		depth = DefaultUndoDepth
+	}
+	return &UndoStack[T]{depth: depth}
+}
+
+// Record saves the state from before a change described by label. Recording
+// a new change discards anything that could have been redone, and the oldest
+// change is forgotten once the depth is reached
+func (u *UndoStack[T]) Record(label string, before T) {
+	u.undo = push(u.undo, undoEntry[T]{label: label, state: before}, u.depth)
+	u.redo = nil
+}
+
+// Undo returns the state from before the most recent change and that change's
+// label. The current state is kept so the change can be redone
+func (u *UndoStack[T]) Undo(current T) (T, string, bool) {
+	return u.swap(&u.undo, &u.redo, current)
+}
+
+// Redo returns the state from after the most recently undone change and its
+// label. The current state is kept so the change can be undone again
+func (u *UndoStack[T]) Redo(current T) (T, string, bool) {
+	return u.swap(&u.redo, &u.undo, current)
+}
+
+// Depths returns the number of changes that can be undone and redone
+func (u *UndoStack[T]) Depths() (undo, redo int) {
+	return len(u.undo), len(u.redo)
+}
+
+// swap pops the top entry of from and pushes current onto to under the same label
+func (u *UndoStack[T]) swap(from, to *[]undoEntry[T], current T) (T, string, bool) {
+	if len(*from) == 0 {
+		var zero T
+		return zero, "", false
+	}
+	entry := (*from)[len(*from)-1]
+	*from = (*from)[:len(*from)-1]
+	*to = push(*to, undoEntry[T]{label: entry.label, state: current}, u.depth)
+	return entry.state, entry.label, true
+}
+
+// push appends an entry, dropping the oldest beyond depth
+func push[T any](entries []undoEntry[T], entry undoEntry[T], depth int) []undoEntry[T] {
+	entries = append(entries, entry)
+	if len(entries) > depth {
+		entries = append([]undoEntry[T](nil), entries[len(entries)-depth:]...)
+	}
+	return entries
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Calculation represents a mathematical calculation with its inputs, operation and result
+// Source: docs/architecture/data-models.md - Calculation
+type Calculation struct {
+	ID         string    `json:"id"`
+	Expression string    `json:"expression"`
+	Result     float64   `json:"result"`
+	ResultText string    `json:"result_text,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+	Operation  string    `json:"operation"`
+	Operands   []float64 `json:"operands"`
+	Error      string    `json:"error,omitempty"`
+	Annotation string    `json:"annotation,omitempty"`
+}
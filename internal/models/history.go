@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// History is the session history of calculations performed by the user
+// Source: docs/architecture/data-models.md - History
+type History struct {
+	ID           string        `json:"id"`
+	SessionID    string        `json:"session_id"`
+	Calculations []Calculation `json:"calculations"`
+	CreatedAt    time.Time     `json:"created_at"`
+	LastUpdated  time.Time     `json:"last_updated"`
+	Size         int           `json:"size"`
+}
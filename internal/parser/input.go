@@ -0,0 +1,110 @@
+package parser
+
+import "unicode/utf8"
+
+// Button is a keypad button, identified by the input it adds to the
+// expression: a digit, ".", an operator, "(", ")", "%", a constant such as
+// "pi", a function opener such as "sqrt(" or a short sequence such as "^2".
+// The buttons below have special meaning
+type Button string
+
+const (
+	// ButtonEquals evaluates the expression
+	ButtonEquals Button = "="
+	// ButtonClear discards the expression and the last result
+	ButtonClear Button = "C"
+	// ButtonBackspace removes the last digit or token
+	ButtonBackspace Button = "⌫"
+	// ButtonSign negates the value being entered, or removes that negation
+	ButtonSign Button = "±"
+)
+
+// Key is a keyboard key: a printable rune or one of the control keys below
+type Key rune
+
+const (
+	// KeyEnter evaluates the expression
+	KeyEnter Key = '\r'
+	// KeyEscape clears the expression
+	KeyEscape Key = 0x1b
+	// KeyBackspace removes the last digit or token
+	KeyBackspace Key = 0x7f
+)
+
+// keyInputs are the printable keys accepted by ProcessKeyInput
+const keyInputs = "0123456789.+-*/^()%"
+
+// tokenKind classifies the pieces of an expression under construction
+type tokenKind int
+
+const (
+	// tokNumber is a literal made of digits and at most one decimal point
+	tokNumber tokenKind = iota
+	// tokConstant is a named value such as pi, or a previous result
+	tokConstant
+	// tokOperator is a binary operator: + - * / ^
+	tokOperator
+	// tokSign is a unary minus
+	tokSign
+	// tokOpen is "(" or a function opener such as "sqrt("
+	tokOpen
+	// tokClose is ")"
+	tokClose
+	// tokPercent is the postfix percent operator
+	tokPercent
+)
+
+// token is one piece of the expression being built
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// endsOperand reports whether the token completes a value, so that the next
+// input may be an operator
+func (t token) endsOperand() bool {
+	switch t.kind {
+	case tokNumber, tokConstant, tokClose, tokPercent:
+		return true
+	}
+	return false
+}
+
+// splitInput breaks button input into the units the state machine consumes:
+// single digits and symbols, names such as "pi", and function openers such
+// as "sqrt(". Spaces are ignored
+func splitInput(input string) []string {
+	var units []string
+	for i := 0; i < len(input); {
+		c := input[i]
+		switch {
+		case c == ' ':
+			i++
+		case isLetter(c):
+			j := i
+			for j < len(input) && (isLetter(input[j]) || isDigit(input[j])) {
+				j++
+			}
+			if j < len(input) && input[j] == '(' {
+				j++
+			}
+			units = append(units, input[i:j])
+			i = j
+		default:
+			_, size := utf8.DecodeRuneInString(input[i:])
+			units = append(units, input[i:i+size])
+			i += size
+		}
+	}
+	return units
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isLetter reports whether c is an ASCII letter or underscore
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
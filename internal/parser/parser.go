@@ -0,0 +1,396 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"calculator/internal/calculation"
+	"calculator/internal/models"
+)
+
+// InputParser assembles an expression one button or key at a time
+// Source: docs/architecture/components.md - InputParser
+//
+// It is a small state machine over the tokens entered so far. Input that
+// would make the expression malformed is rejected with an error and leaves
+// the expression unchanged, except that:
+//
+//   - an operator entered after another operator replaces it ("2+*" is "2*"),
+//     while "-" after an operator or "(" starts a negative value ("2*-3")
+//   - "." starts a number as "0." and is rejected if the number already has one
+//   - a value or "(" entered directly after a value gets an implicit "*"
+//     ("2(3)" is "2*(3)", "2pi" is "2*pi")
+//   - "=" closes any parentheses still open before evaluating
+//
+// After evaluation an operator continues from the result, while any other
+// input starts a new expression
+type InputParser struct {
+	engine    *calculation.CalculationEngine
+	tokens    []token
+	result    calculation.Value
+	evaluated bool
+}
+
+// NewInputParser creates an empty input parser that evaluates with the given engine
+func NewInputParser(engine *calculation.CalculationEngine) *InputParser {
+	return &InputParser{engine: engine}
+}
+
+// ProcessButtonInput applies a keypad button. It returns a calculation when
+// the button evaluated the expression, with Error set if evaluation failed
+func (p *InputParser) ProcessButtonInput(button Button) (*models.Calculation, error) {
+	switch button {
+	case ButtonEquals:
+		return p.evaluate()
+	case ButtonClear:
+		p.Clear()
+		return nil, nil
+	case ButtonBackspace:
+		p.backspace()
+		return nil, nil
+	case ButtonSign:
+		p.continueFromResult()
+		p.toggleSign()
+		return nil, nil
+	}
+	return nil, p.input(string(button))
+}
+
+// ProcessKeyInput applies a keyboard key: digits, ".", operators, parentheses
+// and "%" build the expression, Enter or "=" evaluates, Backspace deletes and
+// Escape clears
+func (p *InputParser) ProcessKeyInput(key Key) (*models.Calculation, error) {
+	switch {
+	case key == KeyEnter || key == '=':
+		return p.ProcessButtonInput(ButtonEquals)
+	case key == KeyEscape:
+		return p.ProcessButtonInput(ButtonClear)
+	case key == KeyBackspace || key == '\b':
+		return p.ProcessButtonInput(ButtonBackspace)
+	case strings.ContainsRune(keyInputs, rune(key)):
+		return p.ProcessButtonInput(Button(string(rune(key))))
+	}
+	return nil, fmt.Errorf("unsupported key %q", rune(key))
+}
+
+// BuildExpression appends input to currentExpr under the same rules as button
+// presses and returns the new expression, without changing the parser's state
+func (p *InputParser) BuildExpression(currentExpr string, input string) (string, error) {
+	scratch := NewInputParser(p.engine)
+	if err := scratch.input(currentExpr); err != nil {
+		return "", fmt.Errorf("invalid expression %q: %w", currentExpr, err)
+	}
+	if err := scratch.input(input); err != nil {
+		return "", err
+	}
+	return scratch.GetCurrentExpression(), nil
+}
+
+// ValidateExpression checks that a complete expression parses with the engine
+func (p *InputParser) ValidateExpression(expression string) error {
+	return p.engine.CheckSyntax(expression)
+}
+
+// GetCurrentExpression returns the expression being built, or the last
+// evaluated expression until new input arrives
+func (p *InputParser) GetCurrentExpression() string {
+	var b strings.Builder
+	for _, tok := range p.tokens {
+		b.WriteString(tok.text)
+	}
+	return b.String()
+}
+
+// Result returns the value of the last successful evaluation, or nil
+func (p *InputParser) Result() calculation.Value {
+	return p.result
+}
+
+// Evaluated reports whether the current expression has just been evaluated
+func (p *InputParser) Evaluated() bool {
+	return p.evaluated
+}
+
+// Clear discards the expression and the last result
+func (p *InputParser) Clear() {
+	p.tokens = nil
+	p.result = nil
+	p.evaluated = false
+}
+
+// input applies each unit of button input in turn. If any unit is rejected
+// the expression is left as it was before the input
+func (p *InputParser) input(text string) error {
+	saved := append([]token(nil), p.tokens...)
+	savedEvaluated := p.evaluated
+	for _, unit := range splitInput(text) {
+		if err := p.apply(unit); err != nil {
+			p.tokens, p.evaluated = saved, savedEvaluated
+			return err
+		}
+	}
+	return nil
+}
+
+// apply adds one unit to the expression
+func (p *InputParser) apply(unit string) error {
+	if p.evaluated {
+		if strings.Contains("+-*/^%", unit) {
+			p.continueFromResult()
+		} else {
+			p.tokens, p.evaluated = nil, false
+		}
+	}
+
+	last, hasLast := p.last()
+	switch c := unit[0]; {
+	case isDigit(c):
+		return p.digit(unit, last, hasLast)
+	case c == '.':
+		if hasLast && last.kind == tokNumber {
+			if strings.Contains(last.text, ".") {
+				return fmt.Errorf("number already has a decimal point")
+			}
+			p.tokens[len(p.tokens)-1].text += "."
+			return nil
+		}
+		p.implicitMultiply()
+		p.push(tokNumber, "0.")
+	case isLetter(c) && strings.HasSuffix(unit, "("), c == '(':
+		p.implicitMultiply()
+		p.push(tokOpen, unit)
+	case isLetter(c):
+		p.implicitMultiply()
+		p.push(tokConstant, unit)
+	case c == ')':
+		if p.openCount() == 0 {
+			return fmt.Errorf("no open parenthesis to close")
+		}
+		if !hasLast || !last.endsOperand() {
+			return fmt.Errorf(") needs a value before it")
+		}
+		p.trimDecimalPoint()
+		p.push(tokClose, unit)
+	case c == '%':
+		if !hasLast || !last.endsOperand() || last.kind == tokPercent {
+			return fmt.Errorf("%% must follow a value")
+		}
+		p.trimDecimalPoint()
+		p.push(tokPercent, unit)
+	case strings.Contains("+-*/^", unit):
+		return p.operator(unit, last, hasLast)
+	default:
+		return fmt.Errorf("unsupported input %q", unit)
+	}
+	return nil
+}
+
+// digit appends a digit to the number being entered or starts a new one.
+// A lone leading zero is replaced rather than extended
+func (p *InputParser) digit(d string, last token, hasLast bool) error {
+	if hasLast && last.kind == tokNumber {
+		if last.text == "0" {
+			p.tokens[len(p.tokens)-1].text = d
+		} else {
+			p.tokens[len(p.tokens)-1].text += d
+		}
+		return nil
+	}
+	p.implicitMultiply()
+	p.push(tokNumber, d)
+	return nil
+}
+
+// operator adds a binary operator, replacing one entered just before it, or
+// a unary minus where a value is expected
+func (p *InputParser) operator(op string, last token, hasLast bool) error {
+	if hasLast && last.endsOperand() {
+		p.trimDecimalPoint()
+		p.push(tokOperator, op)
+		return nil
+	}
+
+	if op == "-" {
+		if hasLast && last.kind == tokSign {
+			return fmt.Errorf("value is already negative")
+		}
+		p.push(tokSign, op)
+		return nil
+	}
+
+	// Replace a pending operator, together with any unary minus after it
+	tokens := p.tokens
+	if hasLast && last.kind == tokSign {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if n := len(tokens); n > 0 && tokens[n-1].kind == tokOperator {
+		p.tokens = append(tokens[:n-1], token{kind: tokOperator, text: op})
+		return nil
+	}
+	return fmt.Errorf("%s needs a value before it", op)
+}
+
+// toggleSign negates the value being entered (the last number, constant,
+// parenthesised group or percentage), or removes a negation added earlier.
+// Where a value is expected it toggles a pending unary minus
+func (p *InputParser) toggleSign() {
+	start, ok := p.lastOperandStart()
+	if !ok {
+		if last, hasLast := p.last(); hasLast && last.kind == tokSign {
+			p.tokens = p.tokens[:len(p.tokens)-1]
+		} else {
+			p.push(tokSign, "-")
+		}
+		return
+	}
+
+	if start > 0 && p.tokens[start-1].kind == tokSign {
+		p.tokens = append(p.tokens[:start-1], p.tokens[start:]...)
+		return
+	}
+	p.tokens = append(p.tokens[:start], append([]token{{kind: tokSign, text: "-"}}, p.tokens[start:]...)...)
+}
+
+// lastOperandStart returns the index of the first token of the value at the
+// end of the expression, if the expression ends with one
+func (p *InputParser) lastOperandStart() (int, bool) {
+	i := len(p.tokens) - 1
+	if i >= 0 && p.tokens[i].kind == tokPercent {
+		i--
+	}
+	if i < 0 {
+		return 0, false
+	}
+	switch p.tokens[i].kind {
+	case tokNumber, tokConstant:
+		return i, true
+	case tokClose:
+		depth := 0
+		for ; i >= 0; i-- {
+			switch p.tokens[i].kind {
+			case tokClose:
+				depth++
+			case tokOpen:
+				depth--
+			}
+			if depth == 0 {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// backspace removes the last digit of the number being entered, or the last
+// token. After evaluation it goes back to editing the evaluated expression
+func (p *InputParser) backspace() {
+	p.evaluated = false
+	last, ok := p.last()
+	if !ok {
+		return
+	}
+	if last.kind == tokNumber && len(last.text) > 1 && last.text != "0." {
+		p.tokens[len(p.tokens)-1].text = last.text[:len(last.text)-1]
+		return
+	}
+	p.tokens = p.tokens[:len(p.tokens)-1]
+}
+
+// evaluate closes open parentheses and evaluates the expression
+func (p *InputParser) evaluate() (*models.Calculation, error) {
+	if len(p.tokens) == 0 || p.evaluated {
+		return nil, nil
+	}
+
+	if last, _ := p.last(); last.endsOperand() {
+		p.trimDecimalPoint()
+		for n := p.openCount(); n > 0; n-- {
+			p.push(tokClose, ")")
+		}
+	}
+
+	expression := p.GetCurrentExpression()
+	calc := &models.Calculation{
+		Expression: expression,
+		Timestamp:  time.Now(),
+		Operation:  "expression",
+		Operands:   []float64{},
+	}
+
+	result, err := p.engine.Evaluate(expression)
+	if err != nil {
+		calc.Error = err.Error()
+		return calc, err
+	}
+	if n, ok := result.(calculation.Number); ok {
+		calc.Result = n.Float64()
+	}
+	calc.ResultText = p.engine.FormatResult(result)
+	p.result = result
+	p.evaluated = true
+	return calc, nil
+}
+
+// continueFromResult replaces an evaluated expression with its result, so
+// that further input builds on it
+func (p *InputParser) continueFromResult() {
+	if !p.evaluated {
+		return
+	}
+	p.evaluated = false
+	p.tokens = nil
+	if p.result == nil {
+		return
+	}
+
+	text := p.engine.FormatResult(p.result)
+	if _, err := strconv.ParseFloat(text, 64); err != nil || strings.HasPrefix(text, "-") {
+		// Keep results such as -2.5, 3+4i or 5 km together as one operand
+		text = "(" + text + ")"
+	}
+	p.push(tokConstant, text)
+}
+
+// implicitMultiply inserts "*" when a new value follows a completed one
+func (p *InputParser) implicitMultiply() {
+	if last, ok := p.last(); ok && last.endsOperand() {
+		p.trimDecimalPoint()
+		p.push(tokOperator, "*")
+	}
+}
+
+// trimDecimalPoint drops a trailing decimal point from the last number, so "2." becomes "2"
+func (p *InputParser) trimDecimalPoint() {
+	if last, ok := p.last(); ok && last.kind == tokNumber && strings.HasSuffix(last.text, ".") {
+		p.tokens[len(p.tokens)-1].text = strings.TrimSuffix(last.text, ".")
+	}
+}
+
+// openCount returns the number of parentheses still to be closed
+func (p *InputParser) openCount() int {
+	count := 0
+	for _, tok := range p.tokens {
+		switch tok.kind {
+		case tokOpen:
+			count++
+		case tokClose:
+			count--
+		}
+	}
+	return count
+}
+
+// last returns the final token, if any
+func (p *InputParser) last() (token, bool) {
+	if len(p.tokens) == 0 {
+		return token{}, false
+	}
+	return p.tokens[len(p.tokens)-1], true
+}
+
+// push appends a token
+func (p *InputParser) push(kind tokenKind, text string) {
+	p.tokens = append(p.tokens, token{kind: kind, text: text})
+}
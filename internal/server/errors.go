@@ -0,0 +1,72 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"calculator/internal/calculation"
+)
+
+// Error codes returned in error_code fields and error responses
+const (
+	// CodeInvalidRequest means the request body is not the expected JSON
+	CodeInvalidRequest = "invalid_request"
+	// CodeEmptyExpression means no expression was given
+	CodeEmptyExpression = "empty_expression"
+	// CodeSyntaxError means the expression could not be parsed
+	CodeSyntaxError = "syntax_error"
+	// CodeDivisionByZero means the expression divides by zero
+	CodeDivisionByZero = "division_by_zero"
+	// CodeEvaluationError means the expression parsed but could not be evaluated
+	CodeEvaluationError = "evaluation_error"
+	// CodeLimitExceeded means the expression exceeded a resource limit, such
+	// as its length, nesting depth or the size of an intermediate result
+	CodeLimitExceeded = "limit_exceeded"
+	// CodeRequestTooLarge means the request body exceeds the size limit
+	CodeRequestTooLarge = "request_too_large"
+	// CodeBatchTooLarge means a batch has more expressions than allowed
+	CodeBatchTooLarge = "batch_too_large"
+	// CodeTimeout means evaluation took longer than the per-request timeout
+	CodeTimeout = "timeout"
+	// CodeMethodNotAllowed means the route does not accept the HTTP method
+	CodeMethodNotAllowed = "method_not_allowed"
+	// CodeNotFound means there is no such route
+	CodeNotFound = "not_found"
+)
+
+// errEmptyExpression is returned for a missing or blank expression
+var errEmptyExpression = errors.New("expression cannot be empty")
+
+// errorCode classifies an expression error
+func errorCode(err error) string {
+	var syntax *calculation.SyntaxError
+	var limit *calculation.LimitError
+	switch {
+	case errors.Is(err, errEmptyExpression):
+		return CodeEmptyExpression
+	case errors.As(err, &syntax):
+		return CodeSyntaxError
+	case errors.As(err, &limit):
+		return CodeLimitExceeded
+	case errors.Is(err, calculation.ErrDivisionByZero):
+		return CodeDivisionByZero
+	default:
+		return CodeEvaluationError
+	}
+}
+
+// errorResponse is the body of a response for a request that failed as a whole
+type errorResponse struct {
+	Error apiError `json:"error"`
+}
+
+// apiError is a typed error code with a human-readable message
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// writeError writes an error response with the given status and code
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, errorResponse{Error: apiError{Code: code, Message: message}})
+}
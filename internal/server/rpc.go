@@ -0,0 +1,267 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"calculator/internal/history"
+	"calculator/internal/models"
+)
+
+// JSON-RPC 2.0 error codes. The -32000 range is reserved for application errors
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+	// rpcExpressionError reports an expression that failed; data.error_code
+	// holds the same code the HTTP API uses
+	rpcExpressionError = -32000
+	// rpcTimeout reports an evaluation that exceeded the per-request timeout
+	rpcTimeout = -32001
+)
+
+// rpcRequest is a JSON-RPC 2.0 request or notification
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+// rpcResponse is a JSON-RPC 2.0 response carrying either a result or an error
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// rpcError is the error member of a JSON-RPC response
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// rpcSession is one ServeRPC connection with its own calculation history
+type rpcSession struct {
+	server  *Server
+	ctx     context.Context
+	history *history.HistoryManager
+}
+
+// ServeRPC speaks JSON-RPC 2.0 over a pair of streams, one message per line,
+// until the input ends or ctx is cancelled. A line holds a request, a
+// notification or a batch array. The methods are:
+//
+//	calculate    {"expression": "2 + 2"} or ["2 + 2"]  -> Calculation
+//	validate     {"expression": "2 +"} or ["2 +"]      -> {"valid": false, ...}
+//	operations                                         -> operators and functions
+//	history.list {"limit": 10} (optional)              -> {"calculations": [...]}
+//
+// Calculations made in the session are kept for history.list, up to historySize
+func (s *Server) ServeRPC(ctx context.Context, in io.Reader, out io.Writer, historySize int) error {
+	session := &rpcSession{server: s, ctx: ctx, history: history.NewHistoryManager(historySize)}
+	reader := bufio.NewReader(in)
+	encoder := json.NewEncoder(out)
+
+	for ctx.Err() == nil {
+		line, tooLong, err := readLine(reader, s.maxBodyBytes)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to read request: %w", err)
+		}
+
+		var reply any
+		switch {
+		case tooLong:
+			reply = errorReply(nil, rpcInvalidRequest, fmt.Sprintf("request exceeds %d bytes", s.maxBodyBytes), nil)
+		case len(bytes.TrimSpace(line)) > 0:
+			reply = session.handleMessage(line)
+		}
+		if reply != nil {
+			if err := encoder.Encode(reply); err != nil {
+				return fmt.Errorf("failed to write response: %w", err)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+// handleMessage processes one line, returning the reply or nil when there is
+// nothing to send (a notification or a batch of notifications)
+func (rs *rpcSession) handleMessage(line []byte) any {
+	line = bytes.TrimSpace(line)
+	if line[0] != '[' {
+		var req rpcRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			return errorReply(nil, rpcParseError, fmt.Sprintf("parse error: %v", err), nil)
+		}
+		if reply := rs.handleRequest(req); reply != nil {
+			return reply
+		}
+		return nil
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(line, &batch); err != nil {
+		return errorReply(nil, rpcParseError, fmt.Sprintf("parse error: %v", err), nil)
+	}
+	if len(batch) == 0 {
+		return errorReply(nil, rpcInvalidRequest, "empty batch", nil)
+	}
+	if len(batch) > rs.server.maxBatch {
+		return errorReply(nil, rpcInvalidRequest, fmt.Sprintf("batch has %d requests, the limit is %d", len(batch), rs.server.maxBatch), nil)
+	}
+
+	var replies []*rpcResponse
+	for _, raw := range batch {
+		var req rpcRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			replies = append(replies, errorReply(nil, rpcInvalidRequest, "invalid request", nil))
+			continue
+		}
+		if reply := rs.handleRequest(req); reply != nil {
+			replies = append(replies, reply)
+		}
+	}
+	if len(replies) == 0 {
+		return nil
+	}
+	return replies
+}
+
+// handleRequest dispatches a single request. Notifications, which have no
+// id, are processed but get no reply
+func (rs *rpcSession) handleRequest(req rpcRequest) *rpcResponse {
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return errorReply(req.ID, rpcInvalidRequest, `invalid request: expected "jsonrpc": "2.0" and a method`, nil)
+	}
+
+	result, rpcErr := rs.call(req.Method, req.Params)
+	if req.ID == nil {
+		return nil
+	}
+	if rpcErr != nil {
+		return &rpcResponse{JSONRPC: "2.0", Error: rpcErr, ID: req.ID}
+	}
+	return &rpcResponse{JSONRPC: "2.0", Result: result, ID: req.ID}
+}
+
+// call runs a method with its parameters. The session is long-lived, so a
+// panicking method is reported as an internal error instead of ending it;
+// calculate already reports a panicking evaluation as an expression error
+func (rs *rpcSession) call(method string, params json.RawMessage) (result any, rpcErr *rpcError) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, rpcErr = nil, &rpcError{Code: rpcInternalError, Message: fmt.Sprintf("internal error: %v", r)}
+		}
+	}()
+
+	switch method {
+	case "calculate":
+		expression, err := expressionParam(params)
+		if err != nil {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		calc, ok := runWithTimeout(rs.ctx, rs.server.timeout, func(ctx context.Context) calculationResponse {
+			return rs.server.calculate(ctx, expression)
+		})
+		if !ok {
+			return nil, &rpcError{Code: rpcTimeout, Message: fmt.Sprintf("evaluation took longer than %s", rs.server.timeout)}
+		}
+		if calc.Expression != "" {
+			rs.history.AddCalculation(calc.Calculation)
+		}
+		if calc.ErrorCode != "" {
+			return nil, &rpcError{Code: rpcExpressionError, Message: calc.Error, Data: calc}
+		}
+		return calc, nil
+	case "validate":
+		expression, err := expressionParam(params)
+		if err != nil {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		if err := rs.server.validate(expression); err != nil {
+			return validateResponse{Error: err.Error(), ErrorCode: errorCode(err)}, nil
+		}
+		return validateResponse{Valid: true}, nil
+	case "operations":
+		return map[string][]string{
+			"operators": rs.server.engine.GetSupportedOperations(),
+			"functions": rs.server.engine.GetSupportedFunctions(),
+		}, nil
+	case "history.list":
+		var p struct {
+			Limit int `json:"limit"`
+		}
+		if len(params) > 0 {
+			if err := json.Unmarshal(params, &p); err != nil || p.Limit < 0 {
+				return nil, &rpcError{Code: rpcInvalidParams, Message: `invalid params: expected {"limit": n} with n >= 0`}
+			}
+		}
+		calcs := rs.history.GetHistory()
+		if p.Limit > 0 && len(calcs) > p.Limit {
+			calcs = calcs[len(calcs)-p.Limit:]
+		}
+		if calcs == nil {
+			calcs = []models.Calculation{}
+		}
+		return map[string][]models.Calculation{"calculations": calcs}, nil
+	}
+	return nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("method not found: %s", method)}
+}
+
+// expressionParam reads the expression from {"expression": "..."} or ["..."]
+func expressionParam(params json.RawMessage) (string, error) {
+	var named struct {
+		Expression *string `json:"expression"`
+	}
+	if err := json.Unmarshal(params, &named); err == nil && named.Expression != nil {
+		return *named.Expression, nil
+	}
+	var positional []string
+	if err := json.Unmarshal(params, &positional); err == nil && len(positional) == 1 {
+		return positional[0], nil
+	}
+	return "", fmt.Errorf(`invalid params: expected {"expression": "..."} or ["..."]`)
+}
+
+// errorReply builds an error response
+func errorReply(id json.RawMessage, code int, message string, data any) *rpcResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &rpcResponse{JSONRPC: "2.0", Error: &rpcError{Code: code, Message: message, Data: data}, ID: id}
+}
+
+// readLine reads one line without its newline. A line longer than max is
+// consumed and reported as too long instead of being returned
+func readLine(r *bufio.Reader, max int64) ([]byte, bool, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if int64(len(line)+len(chunk)) <= max+1 {
+			line = append(line, chunk...)
+		} else {
+			line = line[:0]
+			max = -1
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if max < 0 {
+			return nil, true, err
+		}
+		return bytes.TrimRight(line, "\r\n"), false, err
+	}
+}
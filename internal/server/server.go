@@ -0,0 +1,327 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"calculator/internal/calculation"
+	"calculator/internal/models"
+)
+
+// Defaults applied when no Option overrides them
+const (
+	// DefaultTimeout bounds the time spent evaluating one request
+	DefaultTimeout = 5 * time.Second
+	// DefaultMaxBodyBytes bounds the size of a request body
+	DefaultMaxBodyBytes = 1 << 20
+	// DefaultMaxBatch bounds the number of expressions in one batch request
+	DefaultMaxBatch = 1000
+)
+
+// Server exposes a CalculationEngine as a local HTTP JSON API:
+//
+//	POST /v1/calculate   {"expression": "2 + 2"}        -> Calculation
+//	POST /v1/validate    {"expression": "2 +"}          -> {"valid": false, ...}
+//	GET  /v1/operations                                 -> operators and functions
+//	POST /v1/batch       {"expressions": ["1+1", "2*3"]} -> {"results": [Calculation, ...]}
+//
+// Calculations use the JSON shape from docs/architecture/data-models.md, with
+// error and error_code set when an expression fails
+type Server struct {
+	engine       *calculation.CalculationEngine
+	timeout      time.Duration
+	maxBodyBytes int64
+	maxBatch     int
+	nextID       atomic.Int64
+}
+
+// Option configures a Server at construction time
+type Option func(*Server)
+
+// WithTimeout sets how long a request may spend evaluating before it fails with a timeout
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.timeout = timeout
+	}
+}
+
+// WithMaxBodyBytes sets the largest request body accepted
+func WithMaxBodyBytes(n int64) Option {
+	return func(s *Server) {
+		s.maxBodyBytes = n
+	}
+}
+
+// WithMaxBatch sets the largest number of expressions accepted in one batch
+func WithMaxBatch(n int) Option {
+	return func(s *Server) {
+		s.maxBatch = n
+	}
+}
+
+// New creates a server that evaluates with the given engine
+func New(engine *calculation.CalculationEngine, opts ...Option) *Server {
+	s := &Server{
+		engine:       engine,
+		timeout:      DefaultTimeout,
+		maxBodyBytes: DefaultMaxBodyBytes,
+		maxBatch:     DefaultMaxBatch,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Handler returns the HTTP handler serving the API routes
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/calculate", s.route(http.MethodPost, s.handleCalculate))
+	mux.HandleFunc("/v1/validate", s.route(http.MethodPost, s.handleValidate))
+	mux.HandleFunc("/v1/operations", s.route(http.MethodGet, s.handleOperations))
+	mux.HandleFunc("/v1/batch", s.route(http.MethodPost, s.handleBatch))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, CodeNotFound, fmt.Sprintf("no route for %s", r.URL.Path))
+	})
+	return mux
+}
+
+// ListenAndServe serves the API on addr until ctx is cancelled, then shuts
+// down gracefully. ready is called with the bound address once listening
+func (s *Server) ListenAndServe(ctx context.Context, addr string, ready func(net.Addr)) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	if ready != nil {
+		ready(listener.Addr())
+	}
+
+	// WriteTimeout counts from the end of the request headers, so it leaves
+	// room for the evaluation timeout before the response is written
+	httpServer := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      s.timeout + 10*time.Second,
+	}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- httpServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		defer cancel()
+		return httpServer.Shutdown(shutdownCtx)
+	}
+}
+
+// expressionRequest is the body of /v1/calculate and /v1/validate
+type expressionRequest struct {
+	Expression string `json:"expression"`
+}
+
+// batchRequest is the body of /v1/batch
+type batchRequest struct {
+	Expressions []string `json:"expressions"`
+}
+
+// calculationResponse is a Calculation with a typed error code when it failed
+type calculationResponse struct {
+	models.Calculation
+	ErrorCode string `json:"error_code,omitempty"`
+}
+
+// validateResponse is the body returned by /v1/validate
+type validateResponse struct {
+	Valid     bool   `json:"valid"`
+	Error     string `json:"error,omitempty"`
+	ErrorCode string `json:"error_code,omitempty"`
+}
+
+// route wraps a handler so that it only accepts the given method
+func (s *Server) route(method string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, fmt.Sprintf("%s requires %s", r.URL.Path, method))
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func (s *Server) handleCalculate(w http.ResponseWriter, r *http.Request) {
+	var req expressionRequest
+	if !s.decode(w, r, &req) {
+		return
+	}
+
+	calc, ok := runWithTimeout(r.Context(), s.timeout, func(ctx context.Context) calculationResponse {
+		return s.calculate(ctx, req.Expression)
+	})
+	if !ok {
+		writeError(w, http.StatusGatewayTimeout, CodeTimeout, fmt.Sprintf("evaluation took longer than %s", s.timeout))
+		return
+	}
+
+	status := http.StatusOK
+	switch calc.ErrorCode {
+	case "":
+	case CodeEmptyExpression:
+		status = http.StatusBadRequest
+	default:
+		status = http.StatusUnprocessableEntity
+	}
+	writeJSON(w, status, calc)
+}
+
+func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	var req expressionRequest
+	if !s.decode(w, r, &req) {
+		return
+	}
+
+	resp := validateResponse{Valid: true}
+	if err := s.validate(req.Expression); err != nil {
+		resp = validateResponse{Error: err.Error(), ErrorCode: errorCode(err)}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleOperations(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string][]string{
+		"operators": s.engine.GetSupportedOperations(),
+		"functions": s.engine.GetSupportedFunctions(),
+	})
+}
+
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	var req batchRequest
+	if !s.decode(w, r, &req) {
+		return
+	}
+	if len(req.Expressions) == 0 {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "expressions must be a non-empty list")
+		return
+	}
+	if len(req.Expressions) > s.maxBatch {
+		writeError(w, http.StatusRequestEntityTooLarge, CodeBatchTooLarge,
+			fmt.Sprintf("batch has %d expressions, the limit is %d", len(req.Expressions), s.maxBatch))
+		return
+	}
+
+	results, ok := runWithTimeout(r.Context(), s.timeout, func(ctx context.Context) []calculationResponse {
+		results := make([]calculationResponse, len(req.Expressions))
+		for i, expr := range req.Expressions {
+			results[i] = s.calculate(ctx, expr)
+		}
+		return results
+	})
+	if !ok {
+		writeError(w, http.StatusGatewayTimeout, CodeTimeout, fmt.Sprintf("batch took longer than %s", s.timeout))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]calculationResponse{"results": results})
+}
+
+// calculate evaluates one expression into a Calculation record, giving up
+// when ctx is done. It runs in runWithTimeout's goroutine, where a panic would
+// take the whole process down, so a panicking evaluation is recovered and
+// reported as an evaluation error
+func (s *Server) calculate(ctx context.Context, expression string) (resp calculationResponse) {
+	defer func() {
+		if r := recover(); r != nil {
+			resp.Result, resp.ResultText = 0, ""
+			resp.Error, resp.ErrorCode = fmt.Sprintf("evaluation failed: %v", r), CodeEvaluationError
+		}
+	}()
+
+	resp = calculationResponse{Calculation: models.Calculation{
+		ID:         fmt.Sprintf("calc-%d", s.nextID.Add(1)),
+		Expression: expression,
+		Timestamp:  time.Now(),
+		Operation:  "expression",
+		Operands:   []float64{},
+	}}
+
+	if strings.TrimSpace(expression) == "" {
+		resp.Error, resp.ErrorCode = errEmptyExpression.Error(), CodeEmptyExpression
+		return resp
+	}
+	result, err := s.engine.CalculateContext(ctx, expression)
+	if err != nil {
+		resp.Error, resp.ErrorCode = err.Error(), errorCode(err)
+		return resp
+	}
+	if n, ok := result.(calculation.Number); ok {
+		resp.Result = n.Float64()
+	}
+	resp.ResultText = s.engine.FormatResult(result)
+	return resp
+}
+
+// validate checks an expression's syntax without evaluating it
+func (s *Server) validate(expression string) error {
+	if strings.TrimSpace(expression) == "" {
+		return errEmptyExpression
+	}
+	return s.engine.CheckSyntax(expression)
+}
+
+// decode reads a JSON request body, writing an error response and returning
+// false if the body is too large or malformed
+func (s *Server) decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	body := http.MaxBytesReader(w, r.Body, s.maxBodyBytes)
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, CodeRequestTooLarge,
+				fmt.Sprintf("request body exceeds %d bytes", s.maxBodyBytes))
+			return false
+		}
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, fmt.Sprintf("invalid JSON body: %v", err))
+		return false
+	}
+	return true
+}
+
+// runWithTimeout runs fn, giving up when ctx is done or the timeout passes.
+// fn is passed the bounded context so that an abandoned evaluation stops
+// promptly in the background; its result is discarded
+func runWithTimeout[T any](ctx context.Context, timeout time.Duration, fn func(context.Context) T) (T, bool) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan T, 1)
+	go func() {
+		done <- fn(ctx)
+	}()
+	select {
+	case result := <-done:
+		return result, true
+	case <-ctx.Done():
+		var zero T
+		return zero, false
+	}
+}
+
+// writeJSON writes v as the JSON response body with the given status
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
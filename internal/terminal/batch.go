@@ -0,0 +1,85 @@
+package terminal
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"calculator/internal/calculation"
+)
+
+// runBatch implements --batch: it evaluates every expression in a file (or
+// stdin for "-") on a pool of workers, prints one result per line in input
+// order and reports aggregate statistics on stderr. Blank lines and lines
+// starting with # are skipped. Interrupting stops the batch early
+func runBatch(engine *calculation.CalculationEngine, path string, workers int, vars map[string]calculation.Value, stdin io.Reader, stdout, stderr io.Writer) int {
+	expressions, err := readBatch(path, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	start := time.Now()
+	results := engine.CalculateBatch(ctx, expressions,
+		calculation.WithWorkers(workers), calculation.WithBatchVariables(vars))
+	elapsed := time.Since(start)
+
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(stdout, "Error: %v\n", r.Err)
+			continue
+		}
+		fmt.Fprintln(stdout, engine.FormatResult(r.Value))
+	}
+
+	stats := calculation.SummarizeBatch(results)
+	summary := fmt.Sprintf("%d expressions: %d succeeded, %d failed", stats.Total, stats.Succeeded, stats.Failed)
+	if stats.Cancelled > 0 {
+		summary += fmt.Sprintf(", %d cancelled", stats.Cancelled)
+	}
+	fmt.Fprintf(stderr, "%s in %s (slowest %s)\n", summary, elapsed.Round(time.Microsecond), stats.Slowest.Round(time.Microsecond))
+	if cache := engine.Cache(); cache != nil {
+		cs := cache.Stats()
+		fmt.Fprintf(stderr, "cache: %d hits, %d misses\n", cs.Hits, cs.Misses)
+	}
+
+	if stats.Succeeded < stats.Total {
+		return 1
+	}
+	return 0
+}
+
+// readBatch reads the expressions of a batch file
+func readBatch(path string, stdin io.Reader) ([]string, error) {
+	in := stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open batch file: %w", err)
+		}
+		defer file.Close()
+		in = file
+	}
+
+	var expressions []string
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		expressions = append(expressions, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read batch file: %w", err)
+	}
+	return expressions, nil
+}
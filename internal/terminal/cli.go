@@ -0,0 +1,199 @@
+package terminal
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"calculator/internal/calculation"
+	"calculator/internal/config"
+)
+
+// Run parses command-line arguments, evaluates the expression given on the
+// command line, or starts the interactive REPL when there is none, and
+// returns the process exit code. "calculator serve" starts the HTTP API,
+// "calculator rpc" speaks JSON-RPC over stdio, "calculator run" executes a
+// script, "calculator assert" checks an expression and "calculator stats"
+// summarizes a column of numbers instead
+// Source: docs/stories/1.3.story.md - Basic Command-Line Interface
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "serve":
+			return runServe(args[1:], stdout, stderr)
+		case "rpc":
+			return runRPC(args[1:], stdin, stdout, stderr)
+		case "run":
+			return runScript(args[1:], stdin, stdout, stderr)
+		case "assert":
+			return runAssert(args[1:], stderr)
+		case "stats":
+			return runStats(args[1:], stdin, stdout, stderr)
+		}
+	}
+
+	flags := flag.NewFlagSet("calculator", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configPath := flags.String("config", config.DefaultConfigPath(), "path to config.yaml")
+	rpn := flags.Bool("rpn", false, "use Reverse Polish Notation")
+	desk := flags.Bool("desk", false, "start the interactive prompt in running-total desk mode")
+	tui := flags.Bool("tui", false, "start the full-screen keypad calculator")
+	batch := flags.String("batch", "", "evaluate the expressions in a file, one per line (- for stdin)")
+	workers := flags.Int("workers", runtime.GOMAXPROCS(0), "number of expressions --batch evaluates at once")
+	if err := flags.Parse(expressionAfterFlags(flags, args)); err != nil {
+		return 2
+	}
+
+	cfg, err := config.LoadConfigOrDefault(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	engine, err := NewEngine(cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	memoryPath := MemoryPath(*configPath)
+	memory, err := calculation.LoadMemory(memoryPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *tui {
+		return runTUI(engine, stdin, stdout, stderr)
+	}
+	if *batch != "" {
+		return runBatch(engine, *batch, *workers, memory.Variables(), stdin, stdout, stderr)
+	}
+
+	if flags.NArg() == 0 {
+		mode := ModeInfix
+		switch {
+		case *rpn:
+			mode = ModeRPN
+		case *desk:
+			mode = ModeDesk
+		}
+		return NewREPL(engine, mode, stdout, stderr, WithUndoDepth(cfg.UndoDepth),
+			WithHistorySize(cfg.MaxHistory), WithMemory(memory, memoryPath)).Run(stdin)
+	}
+
+	expression := strings.Join(flags.Args(), " ")
+	if *rpn {
+		stack := engine.NewRPNStack()
+		if err := stack.Eval(expression); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Fprintln(stdout, stack)
+		return 0
+	}
+
+	result, err := engine.EvaluateWithVariables(expression, memory.Variables())
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(stdout, engine.FormatResult(result))
+	return 0
+}
+
+// expressionAfterFlags inserts "--" before the first argument that starts
+// with a single "-" but names none of the flags, so that negative expressions
+// such as calculator -5 EUR are evaluated instead of rejected as unknown
+// flags. Unknown "--name" arguments are left for the flag package to reject
+func expressionAfterFlags(flags *flag.FlagSet, args []string) []string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || arg == "-" || !strings.HasPrefix(arg, "-") {
+			return args
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		f := flags.Lookup(name)
+		if f == nil {
+			if strings.HasPrefix(arg, "--") || name == "h" || name == "help" {
+				return args
+			}
+			return append(append(args[:i:i], "--"), args[i:]...)
+		}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !hasValue && !(ok && b.IsBoolFlag()) {
+			i++ // the next argument is the flag's value
+		}
+	}
+	return args
+}
+
+// MemoryPath returns where memory registers are saved: memory.json next to
+// the config file, so ~/.calculator/memory.json by default
+func MemoryPath(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), "memory.json")
+}
+
+// NewEngine builds a calculation engine configured from the user's configuration
+func NewEngine(cfg *config.Configuration) (*calculation.CalculationEngine, error) {
+	var opts []calculation.EngineOption
+
+	switch mode := calculation.PercentMode(cfg.PercentMode); mode {
+	case calculation.PercentDesk, calculation.PercentMath:
+		opts = append(opts, calculation.WithPercentMode(mode))
+	default:
+		return nil, fmt.Errorf("invalid percent_mode %q: expected desk or math", cfg.PercentMode)
+	}
+
+	switch form := calculation.ComplexForm(cfg.ComplexForm); form {
+	case calculation.ComplexRectangular, calculation.ComplexPolar:
+		opts = append(opts, calculation.WithComplexMode(cfg.ComplexMode), calculation.WithComplexForm(form))
+	default:
+		return nil, fmt.Errorf("invalid complex_form %q: expected rectangular or polar", cfg.ComplexForm)
+	}
+
+	if cfg.CurrencyRatesFile != "" {
+		rates, err := calculation.LoadRateTable(cfg.CurrencyRatesFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, calculation.WithRateTable(rates))
+	}
+
+	opts = append(opts, calculation.WithLimits(calculation.Limits{
+		MaxExpressionLength: cfg.MaxExpressionLength,
+		MaxDepth:            cfg.MaxDepth,
+		MaxDigits:           cfg.MaxDigits,
+		MaxExponent:         cfg.MaxExponent,
+		MaxSteps:            cfg.MaxSteps,
+		MaxIterations:       cfg.MaxIterations,
+	}))
+
+	if cfg.Tolerance < 0 {
+		return nil, fmt.Errorf("invalid tolerance %v: must not be negative", cfg.Tolerance)
+	}
+	opts = append(opts, calculation.WithTolerance(cfg.Tolerance))
+
+	if cfg.CacheSize > 0 {
+		opts = append(opts, calculation.WithCache(calculation.NewResultCache(cfg.CacheSize)))
+	}
+
+	return calculation.NewCalculationEngine(opts...), nil
+}
+
+// runTUI starts the full-screen calculator on an interactive terminal
+func runTUI(engine *calculation.CalculationEngine, stdin io.Reader, stdout, stderr io.Writer) int {
+	in, ok := stdin.(*os.File)
+	if !ok {
+		fmt.Fprintln(stderr, "Error: full-screen mode needs an interactive terminal")
+		return 1
+	}
+	if err := NewTerminalUI(engine).Run(in, stdout); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,118 @@
+package terminal
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Key identifies a keyboard key that does not produce a printable character
+type Key int
+
+const (
+	// KeyRune is a printable character held in KeyEvent.Rune
+	KeyRune Key = iota
+	KeyEnter
+	KeyBackspace
+	KeyEscape
+	KeyCtrlC
+	KeyTab
+	KeyBacktab
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+)
+
+// Event is a decoded terminal input event: a KeyEvent or a MouseEvent
+type Event interface {
+	isEvent()
+}
+
+// KeyEvent is a single key press
+type KeyEvent struct {
+	Key  Key
+	Rune rune
+}
+
+// MouseEvent is a mouse button press or release at a zero-based cell position
+type MouseEvent struct {
+	X, Y    int
+	Button  int
+	Pressed bool
+}
+
+func (KeyEvent) isEvent()   {}
+func (MouseEvent) isEvent() {}
+
+// csiKeys maps the final byte of CSI cursor and tab sequences to keys
+var csiKeys = map[byte]Key{
+	'A': KeyUp,
+	'B': KeyDown,
+	'C': KeyRight,
+	'D': KeyLeft,
+	'Z': KeyBacktab,
+}
+
+// DecodeInput splits raw terminal input into key and mouse events. It
+// understands xterm cursor keys and SGR (1006) mouse reports; an ESC that
+// does not start a known sequence is reported as KeyEscape
+func DecodeInput(buf []byte) []Event {
+	var events []Event
+	for len(buf) > 0 {
+		switch c := buf[0]; {
+		case c == 0x1b:
+			event, size := decodeEscape(buf)
+			events = append(events, event)
+			buf = buf[size:]
+			continue
+		case c == '\r' || c == '\n':
+			events = append(events, KeyEvent{Key: KeyEnter})
+		case c == 0x7f || c == 0x08:
+			events = append(events, KeyEvent{Key: KeyBackspace})
+		case c == 0x03:
+			events = append(events, KeyEvent{Key: KeyCtrlC})
+		case c == '\t':
+			events = append(events, KeyEvent{Key: KeyTab})
+		case c < 0x20:
+			// Other control characters are ignored
+		default:
+			r, size := utf8.DecodeRune(buf)
+			events = append(events, KeyEvent{Key: KeyRune, Rune: r})
+			buf = buf[size:]
+			continue
+		}
+		buf = buf[1:]
+	}
+	return events
+}
+
+// decodeEscape decodes an escape sequence at the start of buf, returning the event and its length
+func decodeEscape(buf []byte) (Event, int) {
+	if len(buf) < 3 || (buf[1] != '[' && buf[1] != 'O') {
+		return KeyEvent{Key: KeyEscape}, 1
+	}
+	if key, ok := csiKeys[buf[2]]; ok {
+		return KeyEvent{Key: key}, 3
+	}
+	if buf[1] != '[' || buf[2] != '<' {
+		return KeyEvent{Key: KeyEscape}, 1
+	}
+
+	// SGR mouse report: ESC [ < button ; x ; y (M | m)
+	end := strings.IndexAny(string(buf), "Mm")
+	if end < 0 {
+		return KeyEvent{Key: KeyEscape}, 1
+	}
+	fields := strings.Split(string(buf[3:end]), ";")
+	if len(fields) != 3 {
+		return KeyEvent{Key: KeyEscape}, 1
+	}
+	button, errB := strconv.Atoi(fields[0])
+	x, errX := strconv.Atoi(fields[1])
+	y, errY := strconv.Atoi(fields[2])
+	if errB != nil || errX != nil || errY != nil {
+		return KeyEvent{Key: KeyEscape}, 1
+	}
+	return MouseEvent{X: x - 1, Y: y - 1, Button: button, Pressed: buf[end] == 'M'}, end + 1
+}
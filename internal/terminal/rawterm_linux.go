@@ -0,0 +1,60 @@
+//go:build linux
+
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// makeRaw switches the terminal on fd to raw mode, returning a function that restores it
+func makeRaw(fd uintptr) (func(), error) {
+	var original syscall.Termios
+	if err := ioctl(fd, syscall.TCGETS, unsafe.Pointer(&original)); err != nil {
+		return nil, fmt.Errorf("full-screen mode needs an interactive terminal: %w", err)
+	}
+
+	raw := original
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctl(fd, syscall.TCSETS, unsafe.Pointer(&raw)); err != nil {
+		return nil, fmt.Errorf("failed to enter raw mode: %w", err)
+	}
+
+	return func() {
+		_ = ioctl(fd, syscall.TCSETS, unsafe.Pointer(&original))
+	}, nil
+}
+
+// windowSize returns the terminal size in character cells
+func windowSize(fd uintptr) (int, int, error) {
+	var ws struct {
+		Row, Col, Xpixel, Ypixel uint16
+	}
+	if err := ioctl(fd, syscall.TIOCGWINSZ, unsafe.Pointer(&ws)); err != nil {
+		return 0, 0, fmt.Errorf("failed to read terminal size: %w", err)
+	}
+	return int(ws.Col), int(ws.Row), nil
+}
+
+// ioctl issues a terminal control request
+func ioctl(fd, request uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// notifyResize delivers SIGWINCH to ch whenever the terminal is resized
+func notifyResize(ch chan<- os.Signal) {
+	signal.Notify(ch, syscall.SIGWINCH)
+}
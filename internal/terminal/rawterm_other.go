@@ -0,0 +1,21 @@
+//go:build !linux
+
+package terminal
+
+import (
+	"fmt"
+	"os"
+)
+
+// makeRaw is only implemented for Linux terminals
+func makeRaw(fd uintptr) (func(), error) {
+	return nil, fmt.Errorf("full-screen mode is only supported on Linux")
+}
+
+// windowSize is only implemented for Linux terminals
+func windowSize(fd uintptr) (int, int, error) {
+	return 0, 0, fmt.Errorf("full-screen mode is only supported on Linux")
+}
+
+// notifyResize is a no-op where terminal resize signals are unavailable
+func notifyResize(ch chan<- os.Signal) {}
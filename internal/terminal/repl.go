@@ -0,0 +1,614 @@
+package terminal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"calculator/internal/calculation"
+	"calculator/internal/history"
+	"calculator/internal/models"
+)
+
+// InputMode selects how the REPL interprets a line of input
+type InputMode string
+
+const (
+	// ModeInfix evaluates each line as an ordinary expression
+	ModeInfix InputMode = "infix"
+	// ModeRPN pushes each line onto a Reverse Polish Notation stack
+	ModeRPN InputMode = "rpn"
+	// ModeDesk applies lines such as "+ 15" to a running total kept on a tape
+	ModeDesk InputMode = "desk"
+)
+
+// replHelp lists the commands available at the prompt
+const replHelp = `Commands:
+  mode infix   evaluate expressions such as (3 + 4) * 2
+  mode rpn     Reverse Polish Notation: 3 4 + 2 *
+  mode desk    running total: 100, then + 15, * 1.2, / 4
+  x = expr     assign a variable; ans holds the last result
+  f(x) = expr  define a function, e.g. tax(x) = x <= 10000 ? 0 : x * 0.2
+  vars         list variables
+  M+, M-, MS [register]
+               add, subtract or store the last result in memory (M by
+               default; numbered M1, M2 or named registers also work)
+  MR [register], MC [register|all], memory
+               recall, clear or list memory; registers work in
+               expressions, e.g. M1 * 2
+  history      list calculations (history clear to empty it)
+  :undo        undo the last change to variables, history, stack or tape
+  :redo        redo the last undone change
+  help         show this help
+  exit, quit   leave the calculator
+
+RPN stack commands: dup, swap, drop, roll, clear, depth
+Desk commands: subtotal (or =), total, tape, clear, note <text>,
+  export <file>, import <file> (.txt, .csv, .md or .json)`
+
+// REPL is the interactive read-eval-print loop
+// Source: docs/stories/1.3.story.md - Basic CLI REPL
+type REPL struct {
+	engine     *calculation.CalculationEngine
+	mode       InputMode
+	state      sessionState
+	undo       *history.UndoStack[sessionState]
+	memoryPath string
+	out        io.Writer
+	errOut     io.Writer
+}
+
+// REPLOption configures a REPL at construction time
+type REPLOption func(*replSettings)
+
+// replSettings collects the values set by REPLOptions
+type replSettings struct {
+	undoDepth   int
+	historySize int
+	memory      *calculation.Memory
+	memoryPath  string
+}
+
+// WithUndoDepth sets how many changes :undo can step back through
+func WithUndoDepth(depth int) REPLOption {
+	return func(s *replSettings) {
+		s.undoDepth = depth
+	}
+}
+
+// WithHistorySize sets how many calculations the session history keeps
+func WithHistorySize(size int) REPLOption {
+	return func(s *replSettings) {
+		s.historySize = size
+	}
+}
+
+// WithMemory starts the session with previously saved memory registers and
+// saves them to path whenever they change; an empty path keeps them in memory only
+func WithMemory(memory *calculation.Memory, path string) REPLOption {
+	return func(s *replSettings) {
+		s.memory = memory
+		s.memoryPath = path
+	}
+}
+
+// NewREPL creates a REPL that starts in the given input mode
+func NewREPL(engine *calculation.CalculationEngine, mode InputMode, stdout, stderr io.Writer, opts ...REPLOption) *REPL {
+	settings := replSettings{undoDepth: history.DefaultUndoDepth, memory: calculation.NewMemory()}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	return &REPL{
+		engine: engine,
+		mode:   mode,
+		state: sessionState{
+			vars:    map[string]calculation.Value{},
+			memory:  settings.memory,
+			history: history.NewHistoryManager(settings.historySize),
+			stack:   engine.NewRPNStack(),
+			desk:    engine.NewRunningTotal(),
+		},
+		undo:       history.NewUndoStack[sessionState](settings.undoDepth),
+		memoryPath: settings.memoryPath,
+		out:        stdout,
+		errOut:     stderr,
+	}
+}
+
+// Run reads lines from stdin until end of input or an exit command
+func (r *REPL) Run(stdin io.Reader) int {
+	scanner := bufio.NewScanner(stdin)
+	fmt.Fprintln(r.out, "Calculator application initialized")
+	fmt.Fprintln(r.out, "Type 'help' for commands, 'exit' to quit")
+
+	for {
+		fmt.Fprint(r.out, r.prompt())
+		if !scanner.Scan() {
+			fmt.Fprintln(r.out)
+			break
+		}
+		if !r.handleLine(strings.TrimSpace(scanner.Text())) {
+			break
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(r.errOut, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// prompt returns the input prompt for the current mode
+func (r *REPL) prompt() string {
+	switch r.mode {
+	case ModeRPN:
+		return "rpn> "
+	case ModeDesk:
+		return "desk> "
+	default:
+		return "> "
+	}
+}
+
+// handleLine processes one line of input and reports whether to keep reading
+func (r *REPL) handleLine(line string) bool {
+	switch {
+	case line == "":
+		return true
+	case line == "exit" || line == "quit":
+		return false
+	case line == "help":
+		fmt.Fprintln(r.out, replHelp)
+		return true
+	case r.handleModeCommand(line):
+		return true
+	case line == ":undo":
+		r.stepUndo(r.undo.Undo, "undo", "Undid")
+		return true
+	case line == ":redo":
+		r.stepUndo(r.undo.Redo, "redo", "Redid")
+		return true
+	case line == "vars":
+		r.printVariables()
+		return true
+	case line == "memory":
+		r.printMemory()
+		return true
+	case r.handleMemoryCommand(line):
+		return true
+	case line == "history":
+		r.printHistory()
+		return true
+	case line == "history clear":
+		r.change(line, func() error {
+			r.state.history.ClearHistory()
+			fmt.Fprintln(r.out, "History cleared")
+			return nil
+		})
+		return true
+	}
+
+	switch r.mode {
+	case ModeRPN:
+		r.change(line, func() error {
+			return r.state.stack.Eval(line)
+		})
+		fmt.Fprintln(r.out, r.state.stack)
+		return true
+	case ModeDesk:
+		r.handleDeskLine(line)
+		return true
+	}
+
+	r.change(line, func() error {
+		return r.evaluate(line)
+	})
+	return true
+}
+
+// evaluate evaluates an infix line or variable assignment, recording the
+// result in the session history and in ans
+func (r *REPL) evaluate(line string) error {
+	if isDefinition(line) {
+		fn, err := r.engine.DefineFunction(line, r.state.variables())
+		if err != nil {
+			return err
+		}
+		r.state.vars[fn.Name] = fn
+		fmt.Fprintln(r.out, fn)
+		return nil
+	}
+
+	name, expression, assignment := splitAssignment(line)
+	if assignment {
+		if err := calculation.ValidateVariableName(name); err != nil {
+			return err
+		}
+	}
+
+	result, err := r.engine.EvaluateWithVariables(expression, r.state.variables())
+	if err != nil {
+		return err
+	}
+
+	calc := models.Calculation{
+		Expression: line,
+		ResultText: r.engine.FormatResult(result),
+		Timestamp:  time.Now(),
+		Operation:  "expression",
+		Operands:   []float64{},
+	}
+	if n, ok := result.(calculation.Number); ok {
+		calc.Result = n.Float64()
+	}
+	if err := r.state.history.AddCalculation(calc); err != nil {
+		return err
+	}
+
+	r.state.vars["ans"] = result
+	if assignment {
+		r.state.vars[name] = result
+		fmt.Fprintf(r.out, "%s = %s\n", name, calc.ResultText)
+		return nil
+	}
+	fmt.Fprintln(r.out, calc.ResultText)
+	return nil
+}
+
+// splitAssignment splits "name = expression" into its parts. Lines without a
+// single "=" after a plain name are ordinary expressions
+func splitAssignment(line string) (name, expression string, ok bool) {
+	name, expression, found := strings.Cut(line, "=")
+	name = strings.TrimSpace(name)
+	if !found || name == "" || strings.HasPrefix(expression, "=") || strings.ContainsAny(name, " \t+-*/^%()<>!") {
+		return "", line, false
+	}
+	return name, strings.TrimSpace(expression), true
+}
+
+// isDefinition reports whether a line defines a function, e.g. "f(x) = x^2",
+// as opposed to the comparison "f(x) == 4"
+func isDefinition(line string) bool {
+	head, body, found := strings.Cut(line, "=")
+	head = strings.TrimSpace(head)
+	return found && !strings.HasPrefix(body, "=") && strings.Contains(head, "(") && strings.HasSuffix(head, ")")
+}
+
+// change runs a command that modifies the session state. If it succeeds the
+// previous state is kept for :undo; if it fails the state is restored and the
+// error reported
+func (r *REPL) change(label string, apply func() error) {
+	before := r.state.clone()
+	if err := apply(); err != nil {
+		r.state = before
+		fmt.Fprintf(r.errOut, "Error: %v\n", err)
+		return
+	}
+	r.undo.Record(label, before)
+}
+
+// stepUndo restores the state returned by :undo or :redo and reports the change
+func (r *REPL) stepUndo(step func(sessionState) (sessionState, string, bool), name, verb string) {
+	state, label, ok := step(r.state)
+	if !ok {
+		fmt.Fprintf(r.errOut, "Error: nothing to %s\n", name)
+		return
+	}
+	r.state = state
+	fmt.Fprintf(r.out, "%s: %s\n", verb, label)
+	r.saveMemory()
+}
+
+// printVariables lists the variables in name order
+func (r *REPL) printVariables() {
+	if len(r.state.vars) == 0 {
+		fmt.Fprintln(r.out, "(no variables)")
+		return
+	}
+	names := make([]string, 0, len(r.state.vars))
+	for name := range r.state.vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if fn, ok := r.state.vars[name].(*calculation.UserFunction); ok {
+			fmt.Fprintln(r.out, fn)
+			continue
+		}
+		fmt.Fprintf(r.out, "%s = %s\n", name, r.engine.FormatResult(r.state.vars[name]))
+	}
+}
+
+// printHistory lists the calculations evaluated in this session
+func (r *REPL) printHistory() {
+	calcs := r.state.history.GetHistory()
+	if len(calcs) == 0 {
+		fmt.Fprintln(r.out, "(no calculations yet)")
+		return
+	}
+	for i, calc := range calcs {
+		fmt.Fprintln(r.out, formatHistoryItem(i+1, calc))
+	}
+}
+
+// handleMemoryCommand runs M+, M-, MS, MR and MC, reporting whether the line
+// was one of them. M+, M- and MS take the current value: the last result in
+// infix mode, the top of the stack in RPN mode and the running total in desk mode
+func (r *REPL) handleMemoryCommand(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) > 2 {
+		return false
+	}
+	command := strings.ToUpper(fields[0])
+	register := ""
+	if len(fields) == 2 {
+		register = fields[1]
+	}
+
+	switch command {
+	case "M+", "M-", "MS":
+		r.change(line, func() error {
+			value, err := r.currentValue()
+			if err != nil {
+				return err
+			}
+			switch command {
+			case "M+":
+				err = r.state.memory.Add(register, value)
+			case "M-":
+				err = r.state.memory.Subtract(register, value)
+			default:
+				err = r.state.memory.Store(register, value)
+			}
+			if err != nil {
+				return err
+			}
+			r.printRegister(register)
+			return nil
+		})
+	case "MR":
+		r.change(line, func() error {
+			return r.recall(register)
+		})
+	case "MC":
+		r.change(line, func() error {
+			if register == "all" {
+				r.state.memory.ClearAll()
+				fmt.Fprintln(r.out, "Memory cleared")
+				return nil
+			}
+			r.state.memory.Clear(register)
+			r.printRegister(register)
+			return nil
+		})
+	default:
+		return false
+	}
+	r.saveMemory()
+	return true
+}
+
+// currentValue is the value M+, M- and MS act on in the current mode
+func (r *REPL) currentValue() (calculation.Value, error) {
+	switch r.mode {
+	case ModeRPN:
+		if top, ok := r.state.stack.Top(); ok {
+			return top, nil
+		}
+		return nil, fmt.Errorf("stack is empty: nothing to store")
+	case ModeDesk:
+		return r.engine.Evaluate(strconv.FormatFloat(r.state.desk.Result(), 'f', -1, 64))
+	}
+	if ans, ok := r.state.vars["ans"]; ok {
+		return ans, nil
+	}
+	return nil, fmt.Errorf("no result yet: nothing to store")
+}
+
+// recall brings a register's value back: as the new result in infix mode,
+// pushed onto the stack in RPN mode and added to the running total in desk mode
+func (r *REPL) recall(register string) error {
+	value := r.state.memory.Recall(register)
+	switch r.mode {
+	case ModeRPN:
+		r.state.stack.Push(value)
+		fmt.Fprintln(r.out, r.state.stack)
+	case ModeDesk:
+		step := "+ " + value.String()
+		if text, negative := strings.CutPrefix(value.String(), "-"); negative {
+			step = "- " + text
+		}
+		entry, err := r.state.desk.Apply(step)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(r.out, entry)
+	default:
+		r.state.vars["ans"] = value
+		fmt.Fprintln(r.out, r.engine.FormatResult(value))
+	}
+	return nil
+}
+
+// printRegister shows a register's contents after a change
+func (r *REPL) printRegister(register string) {
+	if register == "" {
+		register = calculation.DefaultRegister
+	}
+	fmt.Fprintf(r.out, "%s = %s\n", register, r.engine.FormatResult(r.state.memory.Recall(register)))
+}
+
+// printMemory lists the registers in use
+func (r *REPL) printMemory() {
+	names := r.state.memory.Names()
+	if len(names) == 0 {
+		fmt.Fprintln(r.out, "(memory empty)")
+		return
+	}
+	for _, name := range names {
+		r.printRegister(name)
+	}
+}
+
+// saveMemory writes the memory registers to the memory file, if there is one
+func (r *REPL) saveMemory() {
+	if r.memoryPath == "" {
+		return
+	}
+	if err := r.state.memory.Save(r.memoryPath); err != nil {
+		fmt.Fprintf(r.errOut, "Error: %v\n", err)
+	}
+}
+
+// handleDeskLine applies a line to the running total, printing the new tape entry
+func (r *REPL) handleDeskLine(line string) {
+	switch line {
+	case "tape":
+		fmt.Fprintln(r.out, r.state.desk)
+		return
+	case "subtotal", "=":
+		r.change(line, func() error {
+			fmt.Fprintln(r.out, r.state.desk.Subtotal())
+			return nil
+		})
+		return
+	case "total":
+		r.change(line, func() error {
+			fmt.Fprintln(r.out, r.state.desk.Total())
+			return nil
+		})
+		return
+	case "clear":
+		r.change(line, func() error {
+			r.state.desk.Clear()
+			fmt.Fprintln(r.out, "Tape cleared")
+			return nil
+		})
+		return
+	}
+
+	if command, arg, ok := strings.Cut(line, " "); ok && r.handleTapeCommand(line, command, strings.TrimSpace(arg)) {
+		return
+	}
+	r.change(line, func() error {
+		entry, err := r.state.desk.Apply(line)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(r.out, entry)
+		return nil
+	})
+}
+
+// handleTapeCommand runs the note, export and import desk commands, reporting
+// whether the line was one of them
+func (r *REPL) handleTapeCommand(line, command, arg string) bool {
+	switch command {
+	case "note":
+		r.change(line, func() error {
+			if err := r.state.desk.Annotate(arg); err != nil {
+				return err
+			}
+			entries := r.state.desk.Entries()
+			fmt.Fprintln(r.out, entries[len(entries)-1])
+			return nil
+		})
+	case "export":
+		if err := exportTape(r.state.desk, arg); err != nil {
+			fmt.Fprintf(r.errOut, "Error: %v\n", err)
+			return true
+		}
+		fmt.Fprintf(r.out, "Tape exported to %s\n", arg)
+	case "import":
+		r.change(line, func() error {
+			if err := importTape(r.state.desk, arg); err != nil {
+				return err
+			}
+			fmt.Fprintln(r.out, r.state.desk)
+			return nil
+		})
+	default:
+		return false
+	}
+	return true
+}
+
+// exportTape writes the desk tape to a file, choosing the format from its extension
+func exportTape(desk *calculation.RunningTotal, path string) error {
+	format, err := history.FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create tape file: %w", err)
+	}
+	if err := history.NewTape(desk.Entries()).Export(file, format); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// importTape replaces the desk tape by replaying a previously exported one
+func importTape(desk *calculation.RunningTotal, path string) error {
+	format, err := history.FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open tape file: %w", err)
+	}
+	defer file.Close()
+
+	tape, err := history.ImportTape(file, format)
+	if err != nil {
+		return err
+	}
+	desk.Clear()
+	if err := tape.Replay(desk); err != nil {
+		desk.Clear()
+		return fmt.Errorf("replay failed: %w", err)
+	}
+	return nil
+}
+
+// handleModeCommand runs "mode" and "mode <name>", reporting whether the line
+// was one of them. Anything else starting with mode, such as mode([1, 2, 2])
+// or mode (xs), is left to be evaluated
+func (r *REPL) handleModeCommand(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) > 2 || fields[0] != "mode" {
+		return false
+	}
+	name := ""
+	if len(fields) == 2 {
+		name = fields[1]
+		if strings.IndexFunc(name, func(c rune) bool { return !unicode.IsLetter(c) }) >= 0 {
+			return false
+		}
+	}
+	r.setMode(name)
+	return true
+}
+
+// setMode switches the input mode, keeping the RPN stack and desk tape across switches
+func (r *REPL) setMode(name string) {
+	switch mode := InputMode(name); mode {
+	case ModeInfix, ModeRPN, ModeDesk:
+		r.mode = mode
+		fmt.Fprintf(r.out, "Mode: %s\n", mode)
+	case "":
+		fmt.Fprintf(r.out, "Mode: %s\n", r.mode)
+	default:
+		fmt.Fprintf(r.errOut, "Error: unknown mode %q: expected infix, rpn or desk\n", name)
+	}
+}
@@ -0,0 +1,112 @@
+package terminal
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"calculator/internal/calculation"
+	"calculator/internal/config"
+)
+
+// runScript implements "calculator run": it executes a script file, or a
+// script read from stdin when the file is "-", writing what it prints to
+// stdout
+func runScript(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("calculator run", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configPath := flags.String("config", config.DefaultConfigPath(), "path to config.yaml")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(stderr, "Error: expected one script file, e.g. calculator run loan.calc")
+		return 2
+	}
+
+	var source []byte
+	var err error
+	if path := flags.Arg(0); path == "-" {
+		source, err = io.ReadAll(stdin)
+	} else {
+		source, err = os.ReadFile(path)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	cfg, err := config.LoadConfigOrDefault(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	engine, err := NewEngine(cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	script, err := engine.CompileScript(string(source))
+	if err == nil {
+		_, err = script.Run(context.Background(), stdout, nil)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runAssert implements "calculator assert": it evaluates an expression that
+// must be true, such as "total ~= 1234.56" with total a memory register. The
+// exit code is 0 when it is true, 1 when it is false and 2 when it cannot be
+// evaluated, so CI jobs can tell a wrong number from a broken check
+func runAssert(args []string, stderr io.Writer) int {
+	flags := flag.NewFlagSet("calculator assert", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configPath := flags.String("config", config.DefaultConfigPath(), "path to config.yaml")
+	tolerance := flags.Float64("tolerance", -1, "relative tolerance of ~= (default from config)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(stderr, `Error: expected an expression, e.g. calculator assert "total ~= 1234.56"`)
+		return 2
+	}
+
+	cfg, err := config.LoadConfigOrDefault(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 2
+	}
+	if *tolerance >= 0 {
+		cfg.Tolerance = *tolerance
+	}
+	engine, err := NewEngine(cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 2
+	}
+	memory, err := calculation.LoadMemory(MemoryPath(*configPath))
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	err = engine.Assert(strings.Join(flags.Args(), " "), memory.Variables())
+	var failed *calculation.AssertionError
+	switch {
+	case errors.As(err, &failed):
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	case err != nil:
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,91 @@
+package terminal
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+
+	"calculator/internal/config"
+	"calculator/internal/server"
+)
+
+// runServe implements "calculator serve": the HTTP JSON API on a local
+// address until interrupted
+func runServe(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("calculator serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configPath := flags.String("config", config.DefaultConfigPath(), "path to config.yaml")
+	addr := flags.String("addr", "127.0.0.1:8080", "address to listen on")
+	timeout := flags.Duration("timeout", server.DefaultTimeout, "time limit for evaluating one request")
+	maxBody := flags.Int64("max-body", server.DefaultMaxBodyBytes, "largest request body in bytes")
+	maxBatch := flags.Int("max-batch", server.DefaultMaxBatch, "most expressions in one batch request")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(stderr, "Error: unexpected argument %q\n", flags.Arg(0))
+		return 2
+	}
+
+	cfg, err := config.LoadConfigOrDefault(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	engine, err := NewEngine(cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	srv := server.New(engine, server.WithTimeout(*timeout), server.WithMaxBodyBytes(*maxBody), server.WithMaxBatch(*maxBatch))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err = srv.ListenAndServe(ctx, *addr, func(addr net.Addr) {
+		fmt.Fprintf(stdout, "Listening on http://%s\n", addr)
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runRPC implements "calculator rpc": JSON-RPC 2.0 over stdin and stdout,
+// one message per line, until stdin ends
+func runRPC(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("calculator rpc", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configPath := flags.String("config", config.DefaultConfigPath(), "path to config.yaml")
+	timeout := flags.Duration("timeout", server.DefaultTimeout, "time limit for evaluating one request")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(stderr, "Error: unexpected argument %q\n", flags.Arg(0))
+		return 2
+	}
+
+	cfg, err := config.LoadConfigOrDefault(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	engine, err := NewEngine(cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	srv := server.New(engine, server.WithTimeout(*timeout))
+	if err := srv.ServeRPC(context.Background(), stdin, stdout, cfg.MaxHistory); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
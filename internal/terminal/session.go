@@ -0,0 +1,43 @@
+package terminal
+
+import (
+	"calculator/internal/calculation"
+	"calculator/internal/history"
+)
+
+// sessionState is the part of a REPL session that :undo and :redo restore:
+// variables, memory registers, the calculation history, the RPN stack and
+// the desk tape
+type sessionState struct {
+	vars    map[string]calculation.Value
+	memory  *calculation.Memory
+	history *history.HistoryManager
+	stack   *calculation.RPNStack
+	desk    *calculation.RunningTotal
+}
+
+// clone returns a copy of the state that later changes do not affect.
+// Values are immutable, so the variables map is copied shallowly
+func (s sessionState) clone() sessionState {
+	vars := make(map[string]calculation.Value, len(s.vars))
+	for name, v := range s.vars {
+		vars[name] = v
+	}
+	return sessionState{
+		vars:    vars,
+		memory:  s.memory.Clone(),
+		history: s.history.Clone(),
+		stack:   s.stack.Clone(),
+		desk:    s.desk.Clone(),
+	}
+}
+
+// variables returns what expressions can refer to: the memory registers and
+// the session variables, which take precedence over registers of the same name
+func (s sessionState) variables() map[string]calculation.Value {
+	vars := s.memory.Variables()
+	for name, v := range s.vars {
+		vars[name] = v
+	}
+	return vars
+}
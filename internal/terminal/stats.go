@@ -0,0 +1,100 @@
+package terminal
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"calculator/internal/calculation"
+	"calculator/internal/config"
+)
+
+// statsSummary lists the statistics "calculator stats" prints, in order
+var statsSummary = []string{"count", "sum", "mean", "median", "stdev", "min", "max"}
+
+// runStats implements "calculator stats": it reads one number or expression
+// per line from a file, or from stdin when the file is "-" or missing, and
+// prints summary statistics of the column
+func runStats(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("calculator stats", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configPath := flags.String("config", config.DefaultConfigPath(), "path to config.yaml")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 1 {
+		fmt.Fprintln(stderr, "Error: expected at most one file, e.g. calculator stats sales.txt")
+		return 2
+	}
+
+	input := stdin
+	if path := flags.Arg(0); path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		input = f
+	}
+
+	cfg, err := config.LoadConfigOrDefault(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	engine, err := NewEngine(cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	column, err := readColumn(engine, input)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	if len(column.Items) == 0 {
+		fmt.Fprintln(stderr, "Error: no numbers to summarize")
+		return 1
+	}
+
+	vars := map[string]calculation.Value{"column": column}
+	for _, name := range statsSummary {
+		if name == "stdev" && len(column.Items) < 2 {
+			continue
+		}
+		result, err := engine.EvaluateWithVariables(name+"(column)", vars)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "%-7s%s\n", name, engine.FormatResult(result))
+	}
+	return 0
+}
+
+// readColumn evaluates each line of r as a number, skipping blank lines and
+// # comments
+func readColumn(engine *calculation.CalculationEngine, r io.Reader) (calculation.List, error) {
+	var column calculation.List
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		value, err := engine.EvaluateWithVariables(text, nil)
+		if err != nil {
+			return column, fmt.Errorf("line %d: %w", line, err)
+		}
+		if _, ok := value.(calculation.Number); !ok {
+			return column, fmt.Errorf("line %d: expected a number, got %s", line, value.Kind())
+		}
+		column.Items = append(column.Items, value)
+	}
+	return column, scanner.Err()
+}
@@ -0,0 +1,451 @@
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"calculator/internal/calculation"
+	"calculator/internal/models"
+	"calculator/internal/parser"
+)
+
+// ANSI control sequences used by the full-screen interface
+const (
+	enterScreen = "\x1b[?1049h\x1b[?25l\x1b[?1000h\x1b[?1006h"
+	leaveScreen = "\x1b[?1006l\x1b[?1000l\x1b[?25h\x1b[?1049l"
+	clearScreen = "\x1b[H\x1b[2J"
+	styleFocus  = "\x1b[7m"
+	styleError  = "\x1b[31m"
+	styleReset  = "\x1b[0m"
+)
+
+// Layout of the calculator frame in character cells
+// Source: docs/architecture/user-interface-specification.md - Visual Layout
+const (
+	frameWidth   = 51
+	frameHeight  = 19
+	innerWidth   = frameWidth - 2
+	displayWidth = innerWidth - 6
+	keypadTop    = 6
+	buttonWidth  = 7
+	buttonHeight = 3
+	keypadCols   = 6
+	keypadRows   = 4
+)
+
+// button is a keypad key: the label drawn on it and the input it produces
+type button struct {
+	label string
+	input string
+}
+
+// keypad is the button grid, row by row
+var keypad = [keypadRows][keypadCols]button{
+	{{"7", "7"}, {"8", "8"}, {"9", "9"}, {"+", "+"}, {"(", "("}, {")", ")"}},
+	{{"4", "4"}, {"5", "5"}, {"6", "6"}, {"-", "-"}, {"π", "pi"}, {"e", "e"}},
+	{{"1", "1"}, {"2", "2"}, {"3", "3"}, {"*", "*"}, {"x²", "^2"}, {"√", "sqrt("}},
+	{{"0", "0"}, {".", "."}, {"±", "±"}, {"/", "/"}, {"%", "%"}, {"=", "="}},
+}
+
+// TerminalUI is the full-screen keypad calculator
+// Source: docs/architecture/components.md - TerminalUI
+type TerminalUI struct {
+	engine      *calculation.CalculationEngine
+	parser      *parser.InputParser
+	expression  string
+	display     string
+	errMessage  string
+	evaluated   bool
+	history     []models.Calculation
+	showHistory bool
+	focus       int
+	width       int
+	quit        bool
+}
+
+// NewTerminalUI creates a full-screen calculator driving the given engine
+func NewTerminalUI(engine *calculation.CalculationEngine) *TerminalUI {
+	ui := &TerminalUI{engine: engine, parser: parser.NewInputParser(engine)}
+	ui.InitializeUI()
+	return ui
+}
+
+// InitializeUI resets the display, focus and history panel to their initial state
+func (ui *TerminalUI) InitializeUI() {
+	ui.parser.Clear()
+	ui.expression = ""
+	ui.display = "0"
+	ui.errMessage = ""
+	ui.evaluated = false
+	ui.showHistory = true
+	ui.focus = 0
+	ui.quit = false
+}
+
+// DisplayResult shows an evaluated expression and its result
+func (ui *TerminalUI) DisplayResult(result calculation.Value, expression string) {
+	ui.expression = expression
+	ui.display = ui.engine.FormatResult(result)
+	ui.errMessage = ""
+	ui.evaluated = true
+}
+
+// DisplayError shows an error message in the result area
+func (ui *TerminalUI) DisplayError(message string) {
+	ui.errMessage = message
+}
+
+// UpdateDisplay replaces the expression being built
+func (ui *TerminalUI) UpdateDisplay(text string) {
+	ui.expression = text
+	ui.errMessage = ""
+}
+
+// UpdateHistoryPanel replaces the calculations listed in the history panel
+func (ui *TerminalUI) UpdateHistoryPanel(history []models.Calculation) {
+	ui.history = append([]models.Calculation(nil), history...)
+}
+
+// ToggleHistoryPanel shows or hides the history panel
+func (ui *TerminalUI) ToggleHistoryPanel() {
+	ui.showHistory = !ui.showHistory
+}
+
+// History returns the calculations evaluated in this session
+func (ui *TerminalUI) History() []models.Calculation {
+	return append([]models.Calculation(nil), ui.history...)
+}
+
+// Done reports whether the user has asked to exit
+func (ui *TerminalUI) Done() bool {
+	return ui.quit
+}
+
+// HandleKeyEvent processes keyboard input: digits and operators build the
+// expression, Enter or = evaluates, arrows and Tab move the keypad focus,
+// Space presses the focused button, H toggles history, C clears and
+// Esc, Q or Ctrl+C exit
+func (ui *TerminalUI) HandleKeyEvent(event KeyEvent) {
+	switch event.Key {
+	case KeyEnter:
+		ui.press("=")
+	case KeyBackspace:
+		ui.press(string(parser.ButtonBackspace))
+	case KeyEscape, KeyCtrlC:
+		ui.quit = true
+	case KeyTab:
+		ui.focus = (ui.focus + 1) % (keypadRows * keypadCols)
+	case KeyBacktab:
+		ui.focus = (ui.focus + keypadRows*keypadCols - 1) % (keypadRows * keypadCols)
+	case KeyUp, KeyDown, KeyLeft, KeyRight:
+		ui.moveFocus(event.Key)
+	case KeyRune:
+		ui.handleRune(event.Rune)
+	}
+}
+
+// handleRune maps a printable key to a keypad input or shortcut
+func (ui *TerminalUI) handleRune(r rune) {
+	switch r {
+	case 'h', 'H':
+		ui.ToggleHistoryPanel()
+	case 'c', 'C':
+		ui.clear()
+	case 'q', 'Q':
+		ui.quit = true
+	case ' ':
+		ui.press(keypad[ui.focus/keypadCols][ui.focus%keypadCols].input)
+	case 'p':
+		ui.press("pi")
+	case 's':
+		ui.press("sqrt(")
+	default:
+		if strings.ContainsRune("0123456789.+-*/^()%=e", r) {
+			ui.press(string(r))
+		}
+	}
+}
+
+// HandleMouseEvent presses the keypad button or [H]/[C] control under a
+// left click, using the frame position from the most recent Render
+func (ui *TerminalUI) HandleMouseEvent(event MouseEvent) {
+	if !event.Pressed || event.Button != 0 {
+		return
+	}
+	x := event.X - frameOrigin(ui.width)
+	y := event.Y
+
+	if x >= innerWidth-3 && x < innerWidth+1 {
+		switch y {
+		case 3:
+			ui.ToggleHistoryPanel()
+			return
+		case 4:
+			ui.clear()
+			return
+		}
+	}
+
+	col := (x - 2) / (buttonWidth + 1)
+	row := (y - keypadTop) / buttonHeight
+	if x < 2 || (x-2)%(buttonWidth+1) == buttonWidth || y < keypadTop ||
+		col >= keypadCols || row >= keypadRows {
+		return
+	}
+	ui.focus = row*keypadCols + col
+	ui.press(keypad[row][col].input)
+}
+
+// moveFocus moves the keypad focus with the arrow keys, wrapping at the edges
+func (ui *TerminalUI) moveFocus(key Key) {
+	row, col := ui.focus/keypadCols, ui.focus%keypadCols
+	switch key {
+	case KeyUp:
+		row = (row + keypadRows - 1) % keypadRows
+	case KeyDown:
+		row = (row + 1) % keypadRows
+	case KeyLeft:
+		col = (col + keypadCols - 1) % keypadCols
+	case KeyRight:
+		col = (col + 1) % keypadCols
+	}
+	ui.focus = row*keypadCols + col
+}
+
+// press sends a keypad input to the input parser, recording the calculation
+// when it evaluates the expression and showing any input it rejects
+func (ui *TerminalUI) press(input string) {
+	calc, err := ui.parser.ProcessButtonInput(parser.Button(input))
+	switch {
+	case calc != nil:
+		calc.ID = fmt.Sprintf("calc-%d", len(ui.history)+1)
+		ui.history = append(ui.history, *calc)
+		if err != nil {
+			ui.UpdateDisplay(ui.parser.GetCurrentExpression())
+			ui.DisplayError(err.Error())
+			return
+		}
+		ui.DisplayResult(ui.parser.Result(), calc.Expression)
+	case err != nil:
+		ui.DisplayError(err.Error())
+	case !ui.parser.Evaluated():
+		ui.evaluated = false
+		ui.UpdateDisplay(ui.parser.GetCurrentExpression())
+	}
+}
+
+// clear resets the expression and result
+func (ui *TerminalUI) clear() {
+	ui.parser.Clear()
+	ui.expression = ""
+	ui.display = "0"
+	ui.errMessage = ""
+	ui.evaluated = false
+}
+
+// frameOrigin returns the column where the centred frame starts
+func frameOrigin(width int) int {
+	if width <= frameWidth {
+		return 0
+	}
+	return (width - frameWidth) / 2
+}
+
+// Render draws the whole screen for a terminal of the given size, lines
+// separated by CRLF. The frame is centred horizontally, so the width is kept
+// for mapping mouse clicks back to buttons
+func (ui *TerminalUI) Render(width, height int) string {
+	ui.width = width
+	if width < frameWidth || height < frameHeight {
+		return fmt.Sprintf("Terminal too small: need %dx%d, have %dx%d (Esc to exit)", frameWidth, frameHeight, width, height)
+	}
+
+	border := strings.Repeat("─", innerWidth)
+	lines := []string{
+		"┌" + border + "┐",
+		"│" + center("Calculator", innerWidth) + "│",
+		"├" + border + "┤",
+		"│ " + alignRight(ui.expressionLine(), displayWidth) + " [H] │",
+		"│ " + ui.resultLine() + " [C] │",
+		"│" + strings.Repeat(" ", innerWidth) + "│",
+	}
+	lines = append(lines, ui.renderKeypad()...)
+
+	// The history panel needs room for its separator and heading
+	if ui.showHistory && height >= frameHeight+2 {
+		lines = append(lines, "├"+border+"┤", "│ "+padRight("History:", innerWidth-1)+"│")
+		available := height - len(lines) - 1
+		items := ui.history
+		if len(items) > available {
+			items = items[len(items)-available:]
+		}
+		if len(items) == 0 && available > 0 {
+			lines = append(lines, "│ "+padRight("(no calculations yet)", innerWidth-1)+"│")
+		}
+		first := len(ui.history) - len(items) + 1
+		for i, calc := range items {
+			lines = append(lines, "│ "+padRight(truncateLeft(formatHistoryItem(first+i, calc), innerWidth-2), innerWidth-1)+"│")
+		}
+	}
+	lines = append(lines, "└"+border+"┘")
+
+	indent := strings.Repeat(" ", frameOrigin(width))
+	for i := range lines {
+		lines[i] = indent + lines[i]
+	}
+	return strings.Join(lines, "\r\n")
+}
+
+// expressionLine is the text shown above the result
+func (ui *TerminalUI) expressionLine() string {
+	if ui.evaluated {
+		return truncateLeft(ui.expression+" =", displayWidth)
+	}
+	return truncateLeft(ui.expression, displayWidth)
+}
+
+// resultLine is the right-aligned result or the error message in red
+func (ui *TerminalUI) resultLine() string {
+	if ui.errMessage != "" {
+		return styleError + alignRight(truncateLeft(ui.errMessage, displayWidth), displayWidth) + styleReset
+	}
+	text := ui.display
+	if !ui.evaluated && ui.expression != "" {
+		text = ""
+	}
+	return alignRight(truncateLeft(text, displayWidth), displayWidth)
+}
+
+// renderKeypad draws the button grid, highlighting the focused button
+func (ui *TerminalUI) renderKeypad() []string {
+	var lines []string
+	for r, row := range keypad {
+		var top, mid, bottom strings.Builder
+		for _, b := range []*strings.Builder{&top, &mid, &bottom} {
+			b.WriteString("│ ")
+		}
+		for c, btn := range row {
+			parts := []string{"┌─────┐", "│" + center(btn.label, buttonWidth-2) + "│", "└─────┘"}
+			if r*keypadCols+c == ui.focus {
+				for i := range parts {
+					parts[i] = styleFocus + parts[i] + styleReset
+				}
+			}
+			top.WriteString(parts[0] + " ")
+			mid.WriteString(parts[1] + " ")
+			bottom.WriteString(parts[2] + " ")
+		}
+		lines = append(lines, top.String()+"│", mid.String()+"│", bottom.String()+"│")
+	}
+	return lines
+}
+
+// formatHistoryItem renders a history entry as "1. 2 + 2 = 4"
+func formatHistoryItem(n int, calc models.Calculation) string {
+	if calc.Error != "" {
+		return fmt.Sprintf("%d. %s: %s", n, calc.Expression, calc.Error)
+	}
+	if calc.ResultText != "" {
+		return fmt.Sprintf("%d. %s = %s", n, calc.Expression, calc.ResultText)
+	}
+	return fmt.Sprintf("%d. %s = %s", n, calc.Expression, strconv.FormatFloat(calc.Result, 'g', 15, 64))
+}
+
+// center pads s on both sides to width runes
+func center(s string, width int) string {
+	n := utf8.RuneCountInString(s)
+	if n >= width {
+		return s
+	}
+	left := (width - n) / 2
+	return strings.Repeat(" ", left) + s + strings.Repeat(" ", width-n-left)
+}
+
+// alignRight pads s on the left to width runes
+func alignRight(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return strings.Repeat(" ", width-n) + s
+	}
+	return s
+}
+
+// padRight pads s on the right to width runes
+func padRight(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// truncateLeft keeps the last width runes of s, marking the cut with an ellipsis
+func truncateLeft(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return "…" + string(runes[len(runes)-width+1:])
+}
+
+// Run takes over the terminal until the user exits, redrawing after every
+// input event and whenever the window is resized
+func (ui *TerminalUI) Run(in *os.File, out io.Writer) error {
+	fd := in.Fd()
+	restore, err := makeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	fmt.Fprint(out, enterScreen)
+	defer fmt.Fprint(out, leaveScreen)
+
+	resized := make(chan os.Signal, 1)
+	notifyResize(resized)
+	defer signal.Stop(resized)
+
+	input := make(chan []byte)
+	go func() {
+		buf := make([]byte, 256)
+		for {
+			n, err := in.Read(buf)
+			if err != nil {
+				close(input)
+				return
+			}
+			input <- append([]byte(nil), buf[:n]...)
+		}
+	}()
+
+	width, height, err := windowSize(fd)
+	if err != nil {
+		return err
+	}
+	for !ui.quit {
+		fmt.Fprint(out, clearScreen+ui.Render(width, height))
+
+		select {
+		case <-resized:
+			if width, height, err = windowSize(fd); err != nil {
+				return err
+			}
+		case data, ok := <-input:
+			if !ok {
+				return nil
+			}
+			for _, event := range DecodeInput(data) {
+				switch e := event.(type) {
+				case KeyEvent:
+					ui.HandleKeyEvent(e)
+				case MouseEvent:
+					ui.HandleMouseEvent(e)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package calculator
+
+import (
+	"context"
+	"time"
+
+	"calculator/internal/calculation"
+)
+
+// BatchResult is the outcome of one expression evaluated by CalculateBatch.
+// Err is a *Error, or the context's error for an expression that was not
+// evaluated because the batch was cancelled
+type BatchResult struct {
+	Expression string
+	Value      Value
+	Err        error
+	Duration   time.Duration
+}
+
+// BatchStats summarises the results of a batch
+type BatchStats struct {
+	Total     int
+	Succeeded int
+	Failed    int
+	// Cancelled counts expressions that were not evaluated because the
+	// context was done
+	Cancelled int
+	// Busy is the evaluation time summed over all expressions; with several
+	// workers it exceeds the wall-clock time of the batch
+	Busy time.Duration
+	// Slowest is the longest time spent on a single expression
+	Slowest time.Duration
+}
+
+// BatchOption configures a CalculateBatch call
+type BatchOption func(*batchSettings)
+
+// batchSettings collects the options given to CalculateBatch
+type batchSettings struct {
+	opts []calculation.BatchOption
+}
+
+// WithWorkers bounds the number of expressions evaluated at once. The default
+// is runtime.GOMAXPROCS(0)
+func WithWorkers(n int) BatchOption {
+	return func(s *batchSettings) {
+		s.opts = append(s.opts, calculation.WithWorkers(n))
+	}
+}
+
+// WithBatchVariables makes vars available to every expression in the batch
+func WithBatchVariables(vars map[string]Value) BatchOption {
+	return func(s *batchSettings) {
+		s.opts = append(s.opts, calculation.WithBatchVariables(internalVariables(vars)))
+	}
+}
+
+// CalculateBatch evaluates expressions concurrently on a bounded pool of
+// goroutines and returns one result per expression, in input order. When ctx
+// is done no further expressions are started
+func (e *Engine) CalculateBatch(ctx context.Context, expressions []string, opts ...BatchOption) []BatchResult {
+	var settings batchSettings
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	results := e.engine.CalculateBatch(ctx, expressions, settings.opts...)
+	batch := make([]BatchResult, len(results))
+	for i, r := range results {
+		batch[i] = BatchResult{Expression: r.Expression, Duration: r.Duration}
+		switch {
+		case r.Err == nil:
+			batch[i].Value = Value{value: r.Value, text: e.engine.FormatResult(r.Value)}
+		case r.Err == ctx.Err() && r.Duration == 0:
+			// Never started
+			batch[i].Err = r.Err
+		default:
+			batch[i].Err = compileError(r.Expression, r.Err)
+		}
+	}
+	return batch
+}
+
+// SummarizeBatch computes aggregate statistics for the results of CalculateBatch
+func SummarizeBatch(results []BatchResult) BatchStats {
+	internal := make([]calculation.BatchResult, len(results))
+	for i, r := range results {
+		internal[i] = calculation.BatchResult{Err: r.Err, Duration: r.Duration}
+	}
+	return BatchStats(calculation.SummarizeBatch(internal))
+}
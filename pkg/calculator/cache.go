@@ -0,0 +1,51 @@
+package calculator
+
+import "calculator/internal/calculation"
+
+// Cache is a bounded least-recently-used cache of evaluation results that an
+// engine consults before evaluating an expression. Entries are keyed by the
+// expression with its whitespace normalized and by the engine's settings, so
+// one Cache may be shared by engines with different options. An entry is
+// invalidated when a variable its expression reads changes value; results
+// that read the clock and failed evaluations are not cached
+type Cache struct {
+	cache *calculation.ResultCache
+}
+
+// CacheStats counts the lookups made in a Cache
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	// Invalidations counts misses caused by a changed variable; they are
+	// included in Misses
+	Invalidations uint64
+	// Evictions counts entries dropped to stay within capacity
+	Evictions uint64
+	Entries   int
+	Capacity  int
+}
+
+// NewCache creates a cache holding at most capacity results
+func NewCache(capacity int) *Cache {
+	return &Cache{cache: calculation.NewResultCache(capacity)}
+}
+
+// WithCache memoizes the results of Evaluate, EvaluateWithVariables,
+// EvaluateContext and CalculateBatch in cache. Compiled Programs bypass it
+func WithCache(cache *Cache) Option {
+	return func(s *settings) {
+		if cache != nil {
+			s.opts = append(s.opts, calculation.WithCache(cache.cache))
+		}
+	}
+}
+
+// Stats returns a snapshot of the cache's counters
+func (c *Cache) Stats() CacheStats {
+	return CacheStats(c.cache.Stats())
+}
+
+// Purge removes every entry, keeping the counters
+func (c *Cache) Purge() {
+	c.cache.Purge()
+}
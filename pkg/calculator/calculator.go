@@ -0,0 +1,270 @@
+// Package calculator is the public, embeddable form of the calculator's
+// expression engine. It evaluates the same expressions with the same
+// precision as the calculator command:
+//
+//	engine := calculator.New(calculator.WithComplexMode(true))
+//	v, err := engine.Evaluate("sqrt(-4) + 2 * pi")
+//
+// Formulas evaluated many times with different inputs should be compiled once:
+//
+//	program, err := engine.Compile("price * qty * (1 + tax)")
+//	v, err := program.Eval(map[string]calculator.Value{
+//		"price": calculator.Number(9.99),
+//		"qty":   calculator.Number(3),
+//		"tax":   calculator.Number(0.2),
+//	})
+//
+// Errors returned by Evaluate, Validate, Compile and Eval are *Error values
+// whose Kind tells syntax errors apart from evaluation failures
+package calculator
+
+import (
+	"context"
+	"time"
+
+	"calculator/internal/calculation"
+)
+
+// ComplexForm selects how complex results are displayed
+type ComplexForm string
+
+const (
+	// ComplexRectangular displays complex numbers as a+bi
+	ComplexRectangular ComplexForm = "rectangular"
+	// ComplexPolar displays complex numbers as magnitude∠angle (radians)
+	ComplexPolar ComplexForm = "polar"
+)
+
+// PercentMode selects how a percentage is added to or subtracted from a value
+type PercentMode string
+
+const (
+	// PercentDesk makes 80 + 10% mean 80 plus 10% of 80, as on a desk calculator
+	PercentDesk PercentMode = "desk"
+	// PercentMath treats b% as b/100 everywhere, so 80 + 10% is 80.1
+	PercentMath PercentMode = "math"
+)
+
+// Engine evaluates expressions. It holds only its settings, so one Engine
+// may be used from many goroutines
+type Engine struct {
+	engine *calculation.CalculationEngine
+}
+
+// Option configures an Engine at construction time
+type Option func(*settings)
+
+// settings collects the options given to New
+type settings struct {
+	opts []calculation.EngineOption
+}
+
+// WithComplexMode makes sqrt of a negative number return a complex result instead of an error
+func WithComplexMode(enabled bool) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, calculation.WithComplexMode(enabled))
+	}
+}
+
+// WithComplexForm selects rectangular or polar output for complex results
+func WithComplexForm(form ComplexForm) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, calculation.WithComplexForm(calculation.ComplexForm(form)))
+	}
+}
+
+// WithPercentMode selects desk-calculator or pure-math percentage semantics
+func WithPercentMode(mode PercentMode) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, calculation.WithPercentMode(calculation.PercentMode(mode)))
+	}
+}
+
+// WithRateTable supplies the exchange rates used to convert between currencies
+func WithRateTable(table *RateTable) Option {
+	return func(s *settings) {
+		if table != nil {
+			s.opts = append(s.opts, calculation.WithRateTable(table.table))
+		}
+	}
+}
+
+// WithClock sets the source of the current time used by "now" and "today"
+func WithClock(clock func() time.Time) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, calculation.WithClock(clock))
+	}
+}
+
+// WithLocation sets the time zone for date literals, "now" and "today"
+func WithLocation(loc *time.Location) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, calculation.WithLocation(loc))
+	}
+}
+
+// DefaultTolerance is the relative tolerance of ~= unless WithTolerance
+// changes it
+const DefaultTolerance = calculation.DefaultTolerance
+
+// WithTolerance sets the tolerance of ~=: two values are approximately equal
+// when they differ by at most tolerance times the larger of them, or by at
+// most tolerance itself when both are close to zero
+func WithTolerance(tolerance float64) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, calculation.WithTolerance(tolerance))
+	}
+}
+
+// Limits bounds the resources one expression may use, so that untrusted
+// input such as 9^9^9 fails fast. A zero field means no limit
+type Limits struct {
+	// MaxExpressionLength is the longest expression accepted, in bytes
+	MaxExpressionLength int
+	// MaxDepth is the deepest nesting of operators and function calls
+	MaxDepth int
+	// MaxDigits bounds the magnitude of every intermediate result: its
+	// decimal exponent must lie within ±MaxDigits
+	MaxDigits int
+	// MaxExponent is the largest absolute exponent accepted by ^
+	MaxExponent int
+	// MaxSteps is the most expression nodes evaluated for one expression
+	MaxSteps int
+	// MaxIterations is the most loop iterations one script runs
+	MaxIterations int
+}
+
+// DefaultLimits returns the limits every engine starts with
+func DefaultLimits() Limits {
+	return Limits(calculation.DefaultLimits())
+}
+
+// WithLimits replaces the engine's resource limits. Exceeding one fails with
+// an *Error of kind LimitExceeded
+func WithLimits(limits Limits) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, calculation.WithLimits(calculation.Limits(limits)))
+	}
+}
+
+// New creates an engine. Without options it uses rectangular complex output,
+// desk percentages, no exchange rates, the local clock and time zone and
+// DefaultLimits
+func New(opts ...Option) *Engine {
+	var s settings
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return &Engine{engine: calculation.NewCalculationEngine(s.opts...)}
+}
+
+// Evaluate parses and evaluates an expression
+func (e *Engine) Evaluate(expression string) (Value, error) {
+	return e.EvaluateWithVariables(expression, nil)
+}
+
+// EvaluateContext is Evaluate that gives up once ctx is done, returning an
+// *Error of kind Cancelled that wraps ctx.Err()
+func (e *Engine) EvaluateContext(ctx context.Context, expression string) (Value, error) {
+	result, err := e.engine.CalculateContext(ctx, expression)
+	return e.result(expression, result, err)
+}
+
+// EvaluateWithVariables evaluates an expression in which bare identifiers may
+// refer to the given variables
+func (e *Engine) EvaluateWithVariables(expression string, vars map[string]Value) (Value, error) {
+	result, err := e.engine.EvaluateWithVariables(expression, internalVariables(vars))
+	return e.result(expression, result, err)
+}
+
+// result wraps the outcome of evaluating an expression
+func (e *Engine) result(expression string, result calculation.Value, err error) (Value, error) {
+	if err != nil {
+		return Value{}, compileError(expression, err)
+	}
+	return Value{value: result, text: e.engine.FormatResult(result)}, nil
+}
+
+// Assert evaluates an expression that must be true, such as
+// "total ~= 1234.56". It returns nil when it is, and otherwise an *Error of
+// kind AssertionFailed, or of another kind when it could not be evaluated or
+// is not true or false
+func (e *Engine) Assert(expression string, vars map[string]Value) error {
+	if err := e.engine.Assert(expression, internalVariables(vars)); err != nil {
+		return compileError(expression, err)
+	}
+	return nil
+}
+
+// DefineFunction parses a definition such as "tax(x) = x <= 10000 ? 0 :
+// (x - 10000) * 0.2" and returns the function as a Value of kind "function".
+// Stored in the variables under its name, it can be called by expressions
+// evaluated with them. The body may call the functions in vars
+func (e *Engine) DefineFunction(definition string, vars map[string]Value) (Value, error) {
+	fn, err := e.engine.DefineFunction(definition, internalVariables(vars))
+	if err != nil {
+		return Value{}, compileError(definition, err)
+	}
+	return Value{value: fn, text: fn.String()}, nil
+}
+
+// Validate reports whether an expression parses, without evaluating it
+func (e *Engine) Validate(expression string) error {
+	if err := e.engine.CheckSyntax(expression); err != nil {
+		return newError(SyntaxError, expression, err)
+	}
+	return nil
+}
+
+// Compile parses and checks an expression once for repeated evaluation.
+// Besides syntax errors it reports invalid literals, unknown units and
+// unknown functions or wrong argument counts as evaluation errors
+func (e *Engine) Compile(expression string) (*Program, error) {
+	program, err := e.engine.Compile(expression)
+	if err != nil {
+		return nil, compileError(expression, err)
+	}
+	return &Program{engine: e, program: program}, nil
+}
+
+// Operators returns the arithmetic operators the engine supports
+func (e *Engine) Operators() []string {
+	return e.engine.GetSupportedOperations()
+}
+
+// Functions returns the names of the built-in functions, in alphabetical order
+func (e *Engine) Functions() []string {
+	return e.engine.GetSupportedFunctions()
+}
+
+// ValidateVariableName checks that name can be used as a variable: it must be
+// an identifier and must not shadow a built-in constant or keyword such as pi
+func ValidateVariableName(name string) error {
+	return calculation.ValidateVariableName(name)
+}
+
+// Program is a compiled expression. It is not changed by Eval, so one
+// Program may be evaluated from many goroutines
+type Program struct {
+	engine  *Engine
+	program *calculation.Program
+}
+
+// Expression returns the source text the program was compiled from
+func (p *Program) Expression() string {
+	return p.program.Expression()
+}
+
+// Eval evaluates the program, resolving bare identifiers from vars
+func (p *Program) Eval(vars map[string]Value) (Value, error) {
+	return p.EvalContext(context.Background(), vars)
+}
+
+// EvalContext is Eval that gives up once ctx is done
+func (p *Program) EvalContext(ctx context.Context, vars map[string]Value) (Value, error) {
+	result, err := p.program.EvalContext(ctx, internalVariables(vars))
+	if err != nil {
+		return Value{}, evaluationError(p.program.Expression(), err)
+	}
+	return Value{value: result, text: p.engine.engine.FormatResult(result)}, nil
+}
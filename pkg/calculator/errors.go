@@ -0,0 +1,78 @@
+package calculator
+
+import (
+	"context"
+	"errors"
+
+	"calculator/internal/calculation"
+)
+
+// ErrorKind classifies an Error
+type ErrorKind string
+
+const (
+	// SyntaxError means the expression could not be parsed
+	SyntaxError ErrorKind = "syntax_error"
+	// DivisionByZero means the expression divides by zero
+	DivisionByZero ErrorKind = "division_by_zero"
+	// EvaluationError means the expression parsed but could not be evaluated,
+	// e.g. an unknown identifier or incompatible units
+	EvaluationError ErrorKind = "evaluation_error"
+	// LimitExceeded means the expression exceeded one of the engine's Limits
+	LimitExceeded ErrorKind = "limit_exceeded"
+	// Cancelled means evaluation stopped because its context was done; the
+	// Error wraps the context's error
+	Cancelled ErrorKind = "cancelled"
+	// AssertionFailed means an expression passed to Engine.Assert was false
+	AssertionFailed ErrorKind = "assertion_failed"
+)
+
+// ErrDivisionByZero is wrapped by an Error of kind DivisionByZero. Custom
+// operators and functions return it so their errors are classified the same way
+var ErrDivisionByZero = calculation.ErrDivisionByZero
+
+// Error is returned for an expression that failed to parse or evaluate
+type Error struct {
+	Kind       ErrorKind
+	Expression string
+	Err        error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// newError wraps an engine error
+func newError(kind ErrorKind, expression string, err error) *Error {
+	return &Error{Kind: kind, Expression: expression, Err: err}
+}
+
+// evaluationError wraps an error from evaluating a parsed expression
+func evaluationError(expression string, err error) *Error {
+	var limit *calculation.LimitError
+	var assertion *calculation.AssertionError
+	switch {
+	case errors.As(err, &assertion):
+		return newError(AssertionFailed, expression, err)
+	case errors.As(err, &limit):
+		return newError(LimitExceeded, expression, err)
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		return newError(Cancelled, expression, err)
+	case errors.Is(err, calculation.ErrDivisionByZero):
+		return newError(DivisionByZero, expression, err)
+	}
+	return newError(EvaluationError, expression, err)
+}
+
+// compileError wraps an error from compiling an expression
+func compileError(expression string, err error) *Error {
+	var syntax *calculation.SyntaxError
+	if errors.As(err, &syntax) {
+		return newError(SyntaxError, expression, err)
+	}
+	return evaluationError(expression, err)
+}
@@ -0,0 +1,40 @@
+package calculator
+
+import "calculator/internal/calculation"
+
+// RateTable holds the exchange rates used for currency conversions
+type RateTable struct {
+	table *calculation.RateTable
+}
+
+// NewRateTable builds a rate table from decimal rate strings, each giving the
+// amount of a currency equal to one unit of the base currency:
+//
+//	calculator.NewRateTable("EUR", "2026-10-15", map[string]string{"USD": "1.0842"})
+func NewRateTable(base, date string, rates map[string]string) (*RateTable, error) {
+	table, err := calculation.NewRateTable(base, date, rates)
+	if err != nil {
+		return nil, err
+	}
+	return &RateTable{table: table}, nil
+}
+
+// LoadRateTable reads a rate table from a .json or .csv file in the format
+// accepted by the calculator's currency_rates_file setting
+func LoadRateTable(path string) (*RateTable, error) {
+	table, err := calculation.LoadRateTable(path)
+	if err != nil {
+		return nil, err
+	}
+	return &RateTable{table: table}, nil
+}
+
+// Base returns the base currency code
+func (t *RateTable) Base() string {
+	return t.table.Base
+}
+
+// Date returns the date the rates apply to
+func (t *RateTable) Date() string {
+	return t.table.Date
+}
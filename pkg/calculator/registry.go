@@ -0,0 +1,145 @@
+package calculator
+
+import (
+	"fmt"
+
+	"calculator/internal/calculation"
+)
+
+// Associativity says how a chain of infix operators of equal precedence groups
+type Associativity int
+
+const (
+	// LeftAssociative groups a - b - c as (a - b) - c
+	LeftAssociative Associativity = iota
+	// RightAssociative groups a ^ b ^ c as a ^ (b ^ c)
+	RightAssociative
+)
+
+// Precedences of the built-in operators; higher binds tighter. Custom
+// operators are placed relative to these, e.g. PrecedenceAdditive - 1 binds
+// more loosely than + and -
+const (
+	PrecedenceOr             = calculation.PrecedenceOr
+	PrecedenceAnd            = calculation.PrecedenceAnd
+	PrecedenceComparison     = calculation.PrecedenceComparison
+	PrecedenceAdditive       = calculation.PrecedenceAdditive
+	PrecedenceMultiplicative = calculation.PrecedenceMultiplicative
+	PrecedencePrefix         = calculation.PrecedencePrefix
+	PrecedencePower          = calculation.PrecedencePower
+)
+
+// Variadic as a Function's Arity accepts any number of arguments
+const Variadic = calculation.Variadic
+
+// Operator describes a custom prefix or infix operator
+type Operator struct {
+	// Symbol is the operator as written: punctuation such as "<<", a word
+	// such as "mod", or several of these separated by spaces
+	Symbol string
+	// Precedence orders the operator against the others, see PrecedenceAdditive
+	Precedence int
+	// Associativity applies to infix operators
+	Associativity Associativity
+	// Arity is 1 for a prefix operator and 2 for an infix operator
+	Arity int
+	// Unary implements a prefix operator
+	Unary func(operand Value) (Value, error)
+	// Binary implements an infix operator
+	Binary func(left, right Value) (Value, error)
+}
+
+// Function describes a custom function callable from expressions
+type Function struct {
+	Name string
+	// Arity is the number of arguments, or Variadic
+	Arity int
+	Call  func(args []Value) (Value, error)
+}
+
+// Registry holds the operators and functions an engine understands. It
+// starts with the built-ins; register domain-specific ones and pass it to
+// WithRegistry:
+//
+//	registry := calculator.NewRegistry()
+//	err := registry.RegisterOperator(calculator.Operator{
+//		Symbol: "mod", Precedence: calculator.PrecedenceMultiplicative, Arity: 2,
+//		Binary: mod,
+//	})
+//	engine := calculator.New(calculator.WithRegistry(registry))
+type Registry struct {
+	registry *calculation.Registry
+}
+
+// NewRegistry creates a registry holding the built-in operators and functions
+func NewRegistry() *Registry {
+	return &Registry{registry: calculation.NewRegistry()}
+}
+
+// WithRegistry makes the engine use a copy of the registry, so registering
+// more operators later does not change the engine
+func WithRegistry(r *Registry) Option {
+	return func(s *settings) {
+		if r != nil {
+			s.opts = append(s.opts, calculation.WithRegistry(r.registry))
+		}
+	}
+}
+
+// RegisterOperator adds an operator. The symbol must not already be taken by
+// an operator of the same arity, and a word in it must not be a keyword,
+// unit or currency code
+func (r *Registry) RegisterOperator(op Operator) error {
+	internal := calculation.Operator{
+		Symbol:        op.Symbol,
+		Precedence:    op.Precedence,
+		Associativity: calculation.Associativity(op.Associativity),
+		Arity:         op.Arity,
+	}
+	if op.Unary != nil {
+		internal.Unary = func(_ *calculation.CalculationEngine, operand calculation.Value) (calculation.Value, error) {
+			v, err := op.Unary(Value{value: operand})
+			return unwrap(op.Symbol, v, err)
+		}
+	}
+	if op.Binary != nil {
+		internal.Binary = func(_ *calculation.CalculationEngine, left, right calculation.Value) (calculation.Value, error) {
+			v, err := op.Binary(Value{value: left}, Value{value: right})
+			return unwrap(op.Symbol, v, err)
+		}
+	}
+	return r.registry.RegisterOperator(internal)
+}
+
+// RegisterFunction adds a function. The name must be a valid identifier that
+// is not a keyword and not already registered
+func (r *Registry) RegisterFunction(fn Function) error {
+	internal := calculation.Function{Name: fn.Name, Arity: fn.Arity}
+	if fn.Call != nil {
+		internal.Call = func(_ *calculation.CalculationEngine, args []calculation.Value) (calculation.Value, error) {
+			values := make([]Value, len(args))
+			for i, arg := range args {
+				values[i] = Value{value: arg}
+			}
+			v, err := fn.Call(values)
+			return unwrap(fn.Name, v, err)
+		}
+	}
+	return r.registry.RegisterFunction(internal)
+}
+
+// Functions returns the names of the registered functions in alphabetical order
+func (r *Registry) Functions() []string {
+	return r.registry.Functions()
+}
+
+// unwrap returns the engine value of a custom operator's or function's result
+func unwrap(name string, v Value, err error) (calculation.Value, error) {
+	if err != nil {
+		return nil, err
+	}
+	if v.value == nil {
+		return nil, fmt.Errorf("%s returned no value", name)
+	}
+	return v.value, nil
+}
@@ -0,0 +1,116 @@
+package calculator
+
+import (
+	"math/big"
+
+	"calculator/internal/calculation"
+)
+
+// Value is the result of an evaluation or the value of a variable. Results
+// may be plain numbers or richer types such as complex numbers, quantities
+// with units, money, dates and durations; Kind tells them apart
+type Value struct {
+	value calculation.Value
+	text  string
+}
+
+// Number returns a plain number value. f must not be NaN
+func Number(f float64) Value {
+	return BigNumber(new(big.Float).SetFloat64(f))
+}
+
+// BigNumber returns a plain number value holding f at full precision. The
+// value keeps its own copy of f
+func BigNumber(f *big.Float) Value {
+	return Value{value: calculation.NewNumber(new(big.Float).Copy(f))}
+}
+
+// Bool returns a true or false value, as produced by comparisons
+func Bool(b bool) Value {
+	return Value{value: calculation.Bool{Value: b}}
+}
+
+// List returns a list of values, as passed to the statistics functions, e.g.
+// EvaluateWithVariables("median(prices)", map[string]Value{"prices": List(...)}).
+// Zero Values are skipped
+func List(items ...Value) Value {
+	list := calculation.List{Items: make([]calculation.Value, 0, len(items))}
+	for _, item := range items {
+		if item.value != nil {
+			list.Items = append(list.Items, item.value)
+		}
+	}
+	return Value{value: list}
+}
+
+// Kind names the value type: "number", "complex", "quantity", "money",
+// "percent", "datetime", "time", "duration", "text", "boolean", "list" or
+// "function". The zero Value has an empty kind
+func (v Value) Kind() string {
+	if v.value == nil {
+		return ""
+	}
+	return v.value.Kind()
+}
+
+// String formats the value as the calculator displays it, with 15
+// significant digits
+func (v Value) String() string {
+	if v.text != "" || v.value == nil {
+		return v.text
+	}
+	return v.value.String()
+}
+
+// Float64 returns a plain number as a float64. ok is false for any other kind
+func (v Value) Float64() (f float64, ok bool) {
+	n, ok := v.value.(calculation.Number)
+	if !ok {
+		return 0, false
+	}
+	return n.Float64(), true
+}
+
+// BigFloat returns a copy of a plain number at full working precision. ok is
+// false for any other kind
+func (v Value) BigFloat() (f *big.Float, ok bool) {
+	n, ok := v.value.(calculation.Number)
+	if !ok {
+		return nil, false
+	}
+	return new(big.Float).Copy(n.Value), true
+}
+
+// Bool returns the result of a comparison or logical operator. ok is false
+// for any other kind
+func (v Value) Bool() (b bool, ok bool) {
+	t, ok := v.value.(calculation.Bool)
+	return t.Value, ok
+}
+
+// Items returns the values of a list. ok is false for any other kind
+func (v Value) Items() (items []Value, ok bool) {
+	list, ok := v.value.(calculation.List)
+	if !ok {
+		return nil, false
+	}
+	items = make([]Value, len(list.Items))
+	for i, item := range list.Items {
+		items[i] = Value{value: item}
+	}
+	return items, true
+}
+
+// internalVariables converts variables for the engine, skipping zero Values
+func internalVariables(vars map[string]Value) map[string]calculation.Value {
+	if len(vars) == 0 {
+		return nil
+	}
+	values := make(map[string]calculation.Value, len(vars))
+	for name, v := range vars {
+		if v.value != nil {
+			values[name] = v.value
+		}
+	}
+	return values
+}
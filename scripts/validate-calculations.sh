@@ -47,6 +47,10 @@ echo
    go test ./test/unit/... -v
    echo "   ✅ Unit tests passed"
 
+   echo "   Running concurrency tests with the race detector..."
+   go test -race ./test/unit/calculation/... ./test/unit/calculator/... -run 'Batch|Concurrent'
+   echo "   ✅ No data races detected"
+
    echo "   Running integration tests..."
    go test ./test/integration/... -v
    echo "   ✅ Integration tests passed"
@@ -110,7 +110,7 @@ func TestCalculationEngine_ErrorHandlingWorkflow(t *testing.T) {
 		},
 		{
 			name:        "invalid operator workflow",
-			expression:  "10 ^ 2",
+			expression:  "10 & 2",
 			expectedErr: "unsupported operator",
 			description: "Test invalid operator error handling",
 		},
@@ -205,7 +205,7 @@ func TestCalculationEngine_OperationsCoverage(t *testing.T) {
 	engine := calculation.NewCalculationEngine()
 
 	operations := engine.GetSupportedOperations()
-	expectedOps := []string{"+", "-", "*", "/"}
+	expectedOps := []string{"+", "-", "*", "/", "^", "%"}
 
 	if len(operations) != len(expectedOps) {
 		t.Errorf("expected %d operations, got %d", len(expectedOps), len(operations))
@@ -215,6 +215,10 @@ func TestCalculationEngine_OperationsCoverage(t *testing.T) {
 	for _, op := range expectedOps {
 		t.Run("operation_"+op, func(t *testing.T) {
 			expression := "10 " + op + " 5"
+			if op == "%" {
+				// % is a postfix percentage on the second operand
+				expression = "10 * 5%"
+			}
 			_, err := engine.Calculate(expression)
 			if err != nil {
 				t.Errorf("operation %s failed: %v", op, err)
@@ -0,0 +1,562 @@
+package integration_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"calculator/internal/terminal"
+)
+
+// runCLI executes the command-line front end and captures its output
+func runCLI(t *testing.T, stdin string, args ...string) (string, string, int) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := terminal.Run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return stdout.String(), stderr.String(), code
+}
+
+func TestCLI_CurrencyFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	rates := "date,base,currency,rate\n2026-10-15,EUR,USD,1.0842\n2026-10-15,EUR,GBP,0.8571\n"
+	if err := os.WriteFile(filepath.Join(dir, "rates.csv"), []byte(rates), 0o644); err != nil {
+		t.Fatalf("failed to write rates: %v", err)
+	}
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("currency_rates_file: rates.csv\n"), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	stdout, stderr, code := runCLI(t, "", "--config", configPath, "120 EUR + 30 USD in GBP")
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d (stderr: %s)", code, stderr)
+	}
+	if expected := "126.57 GBP (rates as of 2026-10-15)\n"; stdout != expected {
+		t.Errorf("expected %q, got %q", expected, stdout)
+	}
+}
+
+func TestCLI_EvaluationError(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "missing.yaml")
+
+	_, stderr, code := runCLI(t, "", "--config", configPath, "1", "/", "0")
+	if code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr, "division by zero") {
+		t.Errorf("expected division by zero error, got %q", stderr)
+	}
+}
+
+func TestCLI_NegativeExpression(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "missing.yaml")
+
+	// An argument starting with "-" that is not a flag begins the expression
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{args: []string{"--config", configPath, "-1"}, expected: "-1\n"},
+		{args: []string{"--config", configPath, "-5 EUR"}, expected: "-5.00 EUR\n"},
+		{args: []string{"--config", configPath, "-2", "*", "3"}, expected: "-6\n"},
+		{args: []string{"--config", configPath, "--", "-4"}, expected: "-4\n"},
+		{args: []string{"--config", configPath, "--rpn", "-2", "3", "*"}, expected: "1: -6\n"},
+	}
+	for _, tt := range tests {
+		stdout, stderr, code := runCLI(t, "", tt.args...)
+		if code != 0 || stdout != tt.expected {
+			t.Errorf("%v: expected %q, got exit %d, %q (stderr: %q)", tt.args, tt.expected, code, stdout, stderr)
+		}
+	}
+
+	if _, _, code := runCLI(t, "", "--config", configPath, "--nope", "1"); code != 2 {
+		t.Errorf("expected an unknown -- flag to stay a usage error, got exit %d", code)
+	}
+}
+
+func TestCLI_InvalidRatesFile(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("currency_rates_file: missing.json\n"), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	_, stderr, code := runCLI(t, "", "--config", configPath, "1 EUR")
+	if code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr, "failed to open rate table") {
+		t.Errorf("expected rate table error, got %q", stderr)
+	}
+}
+
+func TestCLI_PercentModeFromConfig(t *testing.T) {
+	tests := []struct {
+		mode     string
+		expected string
+	}{
+		{mode: "desk", expected: "88\n"},
+		{mode: "math", expected: "80.1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(configPath, []byte("percent_mode: "+tt.mode+"\n"), 0o644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+
+			stdout, stderr, code := runCLI(t, "", "--config", configPath, "80 + 10%")
+			if code != 0 {
+				t.Fatalf("expected exit code 0, got %d (stderr: %s)", code, stderr)
+			}
+			if stdout != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, stdout)
+			}
+		})
+	}
+}
+
+func TestCLI_ComplexModeFromConfig(t *testing.T) {
+	tests := []struct {
+		config   string
+		expr     string
+		code     int
+		expected string
+	}{
+		{config: "", expr: "sqrt(-4)", code: 1, expected: "enable complex mode"},
+		{config: "complex_mode: true\n", expr: "sqrt(-4)", expected: "2i\n"},
+		{config: "complex_form: polar\n", expr: "3+4i", expected: "5∠0.927295218001612\n"},
+		{config: "complex_form: round\n", expr: "1", code: 1, expected: "invalid complex_form \"round\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.config+tt.expr, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(configPath, []byte(tt.config), 0o644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+
+			stdout, stderr, code := runCLI(t, "", "--config", configPath, tt.expr)
+			if code != tt.code {
+				t.Fatalf("expected exit code %d, got %d (stderr: %s)", tt.code, code, stderr)
+			}
+			if tt.code == 0 && stdout != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, stdout)
+			}
+			if tt.code != 0 && !strings.Contains(stderr, tt.expected) {
+				t.Errorf("expected error containing %q, got %q", tt.expected, stderr)
+			}
+		})
+	}
+}
+
+func TestCLI_InteractiveSession(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "missing.yaml")
+
+	stdout, stderr, code := runCLI(t, "2 + 3\n1 / 0\nexit\n9 * 9\n", "--config", configPath)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d (stderr: %s)", code, stderr)
+	}
+	if !strings.Contains(stdout, "> 5\n") {
+		t.Errorf("expected result 5 after the prompt, got %q", stdout)
+	}
+	if strings.Contains(stdout, "81") {
+		t.Errorf("expected input after exit to be ignored, got %q", stdout)
+	}
+	if !strings.Contains(stderr, "division by zero") {
+		t.Errorf("expected division by zero error, got %q", stderr)
+	}
+}
+
+func TestCLI_ModeCommand(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "missing.yaml")
+
+	// Only "mode" and "mode <name>" switch modes; names starting with mode
+	// are ordinary variables
+	stdout, stderr, code := runCLI(t, "model = 5\nmodes = 2\nmodel * modes\nmode\nmode rpn\n", "--config", configPath)
+	if code != 0 || stderr != "" {
+		t.Fatalf("expected no errors, got exit %d (stderr: %s)", code, stderr)
+	}
+	for _, want := range []string{"model = 5\n", "modes = 2\n", "> 10\n", "Mode: infix\n", "Mode: rpn\n"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected %q in output, got %q", want, stdout)
+		}
+	}
+
+	_, stderr, _ = runCLI(t, "mode hex\n", "--config", configPath)
+	if !strings.Contains(stderr, `unknown mode "hex"`) {
+		t.Errorf("expected an unknown mode error, got %q", stderr)
+	}
+
+	// The mode statistics function is evaluated, not taken as the command
+	stdout, stderr, code = runCLI(t, "mode([1, 2, 2, 3])\nmode ([4, 4, 1])\n", "--config", configPath)
+	if code != 0 || stderr != "" || !strings.Contains(stdout, "> 2\n> 4\n") {
+		t.Errorf("expected mode() to be evaluated, got exit %d, %q (stderr: %q)", code, stdout, stderr)
+	}
+}
+
+func TestCLI_RPNMode(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "missing.yaml")
+
+	stdout, stderr, code := runCLI(t, "3 4\n+ 2\nswap\nmode infix\n2 ^ 10\n", "--config", configPath, "--rpn")
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d (stderr: %s)", code, stderr)
+	}
+	for _, expected := range []string{"rpn> 2: 3\n1: 4\n", "rpn> 2: 7\n1: 2\n", "rpn> 2: 2\n1: 7\n", "Mode: infix\n> 1024\n"} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("expected output to contain %q, got %q", expected, stdout)
+		}
+	}
+
+	stdout, _, code = runCLI(t, "", "--config", configPath, "--rpn", "3 4 + 2 *")
+	if code != 0 || stdout != "1: 14\n" {
+		t.Errorf("expected one-shot RPN to print the stack, got %q (exit %d)", stdout, code)
+	}
+}
+
+func TestCLI_DeskMode(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "missing.yaml")
+
+	stdout, stderr, code := runCLI(t, "100\n+ 15\n* 1.2\nsubtotal\n/ 4\ntape\n", "--config", configPath, "--desk")
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d (stderr: %s)", code, stderr)
+	}
+	for _, expected := range []string{"desk> +  15               = 115\n", "◇  subtotal         = 138\n", "/  4                = 34.5\n"} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("expected output to contain %q, got %q", expected, stdout)
+		}
+	}
+	if strings.Count(stdout, "= 138") != 4 {
+		t.Errorf("expected tape listing to repeat the steps, got %q", stdout)
+	}
+}
+
+func TestCLI_UndoRedo(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "missing.yaml")
+
+	session := "rate = 0.2\nprice = 50\nprice * rate\n:undo\n:undo\nvars\n:redo\nprice * rate\nhistory clear\n:undo\nhistory\n:redo\n:redo\n"
+	stdout, stderr, code := runCLI(t, session, "--config", configPath)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d (stderr: %s)", code, stderr)
+	}
+	for _, expected := range []string{
+		"> Undid: price * rate\n",
+		"> Undid: price = 50\n",
+		"> ans = 0.2\nrate = 0.2\n> Redid: price = 50\n",
+		"> 10\n",
+		"> Undid: history clear\n> 1. rate = 0.2 = 0.2\n2. price = 50 = 50\n3. price * rate = 10\n",
+	} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("expected output to contain %q, got %q", expected, stdout)
+		}
+	}
+	if !strings.Contains(stderr, "nothing to redo") {
+		t.Errorf("expected redo past the newest change to fail, got %q", stderr)
+	}
+
+	stdout, _, _ = runCLI(t, "100\n+ 5\n:undo\ntape\n", "--config", configPath, "--desk")
+	if !strings.Contains(stdout, "Undid: + 5\ndesk>    100              = 100\n") {
+		t.Errorf("expected undo to remove the desk step, got %q", stdout)
+	}
+}
+
+func TestCLI_MemoryRegisters(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+
+	session := "40 * 3\nM+\n15\nM+\nMS M1\nmemory\nM - M1\nMC M1\n"
+	stdout, stderr, code := runCLI(t, session, "--config", configPath)
+	if code != 0 || stderr != "" {
+		t.Fatalf("expected clean session, got exit %d (stderr: %s)", code, stderr)
+	}
+	for _, expected := range []string{"> M = 120\n", "> M = 135\n", "> M1 = 15\n", "> M = 135\nM1 = 15\n", "> 120\n", "> M1 = 0\n"} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("expected output to contain %q, got %q", expected, stdout)
+		}
+	}
+
+	// Registers are saved next to the config file and read by later runs
+	if _, err := os.Stat(filepath.Join(dir, "memory.json")); err != nil {
+		t.Fatalf("expected memory.json next to the config file: %v", err)
+	}
+	stdout, stderr, code = runCLI(t, "", "--config", configPath, "M / 5")
+	if code != 0 || stdout != "27\n" {
+		t.Errorf("expected saved register in a one-shot expression, got %q (exit %d, stderr: %s)", stdout, code, stderr)
+	}
+
+	stdout, _, _ = runCLI(t, "MR\n", "--config", configPath, "--rpn")
+	if !strings.Contains(stdout, "rpn> 1: 135\n") {
+		t.Errorf("expected MR to push the register in RPN mode, got %q", stdout)
+	}
+}
+
+func TestCLI_ServeRejectsBadAddress(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "missing.yaml")
+
+	_, stderr, code := runCLI(t, "", "serve", "--config", configPath, "--addr", "256.0.0.1:bad")
+	if code != 1 || !strings.Contains(stderr, "failed to listen") {
+		t.Errorf("expected listen error, got exit %d (stderr: %s)", code, stderr)
+	}
+	_, _, code = runCLI(t, "", "serve", "--config", configPath, "extra")
+	if code != 2 {
+		t.Errorf("expected usage error for an extra argument, got exit %d", code)
+	}
+}
+
+func TestCLI_RPCOverStdio(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "missing.yaml")
+
+	session := `{"jsonrpc": "2.0", "method": "calculate", "params": ["(2 + 3) * 4"], "id": 1}` + "\n" +
+		`{"jsonrpc": "2.0", "method": "history.list", "id": 2}` + "\n"
+	stdout, stderr, code := runCLI(t, session, "rpc", "--config", configPath)
+	if code != 0 || stderr != "" {
+		t.Fatalf("expected clean exit at end of input, got exit %d (stderr: %s)", code, stderr)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one response line per request, got %q", stdout)
+	}
+	if !strings.Contains(lines[0], `"result":20`) || !strings.Contains(lines[0], `"id":1`) {
+		t.Errorf("expected calculate result 20, got %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"expression":"(2 + 3) * 4"`) {
+		t.Errorf("expected history.list to include the calculation, got %s", lines[1])
+	}
+}
+
+func TestCLI_DeskTapeExportImport(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "missing.yaml")
+	tapePath := filepath.Join(dir, "expenses.csv")
+
+	session := "250\nnote hotel\n+ 40\n* 1.1\ntotal\nexport " + tapePath + "\n"
+	_, stderr, code := runCLI(t, session, "--config", configPath, "--desk")
+	if code != 0 || stderr != "" {
+		t.Fatalf("expected clean export, got exit %d (stderr: %s)", code, stderr)
+	}
+
+	stdout, stderr, code := runCLI(t, "import "+tapePath+"\n", "--config", configPath, "--desk")
+	if code != 0 || stderr != "" {
+		t.Fatalf("expected clean import, got exit %d (stderr: %s)", code, stderr)
+	}
+	for _, expected := range []string{"   250              = 250  # hotel\n", "*  total            = 319\n"} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("expected replayed tape to contain %q, got %q", expected, stdout)
+		}
+	}
+}
+
+func TestCLI_TUIRequiresTerminal(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "missing.yaml")
+
+	_, stderr, code := runCLI(t, "", "--config", configPath, "--tui")
+	if code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr, "interactive terminal") {
+		t.Errorf("expected interactive terminal error, got %q", stderr)
+	}
+}
+
+func TestCLI_Batch(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "missing.yaml")
+
+	input := "1 + 1\n# comment\n\n2 * 21\n5 km to m\n"
+	stdout, stderr, code := runCLI(t, input, "--config", configPath, "--batch", "-", "--workers", "2")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d (stderr: %s)", code, stderr)
+	}
+	if stdout != "2\n42\n5000 m\n" {
+		t.Errorf("expected results in input order, got %q", stdout)
+	}
+	if !strings.Contains(stderr, "3 expressions: 3 succeeded, 0 failed") {
+		t.Errorf("expected batch statistics, got %q", stderr)
+	}
+
+	batchFile := filepath.Join(t.TempDir(), "batch.txt")
+	if err := os.WriteFile(batchFile, []byte("10 / 4\n1 / 0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr, code = runCLI(t, "", "--config", configPath, "--batch", batchFile)
+	if code != 1 || stdout != "2.5\nError: division by zero\n" || !strings.Contains(stderr, "1 failed") {
+		t.Errorf("expected a failed expression to be reported in place, got exit %d, %q (stderr: %q)", code, stdout, stderr)
+	}
+
+	_, stderr, code = runCLI(t, "", "--config", configPath, "--batch", filepath.Join(t.TempDir(), "missing.txt"))
+	if code != 1 || !strings.Contains(stderr, "failed to open batch file") {
+		t.Errorf("expected a missing-file error, got exit %d (stderr: %s)", code, stderr)
+	}
+}
+
+func TestCLI_BatchCache(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("cache_size: 10\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, code := runCLI(t, "2 ^ 10\n2 ^ 10\n3 * 3\n2  ^  10\n", "--config", configPath, "--batch", "-", "--workers", "1")
+	if code != 0 || stdout != "1024\n1024\n9\n1024\n" {
+		t.Fatalf("expected cached results to match, got exit %d, %q (stderr: %q)", code, stdout, stderr)
+	}
+	if !strings.Contains(stderr, "cache: 2 hits, 2 misses") {
+		t.Errorf("expected cache statistics, got %q", stderr)
+	}
+}
+
+func TestCLI_ResourceLimits(t *testing.T) {
+	dir := t.TempDir()
+	stdout, stderr, code := runCLI(t, "", "--config", filepath.Join(dir, "missing.yaml"), "9^9^9")
+	if code != 1 || !strings.Contains(stdout+stderr, "exponent limit exceeded") {
+		t.Errorf("expected the default limits to reject 9^9^9, got exit %d, %q (stderr: %q)", code, stdout, stderr)
+	}
+
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("max_depth: 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr, code = runCLI(t, "", "--config", configPath, "1 + 2 * 3")
+	if code != 1 || !strings.Contains(stdout+stderr, "nesting depth limit exceeded (maximum 2)") {
+		t.Errorf("expected the configured depth limit, got exit %d, %q (stderr: %q)", code, stdout, stderr)
+	}
+}
+
+func TestCLI_RunScript(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "missing.yaml")
+	script := filepath.Join(dir, "loan.calc")
+	source := `# monthly payments on a 1200 loan at 0% interest
+balance = 1200
+for month = 1 to 3 {
+    balance = balance - 400
+    print "month", month, "balance", balance
+}
+if balance == 0 { print "paid off" }
+`
+	if err := os.WriteFile(script, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, code := runCLI(t, "", "run", "--config", configPath, script)
+	expected := "month 1 balance 800\nmonth 2 balance 400\nmonth 3 balance 0\npaid off\n"
+	if code != 0 || stdout != expected {
+		t.Errorf("expected %q, got exit %d, %q (stderr: %q)", expected, code, stdout, stderr)
+	}
+
+	stdout, stderr, code = runCLI(t, "x = 1\nprint x / 0\n", "run", "--config", configPath, "-")
+	if code != 1 || stdout != "" || !strings.Contains(stderr, "line 2: division by zero") {
+		t.Errorf("expected a runtime error with its line, got exit %d, %q (stderr: %q)", code, stdout, stderr)
+	}
+
+	if err := os.WriteFile(configPath, []byte("max_iterations: 10\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr, code = runCLI(t, "while true { }", "run", "--config", configPath, "-")
+	if code != 1 || !strings.Contains(stderr, "loop iterations limit exceeded (maximum 10)") {
+		t.Errorf("expected the configured iteration limit, got exit %d (stderr: %q)", code, stderr)
+	}
+
+	if _, _, code := runCLI(t, "", "run"); code != 2 {
+		t.Errorf("expected exit code 2 without a script, got %d", code)
+	}
+	if _, stderr, code := runCLI(t, "", "run", filepath.Join(dir, "nope.calc")); code != 1 || stderr == "" {
+		t.Errorf("expected a missing script to fail, got exit %d", code)
+	}
+}
+
+func TestCLI_Assert(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+
+	// Store a total in memory as a CI job would, then check it
+	if _, stderr, code := runCLI(t, "1000 + 234.56\nMS total\n", "--config", configPath); code != 0 {
+		t.Fatalf("failed to store the total: %s", stderr)
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stderr string
+	}{
+		{name: "passing", args: []string{"total ~= 1234.56"}, code: 0},
+		{name: "arguments joined", args: []string{"total", ">", "1000"}, code: 0},
+		{name: "failing", args: []string{"total == 1234.5"}, code: 1, stderr: "Error: assertion failed: total == 1234.5 (got 1234.56 and 1234.5)\n"},
+		{name: "tolerance flag", args: []string{"--tolerance", "0.01", "total ~= 1240"}, code: 0},
+		{name: "not a boolean", args: []string{"total + 1"}, code: 2, stderr: "Error: assert expects true or false, got number\n"},
+		{name: "invalid expression", args: []string{"total ~="}, code: 2, stderr: "Error: unexpected end of expression\n"},
+		{name: "no expression", args: nil, code: 2, stderr: "Error: expected an expression"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"assert", "--config", configPath}, tt.args...)
+			stdout, stderr, code := runCLI(t, "", args...)
+			if code != tt.code || stdout != "" || !strings.HasPrefix(stderr, tt.stderr) || (tt.stderr == "" && stderr != "") {
+				t.Errorf("expected exit %d and %q, got exit %d, %q (stdout: %q)", tt.code, tt.stderr, code, stderr, stdout)
+			}
+		})
+	}
+
+	// A failing assert stops a script with exit code 1
+	_, stderr, code := runCLI(t, "x = 2\nassert x ^ 2 ~= 5\n", "run", "--config", configPath, "-")
+	if code != 1 || !strings.Contains(stderr, "line 2: assertion failed: x ^ 2 ~= 5 (got 4 and 5)") {
+		t.Errorf("expected the script assertion to fail, got exit %d, %q", code, stderr)
+	}
+}
+
+func TestCLI_UserFunctions(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "missing.yaml")
+	input := "tax(x) = x <= 10000 ? 0 : (x - 10000) * 0.2\ntax(8000)\ntax(25000)\ntax(1, 2)\nvars\n"
+	stdout, stderr, code := runCLI(t, input, "--config", configPath)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d (stderr: %s)", code, stderr)
+	}
+	for _, want := range []string{"> tax(x) = x <= 10000 ? 0 : (x - 10000) * 0.2\n", "> 0\n", "> 3000\n"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected %q in output, got %q", want, stdout)
+		}
+	}
+	if !strings.Contains(stderr, "tax expects 1 argument(s), got 2") {
+		t.Errorf("expected an argument count error, got %q", stderr)
+	}
+
+	stdout, stderr, code = runCLI(t, "sign(x) = if(x < 0, -1, x > 0 ? 1 : 0)\nprint sign(-4), sign(0), sign(3)\n", "run", "--config", configPath, "-")
+	if code != 0 || stdout != "-1 0 1\n" {
+		t.Errorf("expected \"-1 0 1\", got exit %d, %q (stderr: %q)", code, stdout, stderr)
+	}
+}
+
+func TestCLI_Stats(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "missing.yaml")
+
+	stdout, stderr, code := runCLI(t, "12.5\n# pasted from a spreadsheet\n\n7\n3 * 4\n0.1\n", "stats", "--config", configPath)
+	expected := "count  4\nsum    31.6\nmean   7.9\nmedian 9.5\nstdev  5.76252259576192\nmin    0.1\nmax    12.5\n"
+	if code != 0 || stdout != expected {
+		t.Errorf("expected %q, got exit %d, %q (stderr: %q)", expected, code, stdout, stderr)
+	}
+
+	column := filepath.Join(dir, "column.txt")
+	if err := os.WriteFile(column, []byte("5\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _, code = runCLI(t, "", "stats", "--config", configPath, column)
+	if code != 0 || strings.Contains(stdout, "stdev") || !strings.Contains(stdout, "mean   5\n") {
+		t.Errorf("expected a single value to be summarized without stdev, got exit %d, %q", code, stdout)
+	}
+
+	_, stderr, code = runCLI(t, "1\n2 km\n", "stats", "--config", configPath, "-")
+	if code != 1 || !strings.Contains(stderr, "line 2: expected a number, got quantity") {
+		t.Errorf("expected a line error, got exit %d, %q", code, stderr)
+	}
+	if _, stderr, code := runCLI(t, "# nothing\n", "stats", "--config", configPath); code != 1 || !strings.Contains(stderr, "no numbers") {
+		t.Errorf("expected an empty column to fail, got exit %d, %q", code, stderr)
+	}
+	if _, _, code := runCLI(t, "", "stats", "a.txt", "b.txt"); code != 2 {
+		t.Errorf("expected exit code 2 for two files, got %d", code)
+	}
+}
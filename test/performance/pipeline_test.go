@@ -0,0 +1,237 @@
+package performance_test
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"testing"
+
+	"calculator/internal/calculation"
+)
+
+// pipelineExpressions are representative small and larger expressions for
+// the full lexer, parser and evaluator pipeline
+var pipelineExpressions = []struct {
+	name string
+	expr string
+}{
+	{name: "simple", expr: "2 + 3"},
+	{name: "decimal", expr: "123456789.123456789 + 987654321.987654321"},
+	{name: "nested", expr: "(1.5 + 2.25) * (3 - 0.75) / 4 ^ 2"},
+	{name: "functions", expr: "sqrt(16) + abs(-2.5) * pi"},
+	{name: "units", expr: "9.81 m/s^2 * 3 s to km/h"},
+}
+
+// BenchmarkCheckSyntax benchmarks the lexer and parser without evaluation
+func BenchmarkCheckSyntax(b *testing.B) {
+	engine := calculation.NewCalculationEngine()
+	for _, tc := range pipelineExpressions {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := engine.CheckSyntax(tc.expr); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkEvaluate benchmarks parsing and evaluating on every call
+func BenchmarkEvaluate(b *testing.B) {
+	engine := calculation.NewCalculationEngine()
+	for _, tc := range pipelineExpressions {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := engine.Evaluate(tc.expr); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkProgramEval benchmarks evaluating an expression compiled once,
+// the way batch and simulation workloads should use the engine
+func BenchmarkProgramEval(b *testing.B) {
+	engine := calculation.NewCalculationEngine()
+	for _, tc := range pipelineExpressions {
+		b.Run(tc.name, func(b *testing.B) {
+			program, err := engine.Compile(tc.expr)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := program.Eval(nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkProgramEvalVariables benchmarks a compiled formula evaluated with
+// changing inputs
+func BenchmarkProgramEvalVariables(b *testing.B) {
+	engine := calculation.NewCalculationEngine()
+	program, err := engine.Compile("principal * (1 + rate / 12) ^ months - principal")
+	if err != nil {
+		b.Fatal(err)
+	}
+	vars := map[string]calculation.Value{
+		"principal": calculation.NewNumber(big.NewFloat(1000)),
+		"rate":      calculation.NewNumber(big.NewFloat(0.05)),
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vars["months"] = calculation.NewNumber(big.NewFloat(float64(i % 360)))
+		if _, err := program.Eval(vars); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkEvaluateCached benchmarks repeated expressions answered from the
+// result cache
+func BenchmarkEvaluateCached(b *testing.B) {
+	engine := calculation.NewCalculationEngine(calculation.WithCache(calculation.NewResultCache(len(pipelineExpressions))))
+	for _, tc := range pipelineExpressions {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := engine.Evaluate(tc.expr); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkSanitizeExpression benchmarks input sanitization against the
+// regular expression it replaced
+func BenchmarkSanitizeExpression(b *testing.B) {
+	const expr = "123456789.123456789 + 987654321.987654321; DROP TABLE"
+	for _, tc := range []struct {
+		name     string
+		sanitize func(string) string
+	}{
+		{name: "current", sanitize: calculation.SanitizeExpression},
+		{name: "baseline", sanitize: baselineSanitizeExpression},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				tc.sanitize(expr)
+			}
+		})
+	}
+}
+
+// BenchmarkValidateExpression benchmarks the three-token validator against
+// the regular expression and strings.Fields version it replaced
+func BenchmarkValidateExpression(b *testing.B) {
+	const expr = "123456789.123456789 / 987654321.987654321"
+	for _, tc := range []struct {
+		name     string
+		validate func(string) error
+	}{
+		{name: "current", validate: calculation.ValidateExpression},
+		{name: "baseline", validate: baselineValidateExpression},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := tc.validate(expr); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// baselineSanitizeExpression is SanitizeExpression as it was before the
+// hot paths stopped compiling regular expressions on every call
+func baselineSanitizeExpression(expression string) string {
+	reg := regexp.MustCompile(`[^0-9+\-*/.\s]`)
+	return reg.ReplaceAllString(expression, "")
+}
+
+// baselineValidateExpression is ValidateExpression as it was before the hot
+// paths stopped compiling regular expressions on every call: the expression
+// is split twice and each number is matched against a fresh regexp before
+// being parsed
+func baselineValidateExpression(expression string) error {
+	if strings.TrimSpace(expression) == "" {
+		return fmt.Errorf("expression cannot be empty")
+	}
+	if parts := strings.Fields(expression); len(parts) != 3 {
+		return fmt.Errorf("invalid format: expected 'number operator number', got %d parts", len(parts))
+	}
+	parts := strings.Fields(expression)
+	num1, op, num2 := parts[0], parts[1], strings.TrimSuffix(parts[2], "%")
+	for _, num := range []string{num1, num2} {
+		if matched, _ := regexp.MatchString(`^-?\d+(\.\d+)?$`, num); !matched {
+			return fmt.Errorf("invalid number format: %s", num)
+		}
+		if _, _, err := big.ParseFloat(num, 10, 100, big.ToNearestEven); err != nil {
+			return fmt.Errorf("failed to parse number: %w", err)
+		}
+	}
+	switch op {
+	case "+", "-", "*", "/":
+	default:
+		return fmt.Errorf("unsupported operator: %s", op)
+	}
+	if op == "/" {
+		if matched, _ := regexp.MatchString(`^-?\d+(\.\d+)?$`, num2); !matched {
+			return fmt.Errorf("invalid number format: %s", num2)
+		}
+		divisor, _, err := big.ParseFloat(num2, 10, 100, big.ToNearestEven)
+		if err != nil {
+			return fmt.Errorf("failed to parse number: %w", err)
+		}
+		if divisor.Sign() == 0 {
+			return fmt.Errorf("division by zero detected")
+		}
+	}
+	return nil
+}
+
+// TestAllocationBudgets guards the hot paths against regressions: the lexer
+// must not allocate for small expressions, so parsing allocates only the
+// syntax tree, and the legacy three-token path must not compile regular
+// expressions per call
+func TestAllocationBudgets(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+	program, err := engine.Compile("2 + 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		budget float64
+		fn     func()
+	}{
+		{name: "CheckSyntax", budget: 3, fn: func() { engine.CheckSyntax("2 + 3") }},
+		{name: "CheckSyntax nested", budget: 11, fn: func() { engine.CheckSyntax("(1.5 + 2.25) * (3 - 0.75) / 4 ^ 2") }},
+		{name: "Program.Eval", budget: 3, fn: func() { program.Eval(nil) }},
+		{name: "Validate", budget: 12, fn: func() { engine.Validate("123.456 / 789.012") }},
+		{name: "Calculate", budget: 20, fn: func() { engine.Calculate("123.456 / 789.012") }},
+		{name: "SanitizeExpression", budget: 1, fn: func() { calculation.SanitizeExpression("2 + 3; DROP") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if allocs := testing.AllocsPerRun(100, tt.fn); allocs > tt.budget {
+				t.Errorf("%s made %.0f allocations, budget is %.0f", tt.name, allocs, tt.budget)
+			}
+		})
+	}
+}
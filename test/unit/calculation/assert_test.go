@@ -0,0 +1,65 @@
+package calculation_test
+
+import (
+	"errors"
+	"testing"
+
+	"calculator/internal/calculation"
+	"calculator/test"
+)
+
+func TestAssert(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		failed   bool
+		errorMsg string
+	}{
+		{name: "true comparison", expr: "total ~= 1234.56"},
+		{name: "true expression", expr: "total > 1000 && total < 2000"},
+		{name: "failed comparison", expr: "total == 1234.5", failed: true, errorMsg: "assertion failed: total == 1234.5 (got 1234.56 and 1234.5)"},
+		{name: "failed expression", expr: " !(total > 0) ", failed: true, errorMsg: "assertion failed: !(total > 0)"},
+		{name: "not a boolean", expr: "total * 2", errorMsg: "assert expects true or false, got number"},
+		{name: "evaluation error", expr: "total / 0 > 1", errorMsg: "division by zero"},
+		{name: "syntax error", expr: "total ~=", errorMsg: "unexpected end of expression"},
+	}
+
+	engine := calculation.NewCalculationEngine()
+	vars := map[string]calculation.Value{"total": number(1234.56)}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := engine.Assert(tt.expr, vars)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			var assertion *calculation.AssertionError
+			if errors.As(err, &assertion) != tt.failed {
+				t.Errorf("expected assertion failure %t, got %v", tt.failed, err)
+			}
+			if err == nil || !test.ContainsString(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error containing %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestScript_Assert(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+	out, _, err := runScript(t, engine, "x = 0.1 * 3\nassert x ~= 0.3\nprint \"ok\"")
+	if err != nil || out != "ok\n" {
+		t.Errorf("expected the assertion to pass, got %q (%v)", out, err)
+	}
+
+	out, _, err = runScript(t, engine, "for k = 1 to 3 {\n  print k\n  assert k*k < 4\n}")
+	var scriptErr *calculation.ScriptError
+	var assertion *calculation.AssertionError
+	if !errors.As(err, &scriptErr) || !errors.As(err, &assertion) || scriptErr.Line != 3 {
+		t.Fatalf("expected an assertion failure on line 3, got %v", err)
+	}
+	if out != "1\n2\n" || err.Error() != "line 3: assertion failed: k*k < 4 (got 4 and 4)" {
+		t.Errorf("expected the script to stop at k = 2, got %q (%v)", out, err)
+	}
+}
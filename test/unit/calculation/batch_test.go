@@ -0,0 +1,98 @@
+package calculation_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"calculator/internal/calculation"
+)
+
+func TestCalculateBatch_PreservesOrder(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+	expressions := make([]string, 500)
+	for i := range expressions {
+		expressions[i] = fmt.Sprintf("%d * 2 + 1", i)
+	}
+
+	for _, workers := range []int{1, 4, 64} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			results := engine.CalculateBatch(context.Background(), expressions, calculation.WithWorkers(workers))
+			if len(results) != len(expressions) {
+				t.Fatalf("expected %d results, got %d", len(expressions), len(results))
+			}
+			for i, r := range results {
+				if r.Err != nil {
+					t.Fatalf("%s: unexpected error: %v", r.Expression, r.Err)
+				}
+				if r.Expression != expressions[i] {
+					t.Fatalf("result %d is for %q, expected %q", i, r.Expression, expressions[i])
+				}
+				if expected := fmt.Sprint(i*2 + 1); r.Value.String() != expected {
+					t.Fatalf("%s: expected %s, got %s", r.Expression, expected, r.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateBatch_ConcurrentMixedExpressions(t *testing.T) {
+	// Run with -race: the workers share one engine and one variables map
+	rates, err := calculation.NewRateTable("EUR", "2026-10-15", map[string]string{"USD": "1.1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	engine := calculation.NewCalculationEngine(calculation.WithRateTable(rates), calculation.WithComplexMode(true))
+	vars := map[string]calculation.Value{"x": calculation.NewNumber(big.NewFloat(4))}
+
+	base := []string{
+		"sqrt(x) + pi", "5 km to m", "100 EUR to USD", "sqrt(-4) * 2i", "2026-10-17 + 90 days",
+		"17:30 + 45min", "200 * 15%", "80 + 10%", "x ^ 3 / 7", "1 / 0", "2 +",
+	}
+	var expressions []string
+	for i := 0; i < 40; i++ {
+		expressions = append(expressions, base...)
+	}
+
+	sequential := engine.CalculateBatch(context.Background(), expressions, calculation.WithWorkers(1), calculation.WithBatchVariables(vars))
+	concurrent := engine.CalculateBatch(context.Background(), expressions, calculation.WithWorkers(16), calculation.WithBatchVariables(vars))
+	for i := range expressions {
+		want, got := sequential[i], concurrent[i]
+		if (want.Err == nil) != (got.Err == nil) {
+			t.Fatalf("%s: sequential error %v, concurrent error %v", expressions[i], want.Err, got.Err)
+		}
+		if want.Err == nil && want.Value.String() != got.Value.String() {
+			t.Fatalf("%s: sequential %s, concurrent %s", expressions[i], want.Value, got.Value)
+		}
+	}
+
+	stats := calculation.SummarizeBatch(concurrent)
+	if stats.Total != len(expressions) || stats.Failed != 80 || stats.Succeeded != len(expressions)-80 || stats.Cancelled != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if stats.Slowest <= 0 || stats.Busy < stats.Slowest {
+		t.Errorf("expected timing statistics, got %+v", stats)
+	}
+}
+
+func TestCalculateBatch_Cancellation(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := engine.CalculateBatch(ctx, []string{"1 + 1", "2 + 2", "3 + 3"}, calculation.WithWorkers(2))
+	for _, r := range results {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("%s: expected context.Canceled, got %v", r.Expression, r.Err)
+		}
+	}
+	if stats := calculation.SummarizeBatch(results); stats.Cancelled != 3 || stats.Succeeded != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	if results := engine.CalculateBatch(context.Background(), nil); len(results) != 0 {
+		t.Errorf("expected no results for an empty batch, got %d", len(results))
+	}
+}
@@ -0,0 +1,192 @@
+package calculation_test
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"calculator/internal/calculation"
+)
+
+// number builds a Number value for tests
+func number(f float64) calculation.Value {
+	return calculation.NewNumber(big.NewFloat(f))
+}
+
+func TestResultCache_HitsAndMisses(t *testing.T) {
+	cache := calculation.NewResultCache(10)
+	engine := calculation.NewCalculationEngine(calculation.WithCache(cache))
+
+	for _, expr := range []string{"2 + 3 * 4", "2 + 3 * 4", "  2  +  3 *\t4 ", "2+3*4"} {
+		v, err := engine.Evaluate(expr)
+		if err != nil || v.String() != "14" {
+			t.Fatalf("%q: expected 14, got %v (%v)", expr, v, err)
+		}
+	}
+
+	stats := cache.Stats()
+	// "2+3*4" is spelled differently and gets its own entry
+	if stats.Hits != 2 || stats.Misses != 2 || stats.Entries != 2 || stats.Capacity != 10 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if engine.Cache() != cache {
+		t.Error("expected Cache to return the engine's cache")
+	}
+}
+
+func TestResultCache_VariablesInvalidate(t *testing.T) {
+	cache := calculation.NewResultCache(10)
+	engine := calculation.NewCalculationEngine(calculation.WithCache(cache))
+
+	tests := []struct {
+		vars     map[string]calculation.Value
+		expected string
+		hit      bool
+	}{
+		{vars: map[string]calculation.Value{"x": number(2)}, expected: "4"},
+		{vars: map[string]calculation.Value{"x": number(2)}, expected: "4", hit: true},
+		{vars: map[string]calculation.Value{"x": number(2), "unused": number(9)}, expected: "4", hit: true},
+		{vars: map[string]calculation.Value{"x": number(3)}, expected: "9"},
+		{vars: map[string]calculation.Value{"x": calculation.Text{Value: "three"}}, expected: ""},
+		{vars: map[string]calculation.Value{"x": number(3)}, expected: "9", hit: true},
+	}
+
+	for i, tt := range tests {
+		before := cache.Stats().Hits
+		v, err := engine.EvaluateWithVariables("x * x", tt.vars)
+		if tt.expected == "" {
+			if err == nil {
+				t.Errorf("step %d: expected an error, got %v", i, v)
+			}
+			continue
+		}
+		if err != nil || v.String() != tt.expected {
+			t.Fatalf("step %d: expected %s, got %v (%v)", i, tt.expected, v, err)
+		}
+		if hit := cache.Stats().Hits > before; hit != tt.hit {
+			t.Errorf("step %d: expected hit=%v", i, tt.hit)
+		}
+	}
+
+	if stats := cache.Stats(); stats.Invalidations == 0 {
+		t.Errorf("expected invalidations to be counted, got %+v", stats)
+	}
+	if _, err := engine.Evaluate("x * x"); err == nil {
+		t.Error("expected an error once the variable is undefined")
+	}
+}
+
+func TestResultCache_NotCached(t *testing.T) {
+	clock := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	cache := calculation.NewResultCache(10)
+	engine := calculation.NewCalculationEngine(calculation.WithCache(cache),
+		calculation.WithClock(func() time.Time { return clock }), calculation.WithLocation(time.UTC))
+
+	first, err := engine.Evaluate("today + 1 day")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	clock = clock.Add(48 * time.Hour)
+	second, err := engine.Evaluate("today + 1 day")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.String() == second.String() {
+		t.Errorf("expected results that read the clock not to be cached, got %s twice", first)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := engine.Evaluate("1 / 0"); err == nil {
+			t.Fatal("expected division by zero")
+		}
+	}
+	if stats := cache.Stats(); stats.Hits != 0 || stats.Entries != 0 {
+		t.Errorf("expected nothing cached, got %+v", stats)
+	}
+}
+
+func TestResultCache_Eviction(t *testing.T) {
+	cache := calculation.NewResultCache(2)
+	engine := calculation.NewCalculationEngine(calculation.WithCache(cache))
+
+	for _, expr := range []string{"1 + 1", "2 + 2", "1 + 1", "3 + 3", "1 + 1", "2 + 2"} {
+		if _, err := engine.Evaluate(expr); err != nil {
+			t.Fatalf("%s: unexpected error: %v", expr, err)
+		}
+	}
+
+	// "1 + 1" stays as the most recently used entry; "2 + 2" is evicted by
+	// "3 + 3" and must be evaluated again
+	stats := cache.Stats()
+	if stats.Hits != 2 || stats.Misses != 4 || stats.Evictions != 2 || stats.Entries != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	cache.Purge()
+	if stats := cache.Stats(); stats.Entries != 0 || stats.Hits != 2 {
+		t.Errorf("expected Purge to empty the cache and keep counters, got %+v", stats)
+	}
+}
+
+func TestResultCache_SharedBetweenSettings(t *testing.T) {
+	cache := calculation.NewResultCache(10)
+	desk := calculation.NewCalculationEngine(calculation.WithCache(cache))
+	math := calculation.NewCalculationEngine(calculation.WithCache(cache),
+		calculation.WithPercentMode(calculation.PercentMath))
+
+	for _, tc := range []struct {
+		engine   *calculation.CalculationEngine
+		expected string
+	}{
+		{engine: desk, expected: "88"},
+		{engine: math, expected: "80.1"},
+		{engine: desk, expected: "88"},
+	} {
+		v, err := tc.engine.Evaluate("80 + 10%")
+		if err != nil || v.String() != tc.expected {
+			t.Errorf("expected %s, got %v (%v)", tc.expected, v, err)
+		}
+	}
+	if stats := cache.Stats(); stats.Entries != 2 || stats.Hits != 1 {
+		t.Errorf("expected one entry per settings, got %+v", stats)
+	}
+}
+
+func TestResultCache_Concurrent(t *testing.T) {
+	cache := calculation.NewResultCache(8)
+	engine := calculation.NewCalculationEngine(calculation.WithCache(cache))
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				n := (w + i) % 16
+				v, err := engine.EvaluateWithVariables(fmt.Sprintf("%d * y", n), map[string]calculation.Value{"y": number(float64(i % 3))})
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				if expected := fmt.Sprint(n * (i % 3)); v.String() != expected {
+					t.Errorf("expected %s, got %s", expected, v)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	results := engine.CalculateBatch(context.Background(), []string{"6 * 7", "6 * 7", "6  *  7"}, calculation.WithWorkers(1))
+	for _, r := range results {
+		if r.Err != nil || r.Value.String() != "42" {
+			t.Errorf("%s: expected 42, got %v (%v)", r.Expression, r.Value, r.Err)
+		}
+	}
+	if stats := cache.Stats(); stats.Entries > stats.Capacity || stats.Hits+stats.Misses != 8*200+3 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,150 @@
+package calculation_test
+
+import (
+	"testing"
+
+	"calculator/internal/calculation"
+	"calculator/test"
+)
+
+func TestEvaluate_ComplexArithmetic(t *testing.T) {
+	engine := calculation.NewCalculationEngine(calculation.WithComplexMode(true))
+
+	tests := []struct {
+		name       string
+		expression string
+		expected   string
+	}{
+		{name: "rectangular literal", expression: "3+4i", expected: "3+4i"},
+		{name: "pure imaginary literal", expression: "2i", expected: "2i"},
+		{name: "imaginary unit", expression: "i", expected: "i"},
+		{name: "negative imaginary part", expression: "3 - 4i", expected: "3-4i"},
+		{name: "addition", expression: "(1+2i) + (3-5i)", expected: "4-3i"},
+		{name: "multiplication", expression: "(3+4i) * (3-4i)", expected: "25"},
+		{name: "i squared", expression: "i * i", expected: "-1"},
+		{name: "division", expression: "(1+2i) / (3+4i)", expected: "0.44+0.08i"},
+		{name: "mixed real and complex", expression: "2 * (1.5-0.5i)", expected: "3-i"},
+		{name: "real part", expression: "re(3+4i)", expected: "3"},
+		{name: "imaginary part", expression: "im(3+4i)", expected: "4"},
+		{name: "magnitude", expression: "abs(3+4i)", expected: "5"},
+		{name: "real absolute value", expression: "abs(-7.5)", expected: "7.5"},
+		{name: "argument of i", expression: "arg(i)", expected: "1.5707963267949"},
+		{name: "argument of negative real", expression: "arg(-1)", expected: "3.14159265358979"},
+		{name: "conjugate", expression: "conj(3+4i)", expected: "3-4i"},
+		{name: "sqrt of negative", expression: "sqrt(-4)", expected: "2i"},
+		{name: "sqrt of complex", expression: "sqrt(3+4i)", expected: "2+i"},
+		{name: "sqrt of positive", expression: "sqrt(2)", expected: "1.4142135623731"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := engine.Evaluate(tt.expression)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := engine.FormatResult(result); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEvaluate_ComplexModeDisabled(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+
+	_, err := engine.Evaluate("sqrt(-4)")
+	if err == nil {
+		t.Fatal("expected error for sqrt of negative number without complex mode")
+	}
+	if !test.ContainsString(err.Error(), "square root of negative number") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Explicit complex literals remain available
+	result, err := engine.Evaluate("sqrt(-4 + 0i)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.String() != "2i" {
+		t.Errorf("expected 2i, got %s", result.String())
+	}
+}
+
+func TestEvaluate_ComplexErrors(t *testing.T) {
+	engine := calculation.NewCalculationEngine(calculation.WithComplexMode(true))
+
+	tests := []struct {
+		name       string
+		expression string
+		errorMsg   string
+	}{
+		{name: "complex division by zero", expression: "(1+i) / (0i)", errorMsg: "division by zero"},
+		{name: "unknown function", expression: "foo(1)", errorMsg: "unknown function"},
+		{name: "wrong arity", expression: "re(1, 2)", errorMsg: "expects 1 argument"},
+		{name: "missing parenthesis", expression: "(3+4i", errorMsg: "missing closing parenthesis"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := engine.Evaluate(tt.expression)
+			if err == nil {
+				t.Fatalf("expected error containing '%s', got nil", tt.errorMsg)
+			}
+			if !test.ContainsString(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error containing '%s', got '%s'", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestEvaluate_PowerOverflow(t *testing.T) {
+	// Without limits, powers that would overflow big.Float fail instead of
+	// producing infinities that later panic
+	engine := calculation.NewCalculationEngine(calculation.WithComplexMode(true), calculation.WithLimits(calculation.Limits{}))
+
+	tests := []struct {
+		expression string
+		errorMsg   string
+	}{
+		{expression: "(10^9999 + 10^9999*i)^1000000", errorMsg: "result overflow"},
+		{expression: "(10^700*i)^1000000 - (10^700*i)^1000000", errorMsg: "result overflow"},
+		{expression: "(10^700 * 1 km)^1000000", errorMsg: "result overflow"},
+		{expression: "(1 km^1000)^1000000", errorMsg: "result overflow"},
+		{expression: "1 km^1000000000", errorMsg: "unit km^1000000000 is too large"},
+		{expression: "(0 m)^-1", errorMsg: "division by zero"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := engine.Evaluate(tt.expression)
+			if err == nil || !test.ContainsString(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error containing %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+
+	result, err := engine.Evaluate("(1+i)^-2")
+	if err != nil || result.String() != "-0.5i" {
+		t.Errorf("expected -0.5i, got %v (%v)", result, err)
+	}
+}
+
+func TestComplex_PolarForm(t *testing.T) {
+	engine := calculation.NewCalculationEngine(
+		calculation.WithComplexMode(true),
+		calculation.WithComplexForm(calculation.ComplexPolar),
+	)
+
+	result, err := engine.Evaluate("3+4i")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Kind() != "complex" {
+		t.Fatalf("expected complex result, got %s", result.Kind())
+	}
+
+	expected := "5∠0.927295218001612"
+	if got := engine.FormatResult(result); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
@@ -0,0 +1,149 @@
+package calculation_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"calculator/internal/calculation"
+	"calculator/test"
+)
+
+const ratesJSON = `{
+  "base": "EUR",
+  "date": "2026-10-15",
+  "rates": {"USD": 1.0842, "GBP": "0.8571", "JPY": 162.35}
+}`
+
+const ratesCSV = `date,base,currency,rate
+2026-10-15,EUR,USD,1.0842
+2026-10-15,EUR,GBP,0.8571
+2026-10-15,EUR,JPY,162.35
+`
+
+// writeRates stores a rate table fixture in a temporary directory
+func writeRates(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write rates fixture: %v", err)
+	}
+	return path
+}
+
+func TestLoadRateTable(t *testing.T) {
+	for _, fixture := range []struct{ name, content string }{
+		{"rates.json", ratesJSON},
+		{"rates.csv", ratesCSV},
+	} {
+		t.Run(fixture.name, func(t *testing.T) {
+			table, err := calculation.LoadRateTable(writeRates(t, fixture.name, fixture.content))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if table.Base != "EUR" || table.Date != "2026-10-15" {
+				t.Errorf("unexpected base/date: %s %s", table.Base, table.Date)
+			}
+			if got := table.Rates["GBP"].FloatString(4); got != "0.8571" {
+				t.Errorf("expected GBP rate 0.8571, got %s", got)
+			}
+			if got := table.Rates["EUR"].FloatString(0); got != "1" {
+				t.Errorf("expected implicit base rate 1, got %s", got)
+			}
+		})
+	}
+}
+
+func TestLoadRateTable_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		errorMsg string
+	}{
+		{name: "unsupported extension", file: "rates.txt", content: "", errorMsg: "unsupported rate table format"},
+		{name: "missing date", file: "rates.json", content: `{"base": "EUR", "rates": {"USD": 1.1}}`, errorMsg: "missing rate date"},
+		{name: "negative rate", file: "rates.json", content: `{"base": "EUR", "date": "2026-10-15", "rates": {"USD": -1}}`, errorMsg: "invalid rate for USD"},
+		{name: "mixed dates", file: "rates.csv", content: "date,base,currency,rate\n2026-10-15,EUR,USD,1.1\n2026-10-16,EUR,GBP,0.9\n", errorMsg: "same date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := calculation.LoadRateTable(writeRates(t, tt.file, tt.content))
+			if err == nil {
+				t.Fatalf("expected error containing '%s', got nil", tt.errorMsg)
+			}
+			if !test.ContainsString(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error containing '%s', got '%s'", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestEvaluate_Currency(t *testing.T) {
+	table, err := calculation.LoadRateTable(writeRates(t, "rates.json", ratesJSON))
+	if err != nil {
+		t.Fatalf("failed to load rates: %v", err)
+	}
+	engine := calculation.NewCalculationEngine(calculation.WithRateTable(table))
+
+	tests := []struct {
+		name       string
+		expression string
+		expected   string
+	}{
+		{name: "literal uses minor units", expression: "120 EUR", expected: "120.00 EUR"},
+		{name: "same currency addition is exact", expression: "0.1 USD + 0.2 USD", expected: "0.30 USD"},
+		{name: "mixed currencies converted to target", expression: "120 EUR + 30 USD in GBP", expected: "126.57 GBP (rates as of 2026-10-15)"},
+		{name: "conversion to zero minor units", expression: "10 EUR to JPY", expected: "1624 JPY (rates as of 2026-10-15)"},
+		{name: "scaling by a number", expression: "19.99 USD * 3", expected: "59.97 USD"},
+		{name: "splitting a bill", expression: "100 EUR / 3", expected: "33.33 EUR"},
+		{name: "ratio of amounts", expression: "50 EUR / 200 EUR", expected: "0.25"},
+		{name: "negative amount", expression: "-(12.5 GBP)", expected: "-12.50 GBP"},
+		{name: "conversion to same currency", expression: "5 EUR in EUR", expected: "5.00 EUR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := engine.Evaluate(tt.expression)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := result.String(); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEvaluate_CurrencyErrors(t *testing.T) {
+	table, err := calculation.LoadRateTable(writeRates(t, "rates.json", ratesJSON))
+	if err != nil {
+		t.Fatalf("failed to load rates: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		engine     *calculation.CalculationEngine
+		expression string
+		errorMsg   string
+	}{
+		{name: "no rate table", engine: calculation.NewCalculationEngine(), expression: "1 EUR in USD", errorMsg: "no exchange rate table loaded"},
+		{name: "missing rate", engine: calculation.NewCalculationEngine(calculation.WithRateTable(table)), expression: "1 EUR in CHF", errorMsg: "no exchange rate for CHF"},
+		{name: "plain number addition", engine: calculation.NewCalculationEngine(), expression: "1 EUR + 1", errorMsg: "cannot add a plain number and EUR"},
+		{name: "money times money", engine: calculation.NewCalculationEngine(), expression: "1 EUR * 1 EUR", errorMsg: "unsupported operator"},
+		{name: "currency to unit", engine: calculation.NewCalculationEngine(), expression: "1 EUR to km", errorMsg: "non-currency unit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.engine.Evaluate(tt.expression)
+			if err == nil {
+				t.Fatalf("expected error containing '%s', got nil", tt.errorMsg)
+			}
+			if !test.ContainsString(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error containing '%s', got '%s'", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package calculation_test
+
+import (
+	"testing"
+	"time"
+
+	"calculator/internal/calculation"
+	"calculator/test"
+)
+
+func newDateEngine() *calculation.CalculationEngine {
+	fixed := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	return calculation.NewCalculationEngine(
+		calculation.WithLocation(time.UTC),
+		calculation.WithClock(func() time.Time { return fixed }),
+	)
+}
+
+func TestEvaluate_DateTime(t *testing.T) {
+	engine := newDateEngine()
+
+	tests := []struct {
+		name       string
+		expression string
+		expected   string
+		kind       string
+	}{
+		{name: "date plus days", expression: "2026-10-17 + 90 days", expected: "2027-01-15", kind: "datetime"},
+		{name: "date minus duration", expression: "2026-03-01 - 1 day", expected: "2026-02-28", kind: "datetime"},
+		{name: "fractional days", expression: "2026-10-17 + 1.5 days", expected: "2026-10-18 12:00 UTC", kind: "datetime"},
+		{name: "date with time", expression: "2026-10-17 14:30 + 2h", expected: "2026-10-17 16:30 UTC", kind: "datetime"},
+		{name: "elapsed weeks", expression: "now - 2026-01-01 in weeks", expected: "41.5714285714286 weeks", kind: "quantity"},
+		{name: "days until date", expression: "2026-12-25 - today", expected: "67d", kind: "duration"},
+		{name: "duration times number", expression: "3h25m * 4", expected: "13h40m", kind: "duration"},
+		{name: "duration ratio", expression: "3h25m / 25 min", expected: "8.2", kind: "number"},
+		{name: "duration in minutes", expression: "1h30m in min", expected: "90 min", kind: "quantity"},
+		{name: "negative duration", expression: "-(1h30m)", expected: "-1h30m", kind: "duration"},
+		{name: "time plus minutes", expression: "17:30 + 45min", expected: "18:15", kind: "time"},
+		{name: "time wraps midnight", expression: "23:30 + 2h", expected: "01:30", kind: "time"},
+		{name: "time difference", expression: "17:30 - 08:00", expected: "9h30m", kind: "duration"},
+		{name: "time zone conversion", expression: "2026-10-17 14:30 in America/New_York", expected: "2026-10-17 10:30 EDT", kind: "datetime"},
+		{name: "explicit offset", expression: "2026-10-17T14:30+02:00 in UTC", expected: "2026-10-17 12:30 UTC", kind: "datetime"},
+		{name: "weekday", expression: "weekday(2026-10-17)", expected: "Saturday", kind: "text"},
+		{name: "workdays in month", expression: "workdays(2026-10-01, 2026-10-31)", expected: "22", kind: "number"},
+		{name: "add workdays over weekend", expression: "addworkdays(2026-10-16, 1)", expected: "2026-10-19", kind: "datetime"},
+		{name: "time in conditional", expression: "1>0 ? 17:30 : 09:00", expected: "17:30", kind: "time"},
+		{name: "time in conditional without spaces", expression: "1>0?17:30:00", expected: "17:30", kind: "time"},
+		{name: "time then seconds in conditional", expression: "1<0?17:30:00:5", expected: "5", kind: "number"},
+		{name: "time arithmetic in conditional", expression: "1>0 ? 17:30 + 1h : 0", expected: "18:30", kind: "time"},
+		{name: "numbers in conditional", expression: "1>0?17:30", expected: "17", kind: "number"},
+		{name: "nested conditional times", expression: "1>0 ? 1<0 ? 5:30 : 6:30 : 7:30", expected: "06:30", kind: "time"},
+		{name: "parenthesized time in conditional", expression: "(1>0?(17:30):0)", expected: "17:30", kind: "time"},
+		{name: "add months clamps", expression: "addmonths(2026-01-31, 1)", expected: "2026-02-28", kind: "datetime"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := engine.Evaluate(tt.expression)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := result.String(); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+			if result.Kind() != tt.kind {
+				t.Errorf("expected kind %s, got %s", tt.kind, result.Kind())
+			}
+		})
+	}
+}
+
+func TestEvaluate_DateTimeErrors(t *testing.T) {
+	engine := newDateEngine()
+
+	tests := []struct {
+		name       string
+		expression string
+		errorMsg   string
+	}{
+		{name: "invalid calendar date", expression: "2026-02-30", errorMsg: "invalid date"},
+		{name: "unknown time zone", expression: "2026-10-17 in Mars/Base", errorMsg: "unknown time zone"},
+		{name: "multiplying a date", expression: "2026-10-17 * 2", errorMsg: "cannot apply *"},
+		{name: "adding two dates", expression: "2026-10-17 + 2026-10-18", errorMsg: "cannot apply +"},
+		{name: "adding a length", expression: "17:30 + 5 m", errorMsg: "cannot apply +"},
+		{name: "weekday of a number", expression: "weekday(5)", errorMsg: "expected a date"},
+		{name: "fractional months", expression: "addmonths(2026-10-17, 1.5)", errorMsg: "whole number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := engine.Evaluate(tt.expression)
+			if err == nil {
+				t.Fatalf("expected error containing '%s', got nil", tt.errorMsg)
+			}
+			if !test.ContainsString(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error containing '%s', got '%s'", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
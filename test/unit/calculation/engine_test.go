@@ -118,6 +118,18 @@ func TestCalculationEngine_Calculate(t *testing.T) {
 			expectError: true,
 			errorMsg:    "unsupported operator",
 		},
+		{
+			name:        "comparison operator",
+			expression:  "5 < 2",
+			expectError: true,
+			errorMsg:    "unsupported operator: <",
+		},
+		{
+			name:        "logical operator",
+			expression:  "5 && 2",
+			expectError: true,
+			errorMsg:    "unsupported operator: &&",
+		},
 		{
 			name:        "invalid number",
 			expression:  "abc + 2",
@@ -197,6 +209,18 @@ func TestCalculationEngine_Validate(t *testing.T) {
 			expectError: true,
 			errorMsg:    "unsupported operator",
 		},
+		{
+			name:        "comparison operator",
+			expression:  "5 < 2",
+			expectError: true,
+			errorMsg:    "unsupported operator: <",
+		},
+		{
+			name:        "logical operator",
+			expression:  "5 && 2",
+			expectError: true,
+			errorMsg:    "unsupported operator: &&",
+		},
 		{
 			name:        "invalid number",
 			expression:  "abc + 2",
@@ -241,7 +265,7 @@ func TestCalculationEngine_GetSupportedOperations(t *testing.T) {
 
 	operations := engine.GetSupportedOperations()
 
-	expected := []string{"+", "-", "*", "/"}
+	expected := []string{"+", "-", "*", "/", "^", "%"}
 
 	if len(operations) != len(expected) {
 		t.Errorf("expected %d operations, got %d", len(expected), len(operations))
@@ -253,3 +277,34 @@ func TestCalculationEngine_GetSupportedOperations(t *testing.T) {
 		}
 	}
 }
+
+func TestCalculationEngine_CalculatePercent(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       calculation.PercentMode
+		expression string
+		expected   float64
+	}{
+		{name: "desk percent of product", mode: calculation.PercentDesk, expression: "200 * 15%", expected: 30},
+		{name: "desk markup", mode: calculation.PercentDesk, expression: "80 + 10%", expected: 88},
+		{name: "desk discount", mode: calculation.PercentDesk, expression: "50 - 20%", expected: 40},
+		{name: "desk division", mode: calculation.PercentDesk, expression: "60 / 20%", expected: 300},
+		{name: "math addition", mode: calculation.PercentMath, expression: "80 + 10%", expected: 80.1},
+		{name: "math subtraction", mode: calculation.PercentMath, expression: "50 - 20%", expected: 49.8},
+		{name: "math product", mode: calculation.PercentMath, expression: "200 * 15%", expected: 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := calculation.NewCalculationEngine(calculation.WithPercentMode(tt.mode))
+
+			result, err := engine.Calculate(tt.expression)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !test.AlmostEqual(result, tt.expected, 1e-10) {
+				t.Errorf("expected %f, got %f", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package calculation_test
+
+import (
+	"testing"
+
+	"calculator/internal/calculation"
+	"calculator/test"
+)
+
+func TestCalculationEngine_Evaluate(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+
+	tests := []struct {
+		name        string
+		expression  string
+		expected    string
+		expectError bool
+		errorMsg    string
+	}{
+		{name: "simple addition", expression: "2 + 3", expected: "5"},
+		{name: "no whitespace", expression: "2+3*4", expected: "14"},
+		{name: "parentheses", expression: "(2 + 3) * 4", expected: "20"},
+		{name: "left associative subtraction", expression: "10 - 4 - 3", expected: "3"},
+		{name: "unary minus", expression: "-(2 + 3)", expected: "-5"},
+		{name: "decimal precision", expression: "0.1 + 0.2", expected: "0.3"},
+		{name: "repeating fraction", expression: "1 / 3", expected: "0.333333333333333"},
+		{name: "large result", expression: "123456789 * 1000000", expected: "123456789000000"},
+		{name: "tiny result", expression: "1 / 1000000000", expected: "1e-09"},
+		{name: "empty expression", expression: "  ", expectError: true, errorMsg: "expression cannot be empty"},
+		{name: "division by zero", expression: "1 / (2 - 2)", expectError: true, errorMsg: "division by zero"},
+		{name: "dangling operator", expression: "2 +", expectError: true, errorMsg: "unexpected end of expression"},
+		{name: "invalid character", expression: "2 $ 3", expectError: true, errorMsg: "unexpected character"},
+		{name: "trailing decimal point", expression: "2. + 3", expectError: true, errorMsg: "invalid number format"},
+		{name: "unknown identifier", expression: "x + 1", expectError: true, errorMsg: "unknown identifier"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := engine.Evaluate(tt.expression)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error containing '%s', got nil", tt.errorMsg)
+				} else if !test.ContainsString(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing '%s', got '%s'", tt.errorMsg, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := engine.FormatResult(result); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCalculationEngine_EvaluateWithVariables(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+	price, err := engine.Evaluate("12.5")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	distance, err := engine.Evaluate("3 km")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	vars := map[string]calculation.Value{"price": price, "distance": distance}
+
+	tests := []struct {
+		expression string
+		expected   string
+	}{
+		{expression: "price * 4", expected: "50"},
+		{expression: "distance + 500 m", expected: "3.5 km"},
+		{expression: "sqrt(price * 2) + pi - pi", expected: "5"},
+	}
+	for _, tt := range tests {
+		result, err := engine.EvaluateWithVariables(tt.expression, vars)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.expression, err)
+			continue
+		}
+		if got := engine.FormatResult(result); got != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.expression, tt.expected, got)
+		}
+	}
+
+	if _, err := engine.EvaluateWithVariables("qty * 2", vars); err == nil || !test.ContainsString(err.Error(), "unknown identifier: qty") {
+		t.Errorf("expected unknown identifier error, got %v", err)
+	}
+}
+
+func TestValidateVariableName(t *testing.T) {
+	for _, name := range []string{"x", "total_2", "_tmp"} {
+		if err := calculation.ValidateVariableName(name); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+	for name, msg := range map[string]string{"2x": "invalid variable name", "a-b": "invalid variable name", "pi": "built-in name", "to": "built-in name"} {
+		if err := calculation.ValidateVariableName(name); err == nil || !test.ContainsString(err.Error(), msg) {
+			t.Errorf("%s: expected error containing %q, got %v", name, msg, err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package calculation_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"calculator/internal/calculation"
+)
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits calculation.Limits
+		expr   string
+		limit  string
+	}{
+		{name: "expression length", limits: calculation.Limits{MaxExpressionLength: 10}, expr: "1 + 2 + 3 + 4", limit: calculation.LimitExpressionLength},
+		{name: "nesting depth", limits: calculation.Limits{MaxDepth: 5}, expr: "-(-(-(-(-(-1)))))", limit: calculation.LimitDepth},
+		{name: "nested calls", limits: calculation.Limits{MaxDepth: 3}, expr: "abs(abs(abs(abs(1))))", limit: calculation.LimitDepth},
+		{name: "exponent", limits: calculation.DefaultLimits(), expr: "9 ^ 9 ^ 9", limit: calculation.LimitExponent},
+		{name: "negative exponent", limits: calculation.Limits{MaxExponent: 100}, expr: "2 ^ -101", limit: calculation.LimitExponent},
+		{name: "result digits", limits: calculation.DefaultLimits(), expr: "2 ^ 34000", limit: calculation.LimitDigits},
+		{name: "repeated squaring", limits: calculation.Limits{MaxDigits: 50}, expr: "(10 ^ 30) * (10 ^ 30)", limit: calculation.LimitDigits},
+		{name: "complex power overflow", limits: calculation.DefaultLimits(), expr: "(10^700*i)^1000000", limit: calculation.LimitDigits},
+		{name: "quantity power overflow", limits: calculation.DefaultLimits(), expr: "(10^700 * 1 km)^1000000", limit: calculation.LimitDigits},
+		{name: "difference of overflows", limits: calculation.DefaultLimits(), expr: "(10^700*i)^1000000 - (10^700*i)^1000000", limit: calculation.LimitDigits},
+		{name: "real power overflow", limits: calculation.DefaultLimits(), expr: "(10^700)^1000000", limit: calculation.LimitDigits},
+		{name: "money digits", limits: calculation.DefaultLimits(), expr: "1 EUR * 10^6000 * 10^6000", limit: calculation.LimitDigits},
+		{name: "money fraction digits", limits: calculation.DefaultLimits(), expr: "1 EUR / 10^6000 / 10^6000", limit: calculation.LimitDigits},
+		{name: "conversion digits", limits: calculation.DefaultLimits(), expr: "1 km^1000000 to m^1000000", limit: calculation.LimitDigits},
+		{name: "conversion fraction digits", limits: calculation.DefaultLimits(), expr: "(1 m)^1000000 to km^1000000", limit: calculation.LimitDigits},
+		{name: "evaluation steps", limits: calculation.Limits{MaxSteps: 5}, expr: "1 + 2 + 3 + 4 + 5", limit: calculation.LimitSteps},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := calculation.NewCalculationEngine(calculation.WithLimits(tt.limits))
+			_, err := engine.Evaluate(tt.expr)
+			var limitErr *calculation.LimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("expected *LimitError, got %v", err)
+			}
+			if limitErr.Limit != tt.limit {
+				t.Errorf("expected %s limit, got %s", tt.limit, limitErr.Limit)
+			}
+		})
+	}
+}
+
+func TestLimits_WithinBounds(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+	for _, expr := range []string{"2 ^ 1000", "0.5 ^ 1000", "sqrt(16) * (1 + (2 + (3 + 4)))", "10 ^ -300"} {
+		if _, err := engine.Evaluate(expr); err != nil {
+			t.Errorf("%s: unexpected error: %v", expr, err)
+		}
+	}
+
+	unlimited := calculation.NewCalculationEngine(calculation.WithLimits(calculation.Limits{}))
+	if _, err := unlimited.Evaluate("2 ^ 40000"); err != nil {
+		t.Errorf("expected zero limits to disable checks, got %v", err)
+	}
+}
+
+func TestLimits_UnlimitedFormatting(t *testing.T) {
+	engine := calculation.NewCalculationEngine(calculation.WithLimits(calculation.Limits{}))
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"2 ^ 40000", "1.58426037257308e+12041"},
+		{"2 ^ -40000", "6.31209375246727e-12042"},
+		{"1 km^1000000 to m^1000000", "1e+3000000 m^1000000"},
+		{"(1 m)^1000000 to km^1000000", "1e-3000000 km^1000000"},
+	}
+
+	for _, tt := range tests {
+		start := time.Now()
+		v, err := engine.Evaluate(tt.expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.expr, err)
+			continue
+		}
+		if got := engine.FormatResult(v); got != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.expr, tt.expected, got)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("%s: formatting took %v", tt.expr, elapsed)
+		}
+	}
+}
+
+func TestLimits_CheckSyntax(t *testing.T) {
+	engine := calculation.NewCalculationEngine(calculation.WithLimits(calculation.Limits{MaxExpressionLength: 8}))
+	var limitErr *calculation.LimitError
+	if err := engine.CheckSyntax("123456789"); !errors.As(err, &limitErr) {
+		t.Errorf("expected *LimitError from CheckSyntax, got %v", err)
+	}
+	if _, err := engine.Compile("123456789"); !errors.As(err, &limitErr) {
+		t.Errorf("expected *LimitError from Compile, got %v", err)
+	}
+}
+
+func TestLimits_RPN(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+	err := engine.NewRPNStack().Eval("10^6000 dup *")
+	var limitErr *calculation.LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != calculation.LimitDigits {
+		t.Errorf("expected digits limit, got %v", err)
+	}
+}
+
+func TestLimitError_Message(t *testing.T) {
+	err := &calculation.LimitError{Limit: calculation.LimitDepth, Max: 100}
+	if err.Error() != "nesting depth limit exceeded (maximum 100)" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+}
+
+func TestCalculateContext(t *testing.T) {
+	engine := calculation.NewCalculationEngine(calculation.WithLimits(calculation.Limits{}))
+
+	v, err := engine.CalculateContext(context.Background(), "2 + 3")
+	if err != nil || engine.FormatResult(v) != "5" {
+		t.Fatalf("expected 5, got %v (%v)", v, err)
+	}
+
+	// A long chain of additions takes enough steps to notice cancellation
+	expr := strings.Repeat("1 + ", 5000) + "1"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := engine.CalculateContext(ctx, expr); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	if _, err := engine.CalculateContext(ctx, expr); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
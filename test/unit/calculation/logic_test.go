@@ -0,0 +1,132 @@
+package calculation_test
+
+import (
+	"testing"
+
+	"calculator/internal/calculation"
+	"calculator/test"
+)
+
+func TestComparisonOperators(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"2 < 3", "true"},
+		{"3 <= 3", "true"},
+		{"2 > 3", "false"},
+		{"3 >= 4", "false"},
+		{"0.1 + 0.2 == 0.3", "true"},
+		{"1 / 3 != 0.333", "true"},
+		{"1 + 2 == 3 && 2 * 2 == 4", "true"},
+		{"1 > 2 || 2 > 1", "true"},
+		{"!(1 > 2)", "true"},
+		{"!true || false", "false"},
+		{"true == true", "true"},
+		{"1 km > 900 m", "true"},
+		{"60 min == 1 h", "true"},
+		{"2026-10-17 < 2026-10-18", "true"},
+		{"3h25m > 200 min", "true"},
+		{"10% < 20%", "true"},
+		{"2 + 3i == 2 + 3i", "true"},
+		{"2 == 2 + 0i", "true"},
+		{"0.1 * 3 ~= 0.3", "true"},
+		{"1 / 3 ~= 0.3333333333", "true"},
+		{"1 / 3 ~= 0.333", "false"},
+		{"0 ~= 0.0000000000001", "true"},
+		{"1 km ~= 1000.0000001 m", "true"},
+		{"1 km ~= 1001 m", "false"},
+		{"19.99 EUR ~= 19.99 EUR", "true"},
+		{"1 + 2i ~= 1 + 2.0000000001i", "true"},
+		{"true ~= true", "true"},
+		// Comparisons bind more loosely than arithmetic, && more loosely than
+		// comparisons and || most loosely
+		{"1 + 1 == 2", "true"},
+		{"true || false && false", "true"},
+		// The right operand is skipped once the left one decides the result
+		{"false && 1 / 0 > 1", "false"},
+		{"true || 1 / 0 > 1", "true"},
+	}
+
+	engine := calculation.NewCalculationEngine()
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := engine.Evaluate(tt.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Kind() != "boolean" || result.String() != tt.expected {
+				t.Errorf("expected %s, got %s (%s)", tt.expected, result, result.Kind())
+			}
+		})
+	}
+}
+
+func TestComparisonOperators_Errors(t *testing.T) {
+	tests := []struct {
+		expr     string
+		errorMsg string
+	}{
+		{"1 < 2 < 3", "cannot compare boolean and number with <"},
+		{"2i < 3i", "cannot compare complex and complex with <"},
+		{"1 km < 1 kg", "cannot compare quantity and quantity"},
+		{"80 > 10%", "cannot compare number and percent"},
+		{"true < false", "cannot compare boolean and boolean"},
+		{"1 && true", "expected true or false, got number"},
+		{"!1", "expected true or false, got number"},
+		{"true + 1", "boolean"},
+		{"2 = 3", `unexpected "="`},
+		{"10% ~= 0.1", "cannot compare percent and number with ~="},
+		{"1 km ~= 1 kg", "cannot compare quantity and quantity with ~="},
+	}
+
+	engine := calculation.NewCalculationEngine()
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := engine.Evaluate(tt.expr)
+			if err == nil || !test.ContainsString(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error containing %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestBooleanVariables(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+	vars := map[string]calculation.Value{"paid": calculation.Bool{Value: true}}
+	result, err := engine.EvaluateWithVariables("paid && !false", vars)
+	if err != nil || result.String() != "true" {
+		t.Errorf("expected true, got %v (%v)", result, err)
+	}
+	if err := calculation.ValidateVariableName("true"); err == nil {
+		t.Error("expected true to be a reserved name")
+	}
+}
+
+func TestApproxEqual_Tolerance(t *testing.T) {
+	tests := []struct {
+		tolerance float64
+		expr      string
+		expected  string
+	}{
+		{0.01, "100 ~= 100.9", "true"},
+		{0.01, "100 ~= 101.5", "false"},
+		// Near zero the tolerance applies as an absolute difference
+		{0.01, "0 ~= 0.005", "true"},
+		{0, "0.1 + 0.2 ~= 0.3", "true"},
+		{0, "1 ~= 1.000000001", "false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			engine := calculation.NewCalculationEngine(calculation.WithTolerance(tt.tolerance))
+			result, err := engine.Evaluate(tt.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.String() != tt.expected {
+				t.Errorf("expected %s with tolerance %v, got %s", tt.expected, tt.tolerance, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package calculation_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"calculator/internal/calculation"
+	"calculator/test"
+)
+
+func TestMemory_Registers(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+	value := func(expr string) calculation.Value {
+		v, err := engine.Evaluate(expr)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", expr, err)
+		}
+		return v
+	}
+
+	memory := calculation.NewMemory()
+	if got := memory.Recall("").String(); got != "0" {
+		t.Errorf("expected cleared memory to recall 0, got %s", got)
+	}
+
+	steps := []struct {
+		apply    func() error
+		register string
+		expected string
+	}{
+		{apply: func() error { return memory.Add("", value("12.5")) }, register: "M", expected: "12.5"},
+		{apply: func() error { return memory.Add("", value("0.1")) }, register: "M", expected: "12.6"},
+		{apply: func() error { return memory.Subtract("", value("2.6")) }, register: "M", expected: "10"},
+		{apply: func() error { return memory.Store("M1", value("1/3")) }, register: "M1", expected: "0.333333333333333"},
+		{apply: func() error { return memory.Add("tax", value("0.2")) }, register: "tax", expected: "0.2"},
+	}
+	for i, step := range steps {
+		if err := step.apply(); err != nil {
+			t.Fatalf("step %d: unexpected error: %v", i+1, err)
+		}
+		if got := memory.Recall(step.register).String(); got != step.expected {
+			t.Errorf("step %d: expected %s = %s, got %s", i+1, step.register, step.expected, got)
+		}
+	}
+
+	result, err := engine.EvaluateWithVariables("M * 3 + M1 * 3 + tax", memory.Variables())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := result.String(); got != "31.2" {
+		t.Errorf("expected registers usable in expressions, got %s", got)
+	}
+
+	clone := memory.Clone()
+	memory.Clear("M1")
+	if names := memory.Names(); len(names) != 2 || names[0] != "M" || names[1] != "tax" {
+		t.Errorf("expected M and tax after clearing M1, got %v", names)
+	}
+	if len(clone.Names()) != 3 {
+		t.Error("expected clear to leave the clone untouched")
+	}
+	memory.ClearAll()
+	if len(memory.Names()) != 0 {
+		t.Error("expected ClearAll to empty every register")
+	}
+
+	if err := memory.Add("", value("5 km")); err == nil || !test.ContainsString(err.Error(), "plain numbers") {
+		t.Errorf("expected non-number to be rejected, got %v", err)
+	}
+	if err := memory.Store("pi", value("1")); err == nil || !test.ContainsString(err.Error(), "built-in name") {
+		t.Errorf("expected built-in register name to be rejected, got %v", err)
+	}
+	for _, name := range []string{"km", "m", "h", "USD", "EUR"} {
+		if err := memory.Store(name, value("1")); err == nil || !test.ContainsString(err.Error(), "invalid memory register") {
+			t.Errorf("expected unit or currency register %s to be rejected, got %v", name, err)
+		}
+	}
+	if err := memory.Store("usd", value("1")); err != nil {
+		t.Errorf("expected a lowercase name, which is not a currency code, to be accepted: %v", err)
+	}
+}
+
+func TestMemory_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calculator", "memory.json")
+
+	memory, err := calculation.LoadMemory(path)
+	if err != nil || len(memory.Names()) != 0 {
+		t.Fatalf("expected a missing file to give empty memory, got %v, %v", memory.Names(), err)
+	}
+
+	third, _ := calculation.NewCalculationEngine().Evaluate("1/3")
+	if err := memory.Store("M2", third); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := memory.Save(path); err != nil {
+		t.Fatalf("unexpected save error: %v", err)
+	}
+
+	loaded, err := calculation.LoadMemory(path)
+	if err != nil {
+		t.Fatalf("unexpected load error: %v", err)
+	}
+	got := loaded.Recall("M2").(calculation.Number)
+	want := third.(calculation.Number)
+	if got.Value.Cmp(want.Value) != 0 {
+		t.Errorf("expected full precision to survive a round trip, got %s want %s", got.Value.Text('g', 40), want.Value.Text('g', 40))
+	}
+
+	if err := os.WriteFile(path, []byte(`{"registers": {"M": "abc"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := calculation.LoadMemory(path); err == nil || !test.ContainsString(err.Error(), "invalid number") {
+		t.Errorf("expected invalid memory file error, got %v", err)
+	}
+}
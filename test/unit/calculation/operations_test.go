@@ -1,6 +1,7 @@
 package calculation_test
 
 import (
+	"errors"
 	"math/big"
 	"testing"
 
@@ -228,3 +229,48 @@ func TestDivide(t *testing.T) {
 		})
 	}
 }
+
+func TestDivisionByZero_Sentinel(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+	for _, expr := range []string{"1 / 0", "0 ^ -1", "0 ^ -0.5", "(1+2i) / 0i", "10 EUR / 0", "5 km / 0", "3h / 0", "pctchange(0, 5)"} {
+		_, err := engine.Evaluate(expr)
+		if !errors.Is(err, calculation.ErrDivisionByZero) {
+			t.Errorf("%s: expected ErrDivisionByZero, got %v", expr, err)
+		}
+	}
+	if err := engine.Validate("10 / 0"); !errors.Is(err, calculation.ErrDivisionByZero) {
+		t.Errorf("expected Validate to report ErrDivisionByZero, got %v", err)
+	}
+}
+
+func TestOperations_InfiniteOperands(t *testing.T) {
+	inf := new(big.Float).SetInf(false)
+	one := big.NewFloat(1)
+	huge := new(big.Float).SetMantExp(one, big.MaxExp-1)
+	operations := []struct {
+		name string
+		fn   func(a, b *big.Float) (*big.Float, error)
+	}{
+		{"add", calculation.Add},
+		{"subtract", calculation.Subtract},
+		{"multiply", calculation.Multiply},
+		{"divide", calculation.Divide},
+	}
+
+	for _, op := range operations {
+		t.Run(op.name, func(t *testing.T) {
+			for _, args := range [][2]*big.Float{{inf, inf}, {inf, one}, {one, inf}} {
+				if _, err := op.fn(args[0], args[1]); err == nil || !test.ContainsString(err.Error(), "overflow") {
+					t.Errorf("%s(%s, %s): expected an overflow error, got %v", op.name, args[0], args[1], err)
+				}
+			}
+		})
+	}
+
+	if _, err := calculation.Multiply(huge, huge); err == nil || !test.ContainsString(err.Error(), "overflow") {
+		t.Errorf("expected an overflowing product to fail, got %v", err)
+	}
+	if _, err := calculation.Add(huge, huge); err == nil || !test.ContainsString(err.Error(), "overflow") {
+		t.Errorf("expected an overflowing sum to fail, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package calculation_test
+
+import (
+	"testing"
+
+	"calculator/internal/calculation"
+	"calculator/test"
+)
+
+func TestEvaluate_Percent(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       calculation.PercentMode
+		expression string
+		expected   string
+	}{
+		{name: "percent literal", mode: calculation.PercentDesk, expression: "15%", expected: "15%"},
+		{name: "desk product", mode: calculation.PercentDesk, expression: "200 * 15%", expected: "30"},
+		{name: "desk markup", mode: calculation.PercentDesk, expression: "80 + 10%", expected: "88"},
+		{name: "desk discount", mode: calculation.PercentDesk, expression: "50 - 20%", expected: "40"},
+		{name: "desk chained", mode: calculation.PercentDesk, expression: "100 + 10% + 10%", expected: "121"},
+		{name: "desk on currency", mode: calculation.PercentDesk, expression: "120 EUR + 20%", expected: "144.00 EUR"},
+		{name: "desk on quantity", mode: calculation.PercentDesk, expression: "2 km - 25%", expected: "1.5 km"},
+		{name: "math addition", mode: calculation.PercentMath, expression: "80 + 10%", expected: "80.1"},
+		{name: "math discount", mode: calculation.PercentMath, expression: "50 - 20%", expected: "49.8"},
+		{name: "percent of", mode: calculation.PercentDesk, expression: "15% of 200", expected: "30"},
+		{name: "percent of currency", mode: calculation.PercentDesk, expression: "15% of 80 USD", expected: "12.00 USD"},
+		{name: "percent of binds tighter than plus", mode: calculation.PercentDesk, expression: "10% of 50 + 5", expected: "10"},
+		{name: "as percent of", mode: calculation.PercentDesk, expression: "30 as % of 200", expected: "15%"},
+		{name: "as percent of quantities", mode: calculation.PercentDesk, expression: "500 m as % of 2 km", expected: "25%"},
+		{name: "percentage increase", mode: calculation.PercentDesk, expression: "pctchange(80, 100)", expected: "25%"},
+		{name: "percentage decrease", mode: calculation.PercentDesk, expression: "pctchange(200, 150)", expected: "-25%"},
+		{name: "adding percentages", mode: calculation.PercentDesk, expression: "10% + 5%", expected: "15%"},
+		{name: "negative percent", mode: calculation.PercentDesk, expression: "-5%", expected: "-5%"},
+		{name: "percent of parenthesised", mode: calculation.PercentDesk, expression: "(10 + 5)% of 40", expected: "6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := calculation.NewCalculationEngine(calculation.WithPercentMode(tt.mode))
+
+			result, err := engine.Evaluate(tt.expression)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := result.String(); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEvaluate_PercentErrors(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+
+	tests := []struct {
+		name       string
+		expression string
+		errorMsg   string
+	}{
+		{name: "of without percentage", expression: "15 of 200", errorMsg: "'of' requires a percentage"},
+		{name: "incomplete as", expression: "30 as 200", errorMsg: "expected '% of' after 'as'"},
+		{name: "percent of quantity", expression: "(5 m)%", errorMsg: "percent applies to plain numbers"},
+		{name: "change from zero", expression: "pctchange(0, 5)", errorMsg: "division by zero"},
+		{name: "binary modulo", expression: "5 % 2", errorMsg: "unexpected"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := engine.Evaluate(tt.expression)
+			if err == nil {
+				t.Fatalf("expected error containing '%s', got nil", tt.errorMsg)
+			}
+			if !test.ContainsString(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error containing '%s', got '%s'", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package calculation_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"calculator/internal/calculation"
+	"calculator/test"
+)
+
+func TestCompile_EvalMany(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+	program, err := engine.Compile("principal * (1 + rate / 12) ^ months - principal")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	number := func(s string) calculation.Value {
+		f, _, err := big.ParseFloat(s, 10, 100, big.ToNearestEven)
+		if err != nil {
+			t.Fatalf("bad test number %s", s)
+		}
+		return calculation.NewNumber(f)
+	}
+
+	tests := []struct {
+		principal, rate, months string
+		expected                string
+	}{
+		{principal: "1000", rate: "0.12", months: "1", expected: "10"},
+		{principal: "1000", rate: "0.12", months: "2", expected: "20.1"},
+		{principal: "500", rate: "0", months: "12", expected: "0"},
+	}
+	for _, tt := range tests {
+		v, err := program.Eval(map[string]calculation.Value{
+			"principal": number(tt.principal),
+			"rate":      number(tt.rate),
+			"months":    number(tt.months),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if v.String() != tt.expected {
+			t.Errorf("principal=%s rate=%s months=%s: expected %s, got %s", tt.principal, tt.rate, tt.months, tt.expected, v)
+		}
+	}
+}
+
+func TestCompile_LiteralsAreShared(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+	program, err := engine.Compile("-2.5 + pi * 0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		v, err := program.Eval(nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if v.String() != "-2.5" {
+			t.Fatalf("evaluation %d: expected -2.5, got %s", i+1, v)
+		}
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+	tests := []struct {
+		expr     string
+		syntax   bool
+		contains string
+	}{
+		{expr: "", syntax: true, contains: "empty"},
+		{expr: "2 +", syntax: true},
+		{expr: "(3", syntax: true},
+		{expr: "foo(2)", contains: "unknown function: foo"},
+		{expr: "sqrt(4, 9)", contains: "sqrt expects 1 argument(s), got 2"},
+		{expr: "5 furlongs", syntax: true, contains: "furlongs"},
+		{expr: "2026-02-30", contains: "invalid date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := engine.Compile(tt.expr)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			var syntax *calculation.SyntaxError
+			if errors.As(err, &syntax) != tt.syntax {
+				t.Errorf("expected syntax error %v, got %T: %v", tt.syntax, err, err)
+			}
+			if tt.contains != "" && !test.ContainsString(err.Error(), tt.contains) {
+				t.Errorf("expected error containing %q, got %v", tt.contains, err)
+			}
+		})
+	}
+
+	// Unknown variables can only be detected when the program is evaluated
+	program, err := engine.Compile("x + 1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := program.Eval(nil); err == nil || !test.ContainsString(err.Error(), "unknown identifier: x") {
+		t.Errorf("expected unknown identifier error, got %v", err)
+	}
+}
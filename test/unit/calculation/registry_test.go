@@ -0,0 +1,227 @@
+package calculation_test
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"calculator/internal/calculation"
+	"calculator/test"
+)
+
+// customRegistry registers a few domain-specific operators and functions the
+// way an embedding program would
+func customRegistry(t *testing.T) *calculation.Registry {
+	t.Helper()
+	r := calculation.NewRegistry()
+
+	integer := func(v calculation.Value) (int64, error) {
+		n, ok := v.(calculation.Number)
+		if !ok || !n.Value.IsInt() {
+			return 0, fmt.Errorf("expected an integer, got %s", v)
+		}
+		i, _ := n.Value.Int64()
+		return i, nil
+	}
+	register := []calculation.Operator{
+		{Symbol: "mod", Precedence: calculation.PrecedenceMultiplicative, Arity: 2,
+			Binary: func(_ *calculation.CalculationEngine, left, right calculation.Value) (calculation.Value, error) {
+				a, err := integer(left)
+				if err != nil {
+					return nil, err
+				}
+				b, err := integer(right)
+				if err != nil {
+					return nil, err
+				}
+				if b == 0 {
+					return nil, fmt.Errorf("division by zero")
+				}
+				return calculation.NewNumber(new(big.Float).SetInt64(a % b)), nil
+			}},
+		{Symbol: "<<", Precedence: calculation.PrecedenceAdditive - 1, Arity: 2,
+			Binary: func(_ *calculation.CalculationEngine, left, right calculation.Value) (calculation.Value, error) {
+				a, err := integer(left)
+				if err != nil {
+					return nil, err
+				}
+				b, err := integer(right)
+				if err != nil {
+					return nil, err
+				}
+				return calculation.NewNumber(new(big.Float).SetInt64(a << b)), nil
+			}},
+		{Symbol: "~", Precedence: calculation.PrecedencePrefix, Arity: 1,
+			Unary: func(_ *calculation.CalculationEngine, operand calculation.Value) (calculation.Value, error) {
+				a, err := integer(operand)
+				if err != nil {
+					return nil, err
+				}
+				return calculation.NewNumber(new(big.Float).SetInt64(^a)), nil
+			}},
+		{Symbol: "->", Precedence: calculation.PrecedenceAdditive - 2, Associativity: calculation.RightAssociative, Arity: 2,
+			Binary: func(ce *calculation.CalculationEngine, left, right calculation.Value) (calculation.Value, error) {
+				// a -> b is a - b, so right associativity is visible
+				return ce.EvaluateWithVariables("a - b", map[string]calculation.Value{"a": left, "b": right})
+			}},
+	}
+	for _, op := range register {
+		if err := r.RegisterOperator(op); err != nil {
+			t.Fatalf("RegisterOperator(%q): %v", op.Symbol, err)
+		}
+	}
+
+	err := r.RegisterFunction(calculation.Function{Name: "nargs", Arity: calculation.Variadic,
+		Call: func(_ *calculation.CalculationEngine, args []calculation.Value) (calculation.Value, error) {
+			return calculation.NewNumber(big.NewFloat(float64(len(args)))), nil
+		}})
+	if err != nil {
+		t.Fatalf("RegisterFunction: %v", err)
+	}
+	return r
+}
+
+func TestRegistry_CustomOperators(t *testing.T) {
+	engine := calculation.NewCalculationEngine(calculation.WithRegistry(customRegistry(t)))
+
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{expr: "17 mod 5", expected: "2"},
+		{expr: "1 + 17 mod 5 * 2", expected: "5"},
+		{expr: "1 << 2 + 1", expected: "8"},
+		{expr: "1<<3", expected: "8"},
+		{expr: "~5 + 1", expected: "-5"},
+		{expr: "-~5", expected: "6"},
+		{expr: "10 -> 4 -> 1", expected: "7"},
+		{expr: "2 ^ 3 ^ 2", expected: "512"},
+		{expr: "-2 ^ 2", expected: "-4"},
+		{expr: "nargs()", expected: "0"},
+		{expr: "nargs(1, 2 km, 3)", expected: "3"},
+		{expr: "sqrt(nargs(1, 2, 3, 4))", expected: "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			v, err := engine.Evaluate(tt.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if v.String() != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, v)
+			}
+		})
+	}
+
+	for _, expr := range []string{"1 < < 3", "17 mod", "2.5 mod 2", "~"} {
+		if _, err := engine.Evaluate(expr); err == nil {
+			t.Errorf("%s: expected an error", expr)
+		}
+	}
+	if result, err := engine.Calculate("17 mod 5"); err != nil || result != 2 {
+		t.Errorf("expected Calculate to use registered operators, got %v (%v)", result, err)
+	}
+}
+
+func TestRegistry_EngineIsolation(t *testing.T) {
+	r := customRegistry(t)
+	custom := calculation.NewCalculationEngine(calculation.WithRegistry(r))
+	builtin := calculation.NewCalculationEngine()
+
+	if _, err := builtin.Evaluate("17 mod 5"); err == nil {
+		t.Error("expected the default engine not to know mod")
+	}
+	if _, err := builtin.Evaluate("1 << 3"); err == nil {
+		t.Error("expected the default engine not to know <<")
+	}
+
+	// WithRegistry takes a copy, so later registrations do not leak in
+	err := r.RegisterOperator(calculation.Operator{Symbol: "plus", Precedence: calculation.PrecedenceAdditive, Arity: 2,
+		Binary: func(_ *calculation.CalculationEngine, left, _ calculation.Value) (calculation.Value, error) {
+			return left, nil
+		}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := custom.Evaluate("1 plus 2"); err == nil {
+		t.Error("expected the engine not to see operators registered after it was created")
+	}
+
+	ops := custom.GetSupportedOperations()
+	expected := []string{"+", "-", "*", "/", "^", "mod", "<<", "->", "%"}
+	if fmt.Sprint(ops) != fmt.Sprint(expected) {
+		t.Errorf("expected operations %v, got %v", expected, ops)
+	}
+	if functions := custom.GetSupportedFunctions(); !test.ContainsString(fmt.Sprint(functions), "nargs") {
+		t.Errorf("expected nargs among %v", functions)
+	}
+}
+
+func TestRegistry_RegistrationErrors(t *testing.T) {
+	binary := func(_ *calculation.CalculationEngine, left, _ calculation.Value) (calculation.Value, error) {
+		return left, nil
+	}
+	call := func(_ *calculation.CalculationEngine, args []calculation.Value) (calculation.Value, error) {
+		return args[0], nil
+	}
+
+	operators := []struct {
+		name     string
+		op       calculation.Operator
+		errorMsg string
+	}{
+		{name: "duplicate", op: calculation.Operator{Symbol: "+", Precedence: 1, Arity: 2, Binary: binary}, errorMsg: "already registered"},
+		{name: "empty symbol", op: calculation.Operator{Symbol: " ", Precedence: 1, Arity: 2, Binary: binary}, errorMsg: "cannot be empty"},
+		{name: "invalid character", op: calculation.Operator{Symbol: "(+)", Precedence: 1, Arity: 2, Binary: binary}, errorMsg: "invalid character"},
+		{name: "keyword", op: calculation.Operator{Symbol: "to", Precedence: 1, Arity: 2, Binary: binary}, errorMsg: "keyword"},
+		{name: "unit", op: calculation.Operator{Symbol: "km", Precedence: 1, Arity: 2, Binary: binary}, errorMsg: "unit or currency"},
+		{name: "precedence", op: calculation.Operator{Symbol: "<>", Arity: 2, Binary: binary}, errorMsg: "precedence"},
+		{name: "missing implementation", op: calculation.Operator{Symbol: "<>", Precedence: 1, Arity: 1, Binary: binary}, errorMsg: "arity"},
+	}
+	for _, tt := range operators {
+		t.Run(tt.name, func(t *testing.T) {
+			err := calculation.NewRegistry().RegisterOperator(tt.op)
+			if err == nil || !test.ContainsString(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error containing %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+
+	functions := []struct {
+		name     string
+		fn       calculation.Function
+		errorMsg string
+	}{
+		{name: "duplicate", fn: calculation.Function{Name: "sqrt", Arity: 1, Call: call}, errorMsg: "already registered"},
+		{name: "invalid name", fn: calculation.Function{Name: "2x", Arity: 1, Call: call}, errorMsg: "invalid"},
+		{name: "constant", fn: calculation.Function{Name: "pi", Arity: 1, Call: call}, errorMsg: "built-in name"},
+		{name: "missing call", fn: calculation.Function{Name: "f", Arity: 1}, errorMsg: "needs a Call"},
+	}
+	for _, tt := range functions {
+		t.Run("function "+tt.name, func(t *testing.T) {
+			err := calculation.NewRegistry().RegisterFunction(tt.fn)
+			if err == nil || !test.ContainsString(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error containing %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestRegistry_Builtins(t *testing.T) {
+	r := calculation.NewRegistry()
+	var infix, prefix []string
+	for _, op := range r.Operators() {
+		if op.Arity == 2 {
+			infix = append(infix, op.Symbol)
+		} else {
+			prefix = append(prefix, op.Symbol)
+		}
+	}
+	if fmt.Sprint(infix) != "[+ - * / ^ == != < <= > >= ~= && ||]" || fmt.Sprint(prefix) != "[+ - !]" {
+		t.Errorf("unexpected built-in operators %v %v", infix, prefix)
+	}
+	if len(r.Functions()) == 0 || r.Functions()[0] != "abs" {
+		t.Errorf("expected sorted built-in functions, got %v", r.Functions())
+	}
+}
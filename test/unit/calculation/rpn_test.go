@@ -0,0 +1,87 @@
+package calculation_test
+
+import (
+	"testing"
+
+	"calculator/internal/calculation"
+	"calculator/test"
+)
+
+func TestCalculationEngine_CalculateRPN(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+
+	tests := []struct {
+		name       string
+		expression string
+		expected   float64
+	}{
+		{name: "addition", expression: "3 4 +", expected: 7},
+		{name: "chained operations", expression: "3 4 + 2 *", expected: 14},
+		{name: "operand order", expression: "10 4 -", expected: 6},
+		{name: "division", expression: "1 3 /", expected: 0.333333333333333},
+		{name: "percentage of base", expression: "200 15 %", expected: 30},
+		{name: "dup squares", expression: "12 dup *", expected: 144},
+		{name: "swap reverses operands", expression: "4 10 swap -", expected: 6},
+		{name: "drop discards top", expression: "5 99 drop", expected: 5},
+		{name: "roll moves top to bottom", expression: "1 2 3 roll - -", expected: 4},
+		{name: "depth pushes count", expression: "7 7 7 depth + + +", expected: 24},
+		{name: "clear then continue", expression: "1 2 clear 9", expected: 9},
+		{name: "negative operand", expression: "-2 3 *", expected: -6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := engine.CalculateRPN(tt.expression)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !test.AlmostEqual(result, tt.expected, 1e-12) {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestCalculationEngine_CalculateRPNErrors(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+
+	tests := []struct {
+		name       string
+		expression string
+		errorMsg   string
+	}{
+		{name: "empty expression", expression: "  ", errorMsg: "expression cannot be empty"},
+		{name: "operator underflow", expression: "3 +", errorMsg: "stack underflow: + needs 2 value(s), stack has 1"},
+		{name: "leftover values", expression: "1 2", errorMsg: "exactly one value on the stack, got 2"},
+		{name: "division by zero", expression: "5 0 /", errorMsg: "division by zero"},
+		{name: "invalid operand", expression: "3 foo +", errorMsg: "invalid RPN token \"foo\""},
+		{name: "non-real result", expression: "5 km", errorMsg: "invalid RPN token"},
+		{name: "swap underflow", expression: "1 swap", errorMsg: "stack underflow"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := engine.CalculateRPN(tt.expression)
+			if err == nil {
+				t.Fatalf("expected error containing '%s', got nil", tt.errorMsg)
+			}
+			if !test.ContainsString(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error containing '%s', got '%s'", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestRPNStack_LineIsAtomic(t *testing.T) {
+	stack := calculation.NewCalculationEngine().NewRPNStack()
+	if err := stack.Eval("1 2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := stack.Eval("3 + + +"); err == nil {
+		t.Fatal("expected stack underflow error, got nil")
+	}
+	if expected := "2: 1\n1: 2"; stack.String() != expected {
+		t.Errorf("expected stack restored to %q, got %q", expected, stack.String())
+	}
+}
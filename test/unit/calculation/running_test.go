@@ -0,0 +1,106 @@
+package calculation_test
+
+import (
+	"testing"
+
+	"calculator/internal/calculation"
+	"calculator/test"
+)
+
+func TestRunningTotal_Apply(t *testing.T) {
+	rt := calculation.NewCalculationEngine().NewRunningTotal()
+
+	steps := []struct {
+		line     string
+		expected float64
+	}{
+		{line: "100", expected: 100},
+		{line: "+ 15", expected: 115},
+		{line: "* 1.2", expected: 138},
+		{line: "/ 4", expected: 34.5},
+		{line: "-4.5", expected: 30},
+		{line: "+ 10%", expected: 33},
+	}
+
+	for _, step := range steps {
+		entry, err := rt.Apply(step.line)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.line, err)
+		}
+		if !test.AlmostEqual(entry.Result, step.expected, 1e-12) {
+			t.Errorf("%s: expected %v, got %v", step.line, step.expected, entry.Result)
+		}
+	}
+	if !test.AlmostEqual(rt.Result(), 33, 1e-12) {
+		t.Errorf("expected running result 33, got %v", rt.Result())
+	}
+}
+
+func TestRunningTotal_SubtotalAndTotal(t *testing.T) {
+	rt := calculation.NewCalculationEngine().NewRunningTotal()
+	for _, line := range []string{"20", "+ 30"} {
+		if _, err := rt.Apply(line); err != nil {
+			t.Fatalf("%s: unexpected error: %v", line, err)
+		}
+	}
+
+	if sub := rt.Subtotal(); sub.Kind != calculation.TapeSubtotal || sub.Result != 50 {
+		t.Errorf("expected subtotal 50, got %+v", sub)
+	}
+	if _, err := rt.Apply("* 2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total := rt.Total(); total.Kind != calculation.TapeTotal || total.Result != 100 {
+		t.Errorf("expected total 100, got %+v", total)
+	}
+	if rt.Result() != 0 {
+		t.Errorf("expected running result reset to 0 after total, got %v", rt.Result())
+	}
+
+	entries := rt.Entries()
+	if len(entries) != 5 {
+		t.Fatalf("expected 5 tape entries, got %d", len(entries))
+	}
+	if entries[2].Operator != "◇" || entries[3].Operator != "*" || entries[3].Operand != "2" {
+		t.Errorf("unexpected tape entries: %+v", entries)
+	}
+
+	expected := "   20               = 20\n+  30               = 50\n◇  subtotal         = 50\n*  2                = 100\n*  total            = 100"
+	if rt.String() != expected {
+		t.Errorf("expected tape:\n%s\ngot:\n%s", expected, rt.String())
+	}
+}
+
+func TestRunningTotal_Errors(t *testing.T) {
+	rt := calculation.NewCalculationEngine().NewRunningTotal()
+	if _, err := rt.Apply("10"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		line     string
+		errorMsg string
+	}{
+		{line: "/ 0", errorMsg: "division by zero"},
+		{line: "+ abc", errorMsg: "invalid second number"},
+		{line: "ten", errorMsg: "invalid number"},
+		{line: "^ 2", errorMsg: "invalid number"},
+		{line: "   ", errorMsg: "expression cannot be empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			_, err := rt.Apply(tt.line)
+			if err == nil {
+				t.Fatalf("expected error containing '%s', got nil", tt.errorMsg)
+			}
+			if !test.ContainsString(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error containing '%s', got '%s'", tt.errorMsg, err.Error())
+			}
+		})
+	}
+
+	if rt.Result() != 10 || len(rt.Entries()) != 1 {
+		t.Errorf("expected failed steps to leave the tape untouched, got result %v with %d entries", rt.Result(), len(rt.Entries()))
+	}
+}
@@ -0,0 +1,225 @@
+package calculation_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"calculator/internal/calculation"
+	"calculator/test"
+)
+
+// runScript compiles and runs a script, returning what it printed
+func runScript(t *testing.T, engine *calculation.CalculationEngine, source string) (string, map[string]calculation.Value, error) {
+	t.Helper()
+	script, err := engine.CompileScript(source)
+	if err != nil {
+		return "", nil, err
+	}
+	var out strings.Builder
+	vars, err := script.Run(context.Background(), &out, nil)
+	return out.String(), vars, err
+}
+
+func TestScript_Run(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			name:     "assignment and print",
+			source:   "x = 2\ny = x ^ 10\nprint \"y is\", y, y / 4",
+			expected: "y is 1024 256\n",
+		},
+		{
+			name:     "if else",
+			source:   "x = 5\nif x > 3 {\n  print \"big\"\n} else {\n  print \"small\"\n}",
+			expected: "big\n",
+		},
+		{
+			name:     "else if on the next line",
+			source:   "x = 0\nif x > 0 {\n  print 1\n}\nelse if x == 0 {\n  print 0\n}\nelse {\n  print -1\n}",
+			expected: "0\n",
+		},
+		{
+			name:     "while",
+			source:   "n = 1; total = 0\nwhile n <= 100 { total = total + n; n = n + 1 }\nprint total",
+			expected: "5050\n",
+		},
+		{
+			name:     "for with step",
+			source:   "for k = 10 to 1 step -3 { print k }",
+			expected: "10\n7\n4\n1\n",
+		},
+		{
+			name:     "for that never runs",
+			source:   "for k = 1 to 0 { print k }\nprint \"done\"",
+			expected: "done\n",
+		},
+		{
+			name:     "nested loops",
+			source:   "for a = 1 to 3 {\n  for b = 1 to a { print a, b }\n}",
+			expected: "1 1\n2 1\n2 2\n3 1\n3 2\n3 3\n",
+		},
+		{
+			name:     "comments, units and parenthesized line breaks",
+			source:   "# distance\nd = (3 km +\n     500 m) # total\nprint d to m",
+			expected: "3500 m\n",
+		},
+		{
+			name:     "empty print",
+			source:   "print",
+			expected: "\n",
+		},
+	}
+
+	engine := calculation.NewCalculationEngine()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, _, err := runScript(t, engine, tt.source)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, out)
+			}
+		})
+	}
+}
+
+func TestScript_Amortization(t *testing.T) {
+	source := `
+principal = 1000
+rate = 0.01
+months = 12
+payment = principal * rate / (1 - (1 + rate) ^ -months)
+balance = principal
+for month = 1 to months {
+	balance = balance * (1 + rate) - payment
+}
+`
+	_, vars, err := runScript(t, calculation.NewCalculationEngine(), source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	payment := vars["payment"].(calculation.Number).Float64()
+	balance := vars["balance"].(calculation.Number).Float64()
+	if !test.AlmostEqual(payment, 88.8487886783417, 1e-9) || !test.AlmostEqual(balance, 0, 1e-9) {
+		t.Errorf("expected the loan to be paid off by 88.85 a month, got payment %v, balance %v", payment, balance)
+	}
+	if month := vars["month"].String(); month != "12" {
+		t.Errorf("expected the loop variable to keep its last value, got %s", month)
+	}
+}
+
+func TestScript_Ans(t *testing.T) {
+	_, vars, err := runScript(t, calculation.NewCalculationEngine(), "2 + 3\nx = ans * 2")
+	if err != nil || vars["x"].String() != "10" {
+		t.Errorf("expected a bare expression to set ans, got %v (%v)", vars["x"], err)
+	}
+}
+
+func TestScript_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		line     int
+		syntax   bool
+		errorMsg string
+	}{
+		{name: "runtime error", source: "x = 1\ny = x / 0", line: 2, errorMsg: "division by zero"},
+		{name: "error inside a loop", source: "for k = 1 to 3 {\n  if k == 2 {\n    print 1 / (k - 2)\n  }\n}", line: 3, errorMsg: "division by zero"},
+		{name: "non-boolean condition", source: "if 1 { print 1 }", line: 1, errorMsg: "expected true or false, got number"},
+		{name: "non-number bounds", source: "for k = true to 3 { }", line: 1, errorMsg: "for loop bounds must be numbers"},
+		{name: "zero step", source: "for k = 1 to 3 step 0 { }", line: 1, errorMsg: "for loop step cannot be zero"},
+		{name: "unknown variable", source: "print\nprint y", line: 2, errorMsg: "unknown identifier: y"},
+		{name: "unknown function", source: "\nx = nope(1)", line: 2, errorMsg: "unknown function: nope"},
+		{name: "reserved name", source: "pi = 3", line: 1, errorMsg: "cannot assign to built-in name"},
+		{name: "incomplete expression", source: "x = 1 +\ny = 2", line: 1, syntax: true, errorMsg: "unexpected end of expression"},
+		{name: "missing brace", source: "while true {\n  print 1\n", line: 3, syntax: true, errorMsg: "missing closing brace"},
+		{name: "missing block", source: "if true print 1", line: 1, syntax: true, errorMsg: "expected { after if"},
+		{name: "stray else", source: "else { }", line: 1, syntax: true, errorMsg: "else without if"},
+		{name: "two expressions on a line", source: "print 1 2", line: 1, syntax: true, errorMsg: `unexpected "2" at position 9`},
+		{name: "unterminated string", source: "x = 1\nprint \"total", line: 2, syntax: true, errorMsg: "unterminated string"},
+		{name: "for without to", source: "for k = 1, 3 { }", line: 1, syntax: true, errorMsg: "expected to after the start of the for loop"},
+	}
+
+	engine := calculation.NewCalculationEngine()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := runScript(t, engine, tt.source)
+			var scriptErr *calculation.ScriptError
+			if !errors.As(err, &scriptErr) {
+				t.Fatalf("expected *ScriptError, got %v", err)
+			}
+			if scriptErr.Line != tt.line || !test.ContainsString(err.Error(), tt.errorMsg) {
+				t.Errorf("expected line %d: %s, got %v", tt.line, tt.errorMsg, err)
+			}
+			var syntaxErr *calculation.SyntaxError
+			if errors.As(err, &syntaxErr) != tt.syntax {
+				t.Errorf("expected syntax error %t, got %v", tt.syntax, err)
+			}
+		})
+	}
+}
+
+func TestScript_Limits(t *testing.T) {
+	engine := calculation.NewCalculationEngine(calculation.WithLimits(calculation.Limits{MaxIterations: 100}))
+	var limitErr *calculation.LimitError
+
+	_, _, err := runScript(t, engine, "while true { }")
+	if !errors.As(err, &limitErr) || limitErr.Limit != calculation.LimitIterations {
+		t.Errorf("expected the iteration limit to stop an endless loop, got %v", err)
+	}
+
+	// The limit counts the iterations of all loops together
+	_, _, err = runScript(t, engine, "for a = 1 to 10 {\n  for b = 1 to 10 { }\n}")
+	if !errors.As(err, &limitErr) {
+		t.Errorf("expected nested loops to share the iteration limit, got %v", err)
+	}
+	if _, _, err := runScript(t, engine, "for a = 1 to 9 {\n  for b = 1 to 10 { }\n}"); err != nil {
+		t.Errorf("expected 99 iterations to be allowed, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	script, err := calculation.NewCalculationEngine().CompileScript("while true { }")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := script.Run(ctx, &strings.Builder{}, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestScript_UnlimitedOverflow(t *testing.T) {
+	engine := calculation.NewCalculationEngine(calculation.WithLimits(calculation.Limits{}))
+	sources := []string{
+		"x = 10^300000\nfor k = 1 to 20 {\n  x = x * x\n}\nprint x - x",
+		"x = 10^300000 * 1 m\nfor k = 1 to 20 {\n  x = x * (x / (1 m))\n}\nprint x - x",
+		"x = 10^300000 * i\nfor k = 1 to 20 {\n  x = x * x\n}\nprint x - x",
+	}
+	for _, source := range sources {
+		_, _, err := runScript(t, engine, source)
+		if err == nil || !test.ContainsString(err.Error(), "overflow") {
+			t.Errorf("%q: expected an overflow error, got %v", source, err)
+		}
+	}
+}
+
+func TestScript_Variables(t *testing.T) {
+	script, err := calculation.NewCalculationEngine().CompileScript("total = price * 2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	vars := map[string]calculation.Value{"price": number(21)}
+	result, err := script.Run(context.Background(), &strings.Builder{}, vars)
+	if err != nil || result["total"].String() != "42" {
+		t.Errorf("expected total 42, got %v (%v)", result["total"], err)
+	}
+	if _, ok := vars["total"]; ok {
+		t.Error("expected the caller's variables to be left unchanged")
+	}
+}
@@ -0,0 +1,110 @@
+package calculation_test
+
+import (
+	"testing"
+
+	"calculator/internal/calculation"
+	"calculator/test"
+)
+
+func TestStatistics(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+		errorMsg string
+	}{
+		{expr: "[1, 2 km, 3 * 4]", expected: "[1, 2 km, 12]"},
+		{expr: "[]", expected: "[]"},
+		{expr: "sum([0.1, 0.2, 0.3])", expected: "0.6"},
+		{expr: "sum([0.1, 0.2, 0.3]) == 0.6", expected: "true"},
+		{expr: "sum(1, 2, [3, [4]])", expected: "10"},
+		{expr: "sum([])", expected: "0"},
+		{expr: "count(xs)", expected: "8"},
+		{expr: "count([])", expected: "0"},
+		{expr: "mean(xs)", expected: "5"},
+		{expr: "median(xs)", expected: "4.5"},
+		{expr: "median([5, 1, 3])", expected: "3"},
+		{expr: "mode(xs)", expected: "4"},
+		{expr: "mode([3, 1, 3, 1])", expected: "1"},
+		{expr: "min(xs)", expected: "2"},
+		{expr: "max(xs)", expected: "9"},
+		{expr: "max(3, 1, 2)", expected: "3"},
+		{expr: "varp(xs)", expected: "4"},
+		{expr: "stdevp(xs)", expected: "2"},
+		{expr: "var(xs)", expected: "4.57142857142857"},
+		{expr: "stdev(xs)", expected: "2.1380899352994"},
+		{expr: "percentile([1, 2, 3, 4, 5], 90)", expected: "4.6"},
+		{expr: "percentile([1, 2, 3, 4, 5], 90%)", expected: "4.6"},
+		{expr: "percentile(xs, 0) == min(xs)", expected: "true"},
+		{expr: "percentile(xs, 100) == max(xs)", expected: "true"},
+		{expr: "percentile(xs, 50) == median(xs)", expected: "true"},
+		{expr: "mean([])", errorMsg: "mean of an empty list"},
+		{expr: "stdev([1])", errorMsg: "stdev needs at least 2 values, got 1"},
+		{expr: "sum([1, 2 km])", errorMsg: "sum expects numbers, got quantity"},
+		{expr: "percentile(xs, 101)", errorMsg: "percentile must be between 0 and 100, got 101"},
+		{expr: "percentile(xs, true)", errorMsg: "percentile expects a number from 0 to 100, got boolean"},
+		{expr: "xs + 1", errorMsg: "list"},
+		{expr: "[1, 2", errorMsg: "missing closing bracket in list"},
+		{expr: "[1 2]", errorMsg: "missing closing bracket in list"},
+	}
+
+	engine := calculation.NewCalculationEngine()
+	xs := calculation.List{}
+	for _, x := range []float64{2, 4, 4, 4, 5, 5, 7, 9} {
+		xs.Items = append(xs.Items, number(x))
+	}
+	vars := map[string]calculation.Value{"xs": xs}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := engine.EvaluateWithVariables(tt.expr, vars)
+			if tt.errorMsg != "" {
+				if err == nil || !test.ContainsString(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := engine.FormatResult(result); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestStatistics_Cache(t *testing.T) {
+	cache := calculation.NewResultCache(8)
+	engine := calculation.NewCalculationEngine(calculation.WithCache(cache))
+	for _, tt := range []struct {
+		xs       []float64
+		expected string
+	}{
+		{xs: []float64{1, 2}, expected: "3"},
+		{xs: []float64{1, 2}, expected: "3"},
+		{xs: []float64{1, 5}, expected: "6"},
+	} {
+		list := calculation.List{}
+		for _, x := range tt.xs {
+			list.Items = append(list.Items, number(x))
+		}
+		result, err := engine.EvaluateWithVariables("sum(xs)", map[string]calculation.Value{"xs": list})
+		if err != nil || result.String() != tt.expected {
+			t.Errorf("sum(%v): expected %s, got %v (%v)", tt.xs, tt.expected, result, err)
+		}
+	}
+	if stats := cache.Stats(); stats.Hits != 1 {
+		t.Errorf("expected one cache hit for the repeated list, got %+v", stats)
+	}
+}
+
+func TestScript_Lists(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+	out, _, err := runScript(t, engine, "sales = [120, 80,\n  100]  # one per branch\nprint mean(sales), max(sales) - min(sales)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "100 40\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+}
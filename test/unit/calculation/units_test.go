@@ -0,0 +1,103 @@
+package calculation_test
+
+import (
+	"testing"
+
+	"calculator/internal/calculation"
+	"calculator/test"
+)
+
+func TestEvaluate_Units(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+
+	tests := []struct {
+		name       string
+		expression string
+		expected   string
+	}{
+		{name: "mixed length addition", expression: "5 km + 300 m", expected: "5.3 km"},
+		{name: "speed conversion", expression: "60 mph to km/h", expected: "96.56064 km/h"},
+		{name: "force from mass and acceleration", expression: "9.81 m/s^2 * 70 kg", expected: "686.7 m*kg/s^2"},
+		{name: "force conversion", expression: "9.81 m/s^2 * 70 kg to N", expected: "686.7 N"},
+		{name: "in keyword", expression: "1 mi in ft", expected: "5280 ft"},
+		{name: "inch literal", expression: "12 in to cm", expected: "30.48 cm"},
+		{name: "inch literal with in conversion", expression: "1 in in mm", expected: "25.4 mm"},
+		{name: "binary data sizes", expression: "1 GiB to MiB", expected: "1024 MiB"},
+		{name: "decimal data sizes", expression: "1.5 GB to MB", expected: "1500 MB"},
+		{name: "bits to bytes", expression: "64 bit to B", expected: "8 B"},
+		{name: "time units", expression: "90 min to h", expected: "1.5 h"},
+		{name: "scalar multiplication", expression: "3 * 2.5 kg", expected: "7.5 kg"},
+		{name: "division by time", expression: "100 km / 2 h", expected: "50 km/h"},
+		{name: "dimensionless ratio", expression: "1 km / 1 m", expected: "1000"},
+		{name: "area from lengths", expression: "(3 m)^2", expected: "9 m^2"},
+		{name: "negated quantity", expression: "-(5 m)", expected: "-5 m"},
+		{name: "inverse time", expression: "10 / 2 s", expected: "5 s^-1"},
+		{name: "frequency conversion", expression: "10 / 2 s to Hz", expected: "5 Hz"},
+		{name: "imperial mass", expression: "1 lb to g", expected: "453.59237 g"},
+		{name: "power exponent", expression: "2^10", expected: "1024"},
+		{name: "fractional exponent", expression: "2^0.5", expected: "1.4142135623731"},
+		{name: "right associative power", expression: "2^3^2", expected: "512"},
+		{name: "unary minus binds looser than power", expression: "-2^2", expected: "-4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := engine.Evaluate(tt.expression)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := result.String(); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEvaluate_UnitErrors(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+
+	tests := []struct {
+		name       string
+		expression string
+		errorMsg   string
+	}{
+		{name: "adding length and time", expression: "1 m + 1 s", errorMsg: "dimension mismatch: cannot add m and s"},
+		{name: "subtracting mass from length", expression: "1 m - 1 kg", errorMsg: "dimension mismatch: cannot subtract m and kg"},
+		{name: "adding plain number", expression: "5 + 3 m", errorMsg: "dimension mismatch"},
+		{name: "incompatible conversion", expression: "5 kg to m", errorMsg: "cannot convert kg to m"},
+		{name: "unknown target unit", expression: "5 m to parsec", errorMsg: "unknown unit: parsec"},
+		{name: "converting plain number", expression: "5 to m", errorMsg: "value has no unit"},
+		{name: "fractional unit power", expression: "(2 m)^0.5", errorMsg: "must be an integer"},
+		{name: "quantity division by zero", expression: "5 m / 0 s", errorMsg: "division by zero"},
+		{name: "negative base fractional exponent", expression: "(-8)^0.5", errorMsg: "negative base"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := engine.Evaluate(tt.expression)
+			if err == nil {
+				t.Fatalf("expected error containing '%s', got nil", tt.errorMsg)
+			}
+			if !test.ContainsString(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error containing '%s', got '%s'", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestSupportedUnits(t *testing.T) {
+	units := calculation.SupportedUnits()
+
+	for _, symbol := range []string{"m", "km", "mph", "KiB", "GB", "h", "N"} {
+		found := false
+		for _, u := range units {
+			if u == symbol {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("expected unit %s to be registered", symbol)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package calculation_test
+
+import (
+	"errors"
+	"testing"
+
+	"calculator/internal/calculation"
+	"calculator/test"
+)
+
+func TestConditional(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		expected string
+		errorMsg string
+	}{
+		{name: "ternary true", expr: "2 > 1 ? 10 : 20", expected: "10"},
+		{name: "ternary false", expr: "2 < 1 ? 10 : 20", expected: "20"},
+		{name: "if function", expr: "if(x >= 0, x, -x)", expected: "5"},
+		{name: "right grouping", expr: "x < 0 ? -1 : x == 0 ? 0 : 1", expected: "1"},
+		{name: "branch with units", expr: "x > 3 ? 5 km : 500 m to km", expected: "5 km"},
+		{name: "untaken ternary branch", expr: "x > 0 ? 1 : 1/0", expected: "1"},
+		{name: "untaken if branch", expr: "if(x < 0, sqrt(-1 / 0), 2)", expected: "2"},
+		{name: "taken branch fails", expr: "x > 0 ? 1/0 : 1", errorMsg: "division by zero"},
+		{name: "condition not boolean", expr: "x ? 1 : 2", errorMsg: "expected true or false, got number"},
+		{name: "missing colon", expr: "true ? 1", errorMsg: "expected ':' after '?' branch"},
+		{name: "if arity", expr: "if(true, 1)", errorMsg: "if expects 3 argument(s), got 2"},
+	}
+
+	engine := calculation.NewCalculationEngine()
+	vars := map[string]calculation.Value{"x": number(5)}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := engine.EvaluateWithVariables(tt.expr, vars)
+			if tt.errorMsg != "" {
+				if err == nil || !test.ContainsString(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := engine.FormatResult(result); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDefineFunction(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+	vars := map[string]calculation.Value{"rate": number(0.5)}
+	for _, definition := range []string{
+		"tax(x) = x <= 10000 ? 0 : (x - 10000) * 0.2",
+		"fact(n) = n <= 1 ? 1 : n * fact(n - 1)",
+		"half(x) = x * rate",
+		"area(w, h) = w * h",
+		"zero() = 0",
+	} {
+		fn, err := engine.DefineFunction(definition, vars)
+		if err != nil {
+			t.Fatalf("DefineFunction(%q): %v", definition, err)
+		}
+		if fn.String() != definition {
+			t.Errorf("expected %q, got %q", definition, fn.String())
+		}
+		vars[fn.Name] = fn
+	}
+
+	tests := []struct {
+		expr     string
+		expected string
+		errorMsg string
+	}{
+		{expr: "tax(8000)", expected: "0"},
+		{expr: "tax(25000)", expected: "3000"},
+		{expr: "fact(10)", expected: "3628800"},
+		{expr: "half(10)", expected: "5"},
+		{expr: "area(2 m, 3 m)", expected: "6 m^2"},
+		{expr: "zero() + tax(10001)", expected: "0.2"},
+		{expr: "area(2)", errorMsg: "area expects 2 argument(s), got 1"},
+		{expr: "rate(2)", errorMsg: "rate is not a function"},
+		{expr: "nope(2)", errorMsg: "unknown function: nope"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := engine.EvaluateWithVariables(tt.expr, vars)
+			if tt.errorMsg != "" {
+				if err == nil || !test.ContainsString(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := engine.FormatResult(result); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+
+	// The body reads rate when called, so the result follows the variable
+	vars["rate"] = number(0.25)
+	result, err := engine.EvaluateWithVariables("half(10)", vars)
+	if err != nil || engine.FormatResult(result) != "2.5" {
+		t.Errorf("expected 2.5 after changing rate, got %v (%v)", result, err)
+	}
+}
+
+func TestDefineFunction_Errors(t *testing.T) {
+	tests := []struct {
+		definition string
+		errorMsg   string
+		syntax     bool
+	}{
+		{definition: "f(x) == 3", errorMsg: "expected a definition", syntax: true},
+		{definition: "f(x + 1) = x", errorMsg: "expected a definition", syntax: true},
+		{definition: "f(x) =", errorMsg: "function f has no body", syntax: true},
+		{definition: "f(x) = x +", errorMsg: "unexpected end of expression", syntax: true},
+		{definition: "sqrt(x) = x", errorMsg: "cannot redefine built-in function sqrt"},
+		{definition: "pi(x) = x", errorMsg: "cannot assign to built-in name \"pi\""},
+		{definition: "f(x, x) = x", errorMsg: "duplicate parameter x in f"},
+		{definition: "f(e) = e", errorMsg: "cannot assign to built-in name \"e\""},
+		{definition: "f(x) = g(x)", errorMsg: "unknown function: g"},
+	}
+
+	engine := calculation.NewCalculationEngine()
+	for _, tt := range tests {
+		t.Run(tt.definition, func(t *testing.T) {
+			_, err := engine.DefineFunction(tt.definition, nil)
+			if err == nil || !test.ContainsString(err.Error(), tt.errorMsg) {
+				t.Fatalf("expected error containing %q, got %v", tt.errorMsg, err)
+			}
+			var syntax *calculation.SyntaxError
+			if errors.As(err, &syntax) != tt.syntax {
+				t.Errorf("expected syntax error %t, got %T", tt.syntax, err)
+			}
+		})
+	}
+}
+
+func TestDefineFunction_RecursionLimit(t *testing.T) {
+	limits := calculation.DefaultLimits()
+	limits.MaxDepth = 20
+	engine := calculation.NewCalculationEngine(calculation.WithLimits(limits))
+	fn, err := engine.DefineFunction("loop(n) = loop(n + 1)", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = engine.EvaluateWithVariables("loop(1)", map[string]calculation.Value{"loop": fn})
+	var limitErr *calculation.LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != calculation.LimitDepth {
+		t.Errorf("expected the depth limit, got %v", err)
+	}
+}
+
+func TestDefineFunction_NotCached(t *testing.T) {
+	cache := calculation.NewResultCache(8)
+	engine := calculation.NewCalculationEngine(calculation.WithCache(cache))
+	fn, err := engine.DefineFunction("scaled(x) = x * k", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []float64{2, 3} {
+		vars := map[string]calculation.Value{"scaled": fn, "k": number(k)}
+		result, err := engine.EvaluateWithVariables("scaled(10)", vars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := number(10 * k).String(); result.String() != want {
+			t.Errorf("with k = %g expected %s, got %s", k, want, result)
+		}
+	}
+}
+
+func TestScript_Functions(t *testing.T) {
+	engine := calculation.NewCalculationEngine()
+	source := `tax(x) = x <= 10000 ? 0 : (x - 10000) * 0.2
+fib(n) = n < 2 ? n : fib(n - 1) + fib(n - 2)
+print tax(25000), fib(15)
+if(tax(5000) == 0, 1, 1/0)
+if (ans == 1) {
+  print "untaxed"
+}`
+	out, vars, err := runScript(t, engine, source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "3000 610\nuntaxed\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+	if fn, ok := vars["tax"].(*calculation.UserFunction); !ok || fn.Body != "x <= 10000 ? 0 : (x - 10000) * 0.2" {
+		t.Errorf("expected tax to be defined, got %v", vars["tax"])
+	}
+
+	_, err = engine.CompileScript("y = twice(2)\ntwice(x) = 2 * x")
+	var scriptErr *calculation.ScriptError
+	if !errors.As(err, &scriptErr) || scriptErr.Line != 1 || !test.ContainsString(err.Error(), "unknown function: twice") {
+		t.Errorf("expected a call before the definition to fail on line 1, got %v", err)
+	}
+}
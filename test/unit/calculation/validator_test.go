@@ -43,6 +43,18 @@ func TestValidateExpression(t *testing.T) {
 			expectError: true,
 			errorMsg:    "unsupported operator",
 		},
+		{
+			name:        "comparison operator",
+			expression:  "5 < 2",
+			expectError: true,
+			errorMsg:    "unsupported operator: <",
+		},
+		{
+			name:        "logical operator",
+			expression:  "5 && 2",
+			expectError: true,
+			errorMsg:    "unsupported operator: &&",
+		},
 		{
 			name:        "invalid number",
 			expression:  "abc + 2",
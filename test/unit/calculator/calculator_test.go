@@ -0,0 +1,461 @@
+package calculator_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"calculator/pkg/calculator"
+	"calculator/test"
+)
+
+func TestEngine_Evaluate(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []calculator.Option
+		expr     string
+		kind     string
+		expected string
+	}{
+		{name: "arithmetic", expr: "2 + 3 * 4", kind: "number", expected: "14"},
+		{name: "precision", expr: "0.1 + 0.2", kind: "number", expected: "0.3"},
+		{name: "functions", expr: "sqrt(16) + abs(-2)", kind: "number", expected: "6"},
+		{name: "units", expr: "5 km to m", kind: "quantity", expected: "5000 m"},
+		{name: "desk percent", expr: "80 + 10%", kind: "number", expected: "88"},
+		{name: "math percent", opts: []calculator.Option{calculator.WithPercentMode(calculator.PercentMath)}, expr: "80 + 10%", kind: "number", expected: "80.1"},
+		{name: "complex mode", opts: []calculator.Option{calculator.WithComplexMode(true)}, expr: "sqrt(-4)", kind: "complex", expected: "2i"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := calculator.New(tt.opts...).Evaluate(tt.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if v.Kind() != tt.kind {
+				t.Errorf("expected kind %s, got %s", tt.kind, v.Kind())
+			}
+			if v.String() != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, v.String())
+			}
+		})
+	}
+}
+
+func TestEngine_Errors(t *testing.T) {
+	engine := calculator.New()
+	tests := []struct {
+		expr string
+		kind calculator.ErrorKind
+	}{
+		{expr: "", kind: calculator.SyntaxError},
+		{expr: "2 +", kind: calculator.SyntaxError},
+		{expr: "(3", kind: calculator.SyntaxError},
+		{expr: "1 / 0", kind: calculator.DivisionByZero},
+		{expr: "price * 2", kind: calculator.EvaluationError},
+		{expr: "5 km + 3 kg", kind: calculator.EvaluationError},
+		{expr: "foo(2)", kind: calculator.EvaluationError},
+		{expr: "sqrt(4, 9)", kind: calculator.EvaluationError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := engine.Evaluate(tt.expr)
+			var calcErr *calculator.Error
+			if !errors.As(err, &calcErr) {
+				t.Fatalf("expected *calculator.Error, got %v", err)
+			}
+			if calcErr.Kind != tt.kind {
+				t.Errorf("expected kind %s, got %s (%v)", tt.kind, calcErr.Kind, err)
+			}
+			if calcErr.Expression != tt.expr {
+				t.Errorf("expected expression %q, got %q", tt.expr, calcErr.Expression)
+			}
+		})
+	}
+
+	if err := engine.Validate("2 +"); err == nil {
+		t.Error("expected Validate to reject an incomplete expression")
+	}
+	if err := engine.Validate("1 / 0"); err != nil {
+		t.Errorf("expected Validate to accept 1 / 0 without evaluating it, got %v", err)
+	}
+}
+
+func TestProgram_Eval(t *testing.T) {
+	engine := calculator.New()
+	program, err := engine.Compile("price * qty * (1 + tax)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if program.Expression() != "price * qty * (1 + tax)" {
+		t.Errorf("unexpected expression %q", program.Expression())
+	}
+
+	tests := []struct {
+		price, qty float64
+		expected   float64
+	}{
+		{price: 10, qty: 1, expected: 12},
+		{price: 9.99, qty: 3, expected: 35.964},
+		{price: 0, qty: 5, expected: 0},
+	}
+	for _, tt := range tests {
+		v, err := program.Eval(map[string]calculator.Value{
+			"price": calculator.Number(tt.price),
+			"qty":   calculator.Number(tt.qty),
+			"tax":   calculator.Number(0.2),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		f, ok := v.Float64()
+		if !ok || !test.AlmostEqual(f, tt.expected, 1e-9) {
+			t.Errorf("price=%v qty=%v: expected %v, got %v", tt.price, tt.qty, tt.expected, v)
+		}
+	}
+
+	if _, err := program.Eval(nil); err == nil {
+		t.Error("expected an error for missing variables")
+	}
+	if _, err := engine.Compile("2 *"); err == nil {
+		t.Error("expected Compile to reject invalid syntax")
+	}
+}
+
+func TestProgram_ConcurrentEval(t *testing.T) {
+	program, err := calculator.New().Compile("x * x")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(x int) {
+			defer wg.Done()
+			v, err := program.Eval(map[string]calculator.Value{"x": calculator.Number(float64(x))})
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if f, _ := v.Float64(); f != float64(x*x) {
+				t.Errorf("expected %d, got %v", x*x, v)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestValue(t *testing.T) {
+	var zero calculator.Value
+	if zero.Kind() != "" || zero.String() != "" {
+		t.Errorf("expected an empty zero value, got %q %q", zero.Kind(), zero.String())
+	}
+	if _, ok := zero.Float64(); ok {
+		t.Error("expected the zero value not to be a number")
+	}
+
+	v, err := calculator.New().Evaluate("1 / 3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	big, ok := v.BigFloat()
+	if !ok || big.Prec() < 64 {
+		t.Errorf("expected a high-precision number, got %v", big)
+	}
+
+	v, err = calculator.New().Evaluate("5 km")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := v.Float64(); ok {
+		t.Error("expected a quantity not to convert to float64")
+	}
+}
+
+func TestRateTable(t *testing.T) {
+	rates, err := calculator.NewRateTable("eur", "2026-10-15", map[string]string{"USD": "1.25"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rates.Base() != "EUR" || rates.Date() != "2026-10-15" {
+		t.Errorf("unexpected table %s %s", rates.Base(), rates.Date())
+	}
+
+	v, err := calculator.New(calculator.WithRateTable(rates)).Evaluate("100 EUR to USD")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !test.ContainsString(v.String(), "125") || v.Kind() != "money" {
+		t.Errorf("expected 125 USD, got %s (%s)", v, v.Kind())
+	}
+
+	if _, err := calculator.NewRateTable("EUR", "2026-10-15", map[string]string{"USD": "-1"}); err == nil {
+		t.Error("expected an error for a negative rate")
+	}
+}
+
+func TestEngine_CalculateBatch(t *testing.T) {
+	engine := calculator.New()
+	results := engine.CalculateBatch(context.Background(), []string{"x + 1", "2 +", "1 / 0", "x * 2"},
+		calculator.WithWorkers(2), calculator.WithBatchVariables(map[string]calculator.Value{"x": calculator.Number(20)}))
+
+	expected := []struct {
+		value string
+		kind  calculator.ErrorKind
+	}{
+		{value: "21"},
+		{kind: calculator.SyntaxError},
+		{kind: calculator.DivisionByZero},
+		{value: "40"},
+	}
+	for i, want := range expected {
+		r := results[i]
+		if want.kind == "" {
+			if r.Err != nil || r.Value.String() != want.value {
+				t.Errorf("%s: expected %s, got %v (%v)", r.Expression, want.value, r.Value, r.Err)
+			}
+			continue
+		}
+		var calcErr *calculator.Error
+		if !errors.As(r.Err, &calcErr) || calcErr.Kind != want.kind {
+			t.Errorf("%s: expected %s, got %v", r.Expression, want.kind, r.Err)
+		}
+	}
+
+	stats := calculator.SummarizeBatch(results)
+	if stats.Total != 4 || stats.Succeeded != 2 || stats.Failed != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results = engine.CalculateBatch(ctx, []string{"1", "2"})
+	if stats := calculator.SummarizeBatch(results); stats.Cancelled != 2 {
+		t.Errorf("expected both expressions cancelled, got %+v", stats)
+	}
+}
+
+func TestEngine_Limits(t *testing.T) {
+	engine := calculator.New(calculator.WithLimits(calculator.Limits{MaxDepth: 3}))
+	_, err := engine.Evaluate("abs(abs(abs(abs(1))))")
+	var calcErr *calculator.Error
+	if !errors.As(err, &calcErr) || calcErr.Kind != calculator.LimitExceeded {
+		t.Errorf("expected %s, got %v", calculator.LimitExceeded, err)
+	}
+
+	if _, err := calculator.New().Evaluate("9 ^ 9 ^ 9"); !errors.As(err, &calcErr) || calcErr.Kind != calculator.LimitExceeded {
+		t.Errorf("expected the default limits to reject 9 ^ 9 ^ 9, got %v", err)
+	}
+	if limits := calculator.DefaultLimits(); limits.MaxExponent == 0 || limits.MaxSteps == 0 {
+		t.Errorf("expected non-zero default limits, got %+v", limits)
+	}
+}
+
+func TestEngine_EvaluateContext(t *testing.T) {
+	engine := calculator.New()
+	v, err := engine.EvaluateContext(context.Background(), "6 * 7")
+	if err != nil || v.String() != "42" {
+		t.Fatalf("expected 42, got %v (%v)", v, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = engine.EvaluateContext(ctx, "1 + 2 + 3 + 4 + 5 + 6 + 7 + 8 + 9 + 10 + 11 + 12 + 13 + 14 + 15 + 16 + 17 + 18 + 19 + 20 + 21 + 22 + 23 + 24 + 25 + 26 + 27 + 28 + 29 + 30 + 31 + 32 + 33 + 34")
+	var calcErr *calculator.Error
+	if !errors.As(err, &calcErr) || calcErr.Kind != calculator.Cancelled || !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %s wrapping context.Canceled, got %v", calculator.Cancelled, err)
+	}
+}
+
+func TestEngine_Cache(t *testing.T) {
+	cache := calculator.NewCache(16)
+	engine := calculator.New(calculator.WithCache(cache))
+
+	for _, x := range []float64{2, 2, 5, 5} {
+		v, err := engine.EvaluateWithVariables("x ^ 2 + 1", map[string]calculator.Value{"x": calculator.Number(x)})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if f, _ := v.Float64(); f != x*x+1 {
+			t.Errorf("x=%v: expected %v, got %v", x, x*x+1, v)
+		}
+	}
+	stats := cache.Stats()
+	if stats.Hits != 2 || stats.Misses != 2 || stats.Invalidations != 1 || stats.Entries != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	_, err := engine.Evaluate("2 +")
+	var calcErr *calculator.Error
+	if !errors.As(err, &calcErr) || calcErr.Kind != calculator.SyntaxError {
+		t.Errorf("expected a syntax error through the cache, got %v", err)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := calculator.NewRegistry()
+	err := registry.RegisterOperator(calculator.Operator{
+		Symbol: "mod", Precedence: calculator.PrecedenceMultiplicative, Arity: 2,
+		Binary: func(left, right calculator.Value) (calculator.Value, error) {
+			a, _ := left.Float64()
+			b, _ := right.Float64()
+			if b == 0 {
+				return calculator.Value{}, calculator.ErrDivisionByZero
+			}
+			return calculator.Number(float64(int64(a) % int64(b))), nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = registry.RegisterFunction(calculator.Function{
+		Name: "largest", Arity: calculator.Variadic,
+		Call: func(args []calculator.Value) (calculator.Value, error) {
+			if len(args) == 0 {
+				return calculator.Value{}, errors.New("largest needs at least one argument")
+			}
+			best := args[0]
+			for _, arg := range args[1:] {
+				a, _ := arg.Float64()
+				b, _ := best.Float64()
+				if a > b {
+					best = arg
+				}
+			}
+			return best, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	engine := calculator.New(calculator.WithRegistry(registry))
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{expr: "17 mod 5", expected: "2"},
+		{expr: "1 + 17 mod 5 * 3", expected: "7"},
+		{expr: "largest(3, 9, 4) mod 4", expected: "1"},
+	}
+	for _, tt := range tests {
+		v, err := engine.Evaluate(tt.expr)
+		if err != nil || v.String() != tt.expected {
+			t.Errorf("%s: expected %s, got %v (%v)", tt.expr, tt.expected, v, err)
+		}
+	}
+
+	_, err = engine.Evaluate("largest()")
+	var calcErr *calculator.Error
+	if !errors.As(err, &calcErr) || calcErr.Kind != calculator.EvaluationError {
+		t.Errorf("expected an evaluation error from the custom function, got %v", err)
+	}
+	if _, err := engine.Evaluate("5 mod 0"); !errors.As(err, &calcErr) || calcErr.Kind != calculator.DivisionByZero || !errors.Is(err, calculator.ErrDivisionByZero) {
+		t.Errorf("expected division by zero, got %v", err)
+	}
+	if err := registry.RegisterOperator(calculator.Operator{Symbol: "mod", Precedence: 1, Arity: 2,
+		Binary: func(left, _ calculator.Value) (calculator.Value, error) { return left, nil }}); err == nil {
+		t.Error("expected registering mod twice to fail")
+	}
+	if _, err := calculator.New().Evaluate("17 mod 5"); err == nil {
+		t.Error("expected engines without the registry not to know mod")
+	}
+	if ops := engine.Operators(); ops[len(ops)-2] != "mod" {
+		t.Errorf("expected mod among the operators, got %v", ops)
+	}
+}
+
+func TestEngine_Assert(t *testing.T) {
+	engine := calculator.New(calculator.WithTolerance(0.01))
+	vars := map[string]calculator.Value{"total": calculator.Number(1234.5)}
+	if err := engine.Assert("total ~= 1234.56", vars); err != nil {
+		t.Errorf("expected the assertion to pass within 1%%, got %v", err)
+	}
+
+	err := engine.Assert("total == 1234.56", vars)
+	var calcErr *calculator.Error
+	if !errors.As(err, &calcErr) || calcErr.Kind != calculator.AssertionFailed {
+		t.Errorf("expected %s, got %v", calculator.AssertionFailed, err)
+	}
+	if err := engine.Assert("total ~=", vars); !errors.As(err, &calcErr) || calcErr.Kind != calculator.SyntaxError {
+		t.Errorf("expected %s, got %v", calculator.SyntaxError, err)
+	}
+
+	v, err := engine.Evaluate("2 > 1")
+	if b, ok := v.Bool(); err != nil || !ok || !b || v.Kind() != "boolean" {
+		t.Errorf("expected boolean true, got %v (%v)", v, err)
+	}
+	if _, ok := calculator.Number(1).Bool(); ok {
+		t.Error("expected a number not to be a boolean")
+	}
+}
+
+func TestEngine_DefineFunction(t *testing.T) {
+	engine := calculator.New()
+	tax, err := engine.DefineFunction("tax(x) = x <= 10000 ? 0 : (x - 10000) * 0.2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tax.Kind() != "function" || tax.String() != "tax(x) = x <= 10000 ? 0 : (x - 10000) * 0.2" {
+		t.Errorf("unexpected function %s %q", tax.Kind(), tax)
+	}
+	v, err := engine.EvaluateWithVariables("tax(25000) + tax(500)", map[string]calculator.Value{"tax": tax})
+	if err != nil || v.String() != "3000" {
+		t.Errorf("expected 3000, got %v (%v)", v, err)
+	}
+
+	_, err = engine.DefineFunction("f(x) =", nil)
+	var calcErr *calculator.Error
+	if !errors.As(err, &calcErr) || calcErr.Kind != calculator.SyntaxError {
+		t.Errorf("expected %s, got %v", calculator.SyntaxError, err)
+	}
+}
+
+func TestEngine_Lists(t *testing.T) {
+	engine := calculator.New()
+	prices := calculator.List(calculator.Number(19.99), calculator.Number(5.01), calculator.Value{}, calculator.Number(25))
+	if prices.Kind() != "list" {
+		t.Errorf("expected a list, got %s", prices.Kind())
+	}
+	v, err := engine.EvaluateWithVariables("median(prices) + count(prices)", map[string]calculator.Value{"prices": prices})
+	if err != nil || v.String() != "22.99" {
+		t.Errorf("expected 22.99, got %v (%v)", v, err)
+	}
+
+	v, err = engine.Evaluate("[1, 2 + 2]")
+	items, ok := v.Items()
+	if err != nil || !ok || len(items) != 2 || items[1].String() != "4" {
+		t.Errorf("expected the items [1, 4], got %v (%v)", v, err)
+	}
+	if _, ok := calculator.Number(1).Items(); ok {
+		t.Error("expected a number not to be a list")
+	}
+}
+
+func TestError_DivisionByZeroKind(t *testing.T) {
+	registry := calculator.NewRegistry()
+	err := registry.RegisterFunction(calculator.Function{
+		Name: "explain", Arity: 0,
+		Call: func([]calculator.Value) (calculator.Value, error) {
+			return calculator.Value{}, errors.New("cannot explain division by zero")
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := calculator.New(calculator.WithRegistry(registry))
+
+	// The kind comes from ErrDivisionByZero, not from the message text
+	var calcErr *calculator.Error
+	if _, err := engine.Evaluate("explain()"); !errors.As(err, &calcErr) || calcErr.Kind != calculator.EvaluationError {
+		t.Errorf("expected %s, got %v", calculator.EvaluationError, err)
+	}
+	for _, expr := range []string{"1 / 0", "0 ^ -1", "10 EUR / 0"} {
+		if _, err := engine.Evaluate(expr); !errors.As(err, &calcErr) || calcErr.Kind != calculator.DivisionByZero {
+			t.Errorf("%s: expected %s, got %v", expr, calculator.DivisionByZero, err)
+		}
+	}
+}
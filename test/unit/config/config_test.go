@@ -0,0 +1,145 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"calculator/internal/config"
+	"calculator/test"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `# user configuration
+precision: 4
+auto_save: true
+theme: "dark"   # trailing comment
+output_format: json
+currency_rates_file: rates/eur.json
+tolerance: 0.001
+complex_mode: true
+complex_form: polar
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Precision != 4 {
+		t.Errorf("expected precision 4, got %d", cfg.Precision)
+	}
+	if !cfg.AutoSave {
+		t.Error("expected auto_save to be true")
+	}
+	if cfg.Theme != "dark" {
+		t.Errorf("expected theme dark, got %q", cfg.Theme)
+	}
+	if cfg.OutputFormat != "json" {
+		t.Errorf("expected output_format json, got %q", cfg.OutputFormat)
+	}
+	if cfg.Tolerance != 0.001 {
+		t.Errorf("expected tolerance 0.001, got %v", cfg.Tolerance)
+	}
+	if !cfg.ComplexMode || cfg.ComplexForm != "polar" {
+		t.Errorf("expected complex mode with polar output, got %v %q", cfg.ComplexMode, cfg.ComplexForm)
+	}
+	if cfg.MaxHistory != config.DefaultConfig().MaxHistory {
+		t.Errorf("expected default max_history, got %d", cfg.MaxHistory)
+	}
+	if expected := filepath.Join(dir, "rates", "eur.json"); cfg.CurrencyRatesFile != expected {
+		t.Errorf("expected rates file %s, got %s", expected, cfg.CurrencyRatesFile)
+	}
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		errorMsg string
+	}{
+		{name: "unknown key", content: "colour: blue\n", errorMsg: "unknown configuration key: colour"},
+		{name: "invalid integer", content: "precision: many\n", errorMsg: "precision must be an integer"},
+		{name: "invalid boolean", content: "auto_save: maybe\n", errorMsg: "auto_save must be true or false"},
+		{name: "invalid number", content: "tolerance: tight\n", errorMsg: "tolerance must be a number"},
+		{name: "missing separator", content: "precision 4\n", errorMsg: "expected 'key: value'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+
+			_, err := config.LoadConfig(path)
+			if err == nil {
+				t.Fatalf("expected error containing '%s', got nil", tt.errorMsg)
+			}
+			if !test.ContainsString(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error containing '%s', got '%s'", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestSaveConfig_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "config.yaml")
+
+	original := config.DefaultConfig()
+	original.Precision = 8
+	original.Theme = "high-contrast"
+	original.DebugMode = true
+
+	if err := config.SaveConfig(original, path); err != nil {
+		t.Fatalf("unexpected error saving config: %v", err)
+	}
+
+	loaded, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error loading config: %v", err)
+	}
+	if *loaded != *original {
+		t.Errorf("round trip mismatch: saved %+v, loaded %+v", *original, *loaded)
+	}
+}
+
+func TestLoadConfigOrDefault_MissingFile(t *testing.T) {
+	cfg, err := config.LoadConfigOrDefault(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *cfg != *config.DefaultConfig() {
+		t.Errorf("expected default configuration, got %+v", *cfg)
+	}
+}
+
+func TestDefaultConfigFile(t *testing.T) {
+	cfg, err := config.LoadConfig("../../../configs/default.yaml")
+	if err != nil {
+		t.Fatalf("configs/default.yaml failed to load: %v", err)
+	}
+	if *cfg != *config.DefaultConfig() {
+		t.Errorf("configs/default.yaml differs from DefaultConfig: %+v", *cfg)
+	}
+
+	// Every configurable field is listed, so the file documents them all
+	data, err := os.ReadFile("../../../configs/default.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := reflect.TypeOf(config.Configuration{})
+	for i := 0; i < fields.NumField(); i++ {
+		key, _, _ := strings.Cut(fields.Field(i).Tag.Get("yaml"), ",")
+		if !strings.Contains(string(data), "\n"+key+":") {
+			t.Errorf("configs/default.yaml does not list %s", key)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package history_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"calculator/internal/calculation"
+	"calculator/internal/history"
+	"calculator/test"
+)
+
+// sampleDesk builds a running total with steps, a subtotal, an annotation and a total
+func sampleDesk(t *testing.T) *calculation.RunningTotal {
+	t.Helper()
+	fixed := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	engine := calculation.NewCalculationEngine(calculation.WithClock(func() time.Time { return fixed }))
+	desk := engine.NewRunningTotal()
+
+	for _, line := range []string{"100", "+ 15", "* 1.2"} {
+		if _, err := desk.Apply(line); err != nil {
+			t.Fatalf("%s: unexpected error: %v", line, err)
+		}
+	}
+	desk.Subtotal()
+	if err := desk.Annotate("hotel | taxi"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := desk.Apply("/ 3"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	desk.Total()
+	return desk
+}
+
+func TestNewTape_Calculations(t *testing.T) {
+	tape := history.NewTape(sampleDesk(t).Entries())
+
+	if len(tape.Calculations) != 6 {
+		t.Fatalf("expected 6 calculations, got %d", len(tape.Calculations))
+	}
+	multiply := tape.Calculations[2]
+	if multiply.Expression != "* 1.2" || multiply.Operation != "multiply" || multiply.Result != 138 {
+		t.Errorf("unexpected multiply record: %+v", multiply)
+	}
+	if len(multiply.Operands) != 2 || multiply.Operands[0] != 115 || multiply.Operands[1] != 1.2 {
+		t.Errorf("expected operands [115 1.2], got %v", multiply.Operands)
+	}
+	if sub := tape.Calculations[3]; sub.Operation != "subtotal" || sub.Annotation != "hotel | taxi" {
+		t.Errorf("unexpected subtotal record: %+v", sub)
+	}
+	if tape.Calculations[0].ID != "calc-1" || tape.Calculations[0].Operation != "enter" {
+		t.Errorf("unexpected first record: %+v", tape.Calculations[0])
+	}
+}
+
+func TestTape_ExportImportReplay(t *testing.T) {
+	original := sampleDesk(t)
+	formats := []history.Format{history.FormatText, history.FormatCSV, history.FormatMarkdown, history.FormatJSON}
+
+	for _, format := range formats {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := history.NewTape(original.Entries()).Export(&buf, format); err != nil {
+				t.Fatalf("export failed: %v", err)
+			}
+
+			tape, err := history.ImportTape(&buf, format)
+			if err != nil {
+				t.Fatalf("import failed: %v", err)
+			}
+			replayed := calculation.NewCalculationEngine().NewRunningTotal()
+			if err := tape.Replay(replayed); err != nil {
+				t.Fatalf("replay failed: %v", err)
+			}
+			if replayed.String() != original.String() {
+				t.Errorf("expected replayed tape:\n%s\ngot:\n%s", original, replayed)
+			}
+		})
+	}
+}
+
+func TestTape_ExportFormats(t *testing.T) {
+	tape := history.NewTape(sampleDesk(t).Entries())
+
+	tests := []struct {
+		format   history.Format
+		expected string
+	}{
+		{format: history.FormatText, expected: "◇  subtotal         = 138  # hotel | taxi\n"},
+		{format: history.FormatCSV, expected: "3,*,1.2,138,,2026-10-19T09:00:00Z\n"},
+		{format: history.FormatMarkdown, expected: "| 4 | `◇` | subtotal | 138 | hotel \\| taxi |\n"},
+		{format: history.FormatJSON, expected: `"operation": "divide"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tape.Export(&buf, tt.format); err != nil {
+				t.Fatalf("export failed: %v", err)
+			}
+			if !test.ContainsString(buf.String(), tt.expected) {
+				t.Errorf("expected output to contain %q, got:\n%s", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestTape_ReplayDetectsTampering(t *testing.T) {
+	var buf bytes.Buffer
+	if err := history.NewTape(sampleDesk(t).Entries()).Export(&buf, history.FormatCSV); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	tampered := strings.Replace(buf.String(), "3,*,1.2,138", "3,*,1.2,140", 1)
+
+	tape, err := history.ImportTape(strings.NewReader(tampered), history.FormatCSV)
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	err = tape.Replay(calculation.NewCalculationEngine().NewRunningTotal())
+	if err == nil || !test.ContainsString(err.Error(), "step 3") {
+		t.Errorf("expected replay mismatch at step 3, got %v", err)
+	}
+}
+
+func TestImportTape_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   history.Format
+		input    string
+		errorMsg string
+	}{
+		{name: "text without result", format: history.FormatText, input: "+ 15\n", errorMsg: "line 1"},
+		{name: "text unknown operator", format: history.FormatText, input: "^ 2 = 4\n", errorMsg: "unknown operator"},
+		{name: "csv wrong header", format: history.FormatCSV, input: "a,b\n1,2\n", errorMsg: "expected header"},
+		{name: "csv bad result", format: history.FormatCSV, input: "step,operator,operand,result,annotation,timestamp\n1,,5,five,,\n", errorMsg: "invalid result"},
+		{name: "markdown wrong columns", format: history.FormatMarkdown, input: "| h |\n|---|\n| 1 | 2 |\n", errorMsg: "expected 5 table columns"},
+		{name: "json syntax", format: history.FormatJSON, input: "{", errorMsg: "invalid JSON tape"},
+		{name: "unknown format", format: "xml", input: "", errorMsg: "unsupported tape format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := history.ImportTape(strings.NewReader(tt.input), tt.format)
+			if err == nil {
+				t.Fatalf("expected error containing '%s', got nil", tt.errorMsg)
+			}
+			if !test.ContainsString(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error containing '%s', got '%s'", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	tests := map[string]history.Format{
+		"tape.txt":     history.FormatText,
+		"tape.CSV":     history.FormatCSV,
+		"review.md":    history.FormatMarkdown,
+		"session.json": history.FormatJSON,
+	}
+	for path, expected := range tests {
+		if got, err := history.FormatFromPath(path); err != nil || got != expected {
+			t.Errorf("%s: expected %s, got %s (%v)", path, expected, got, err)
+		}
+	}
+	if _, err := history.FormatFromPath("tape.xlsx"); err == nil {
+		t.Error("expected error for unsupported extension, got nil")
+	}
+}
@@ -0,0 +1,98 @@
+package history_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"calculator/internal/history"
+	"calculator/internal/models"
+)
+
+func TestUndoStack_UndoRedo(t *testing.T) {
+	undo := history.NewUndoStack[int](10)
+
+	// The state goes 0 -> 1 -> 2, recording the state before each change
+	undo.Record("set 1", 0)
+	undo.Record("set 2", 1)
+
+	state, label, ok := undo.Undo(2)
+	if !ok || state != 1 || label != "set 2" {
+		t.Fatalf("expected undo to state 1 (set 2), got %d (%s) %v", state, label, ok)
+	}
+	state, label, ok = undo.Undo(state)
+	if !ok || state != 0 || label != "set 1" {
+		t.Fatalf("expected undo to state 0 (set 1), got %d (%s) %v", state, label, ok)
+	}
+	if _, _, ok := undo.Undo(state); ok {
+		t.Error("expected nothing left to undo")
+	}
+
+	state, label, ok = undo.Redo(0)
+	if !ok || state != 1 || label != "set 1" {
+		t.Fatalf("expected redo to state 1 (set 1), got %d (%s) %v", state, label, ok)
+	}
+
+	// A new change discards the redo history
+	undo.Record("set 5", state)
+	if _, _, ok := undo.Redo(5); ok {
+		t.Error("expected a new change to clear redo")
+	}
+	if u, r := undo.Depths(); u != 2 || r != 0 {
+		t.Errorf("expected depths 2/0, got %d/%d", u, r)
+	}
+}
+
+func TestUndoStack_BoundedDepth(t *testing.T) {
+	undo := history.NewUndoStack[int](3)
+	for i := 0; i < 10; i++ {
+		undo.Record("step", i)
+	}
+
+	state := 10
+	steps := 0
+	for {
+		previous, _, ok := undo.Undo(state)
+		if !ok {
+			break
+		}
+		state = previous
+		steps++
+	}
+	if steps != 3 || state != 7 {
+		t.Errorf("expected 3 undo steps back to 7, got %d steps to %d", steps, state)
+	}
+}
+
+func TestHistoryManager(t *testing.T) {
+	manager := history.NewHistoryManager(2)
+	for _, expr := range []string{"1+1", "2+2", "3+3"} {
+		if err := manager.AddCalculation(models.Calculation{Expression: expr}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	calcs := manager.GetHistory()
+	if len(calcs) != 2 || calcs[0].Expression != "2+2" || calcs[1].ID != "calc-3" {
+		t.Fatalf("expected the two newest calculations with sequential IDs, got %+v", calcs)
+	}
+	if err := manager.AddCalculation(models.Calculation{}); err == nil {
+		t.Error("expected a calculation without an expression to be rejected")
+	}
+
+	clone := manager.Clone()
+	manager.ClearHistory()
+	if len(manager.GetHistory()) != 0 || len(clone.GetHistory()) != 2 {
+		t.Error("expected clear to leave the clone untouched")
+	}
+
+	path := filepath.Join(t.TempDir(), "history", "session.json")
+	if err := clone.SaveHistory(path); err != nil {
+		t.Fatalf("unexpected save error: %v", err)
+	}
+	loaded := history.NewHistoryManager(0)
+	if err := loaded.LoadHistory(path); err != nil {
+		t.Fatalf("unexpected load error: %v", err)
+	}
+	if got := loaded.GetHistory(); len(got) != 2 || got[1].Expression != "3+3" || got[1].ID != "calc-3" {
+		t.Errorf("expected saved calculations to load back, got %+v", got)
+	}
+}
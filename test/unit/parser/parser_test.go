@@ -0,0 +1,225 @@
+package parser_test
+
+import (
+	"testing"
+
+	"calculator/internal/calculation"
+	"calculator/internal/parser"
+	"calculator/test"
+)
+
+// pressAll sends each button to the parser, ignoring rejected input
+func pressAll(p *parser.InputParser, buttons ...parser.Button) {
+	for _, b := range buttons {
+		p.ProcessButtonInput(b)
+	}
+}
+
+// buttons splits keypad input into one button per rune
+func buttons(input string) []parser.Button {
+	var out []parser.Button
+	for _, r := range input {
+		out = append(out, parser.Button(string(r)))
+	}
+	return out
+}
+
+func TestInputParser_BuildsExpressions(t *testing.T) {
+	tests := []struct {
+		name     string
+		buttons  []parser.Button
+		expected string
+	}{
+		{name: "digits and operator", buttons: buttons("12+3"), expected: "12+3"},
+		{name: "second operator replaces first", buttons: buttons("2+*3"), expected: "2*3"},
+		{name: "minus after operator is a sign", buttons: buttons("2*-3"), expected: "2*-3"},
+		{name: "operator replaces operator and sign", buttons: buttons("2*-+3"), expected: "2+3"},
+		{name: "leading operator rejected", buttons: buttons("*5"), expected: "5"},
+		{name: "leading minus", buttons: buttons("-5"), expected: "-5"},
+		{name: "decimal point starts with zero", buttons: buttons(".5"), expected: "0.5"},
+		{name: "second decimal point rejected", buttons: buttons("1.2.3"), expected: "1.23"},
+		{name: "trailing decimal point dropped", buttons: buttons("2.+1"), expected: "2+1"},
+		{name: "leading zero replaced", buttons: buttons("007"), expected: "7"},
+		{name: "implicit multiply before paren", buttons: buttons("2(3+1)"), expected: "2*(3+1)"},
+		{name: "implicit multiply after paren", buttons: buttons("(1+1)4"), expected: "(1+1)*4"},
+		{name: "implicit multiply before constant", buttons: []parser.Button{"2", "pi"}, expected: "2*pi"},
+		{name: "function opener", buttons: []parser.Button{"sqrt(", "1", "6", ")"}, expected: "sqrt(16)"},
+		{name: "unmatched close rejected", buttons: buttons("2)"), expected: "2"},
+		{name: "close after operator rejected", buttons: buttons("(2+)"), expected: "(2+"},
+		{name: "percent after value", buttons: buttons("200*15%"), expected: "200*15%"},
+		{name: "double percent rejected", buttons: buttons("15%%"), expected: "15%"},
+		{name: "square button", buttons: []parser.Button{"3", "^2"}, expected: "3^2"},
+		{name: "backspace removes digit", buttons: []parser.Button{"1", "2", "+", "3", "4", parser.ButtonBackspace}, expected: "12+3"},
+		{name: "backspace removes function", buttons: []parser.Button{"2", "+", "sqrt(", parser.ButtonBackspace}, expected: "2+"},
+		{name: "sign toggles last number", buttons: []parser.Button{"1", "2", "+", "3", parser.ButtonSign}, expected: "12+-3"},
+		{name: "sign toggles back", buttons: []parser.Button{"3", parser.ButtonSign, parser.ButtonSign}, expected: "3"},
+		{name: "sign wraps group", buttons: append(buttons("2*(1+3)"), parser.ButtonSign), expected: "2*-(1+3)"},
+		{name: "sign before value", buttons: []parser.Button{"2", "*", parser.ButtonSign, "4"}, expected: "2*-4"},
+		{name: "clear", buttons: []parser.Button{"9", parser.ButtonClear, "1"}, expected: "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := parser.NewInputParser(calculation.NewCalculationEngine())
+			pressAll(p, tt.buttons...)
+			if got := p.GetCurrentExpression(); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestInputParser_RejectedInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		buttons  []parser.Button
+		errorMsg string
+	}{
+		{name: "operator without value", buttons: buttons("*"), errorMsg: "* needs a value before it"},
+		{name: "second decimal point", buttons: buttons("1.2."), errorMsg: "number already has a decimal point"},
+		{name: "unmatched close", buttons: buttons("3)"), errorMsg: "no open parenthesis to close"},
+		{name: "empty group", buttons: buttons("()"), errorMsg: ") needs a value before it"},
+		{name: "percent without value", buttons: buttons("%"), errorMsg: "% must follow a value"},
+		{name: "double sign", buttons: buttons("--"), errorMsg: "value is already negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := parser.NewInputParser(calculation.NewCalculationEngine())
+			last := tt.buttons[len(tt.buttons)-1]
+			pressAll(p, tt.buttons[:len(tt.buttons)-1]...)
+			before := p.GetCurrentExpression()
+
+			_, err := p.ProcessButtonInput(last)
+			if err == nil {
+				t.Fatalf("expected error containing %q", tt.errorMsg)
+			}
+			if !test.ContainsString(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error containing %q, got %q", tt.errorMsg, err.Error())
+			}
+			if got := p.GetCurrentExpression(); got != before {
+				t.Errorf("expected rejected input to leave %q, got %q", before, got)
+			}
+		})
+	}
+}
+
+func TestInputParser_Evaluate(t *testing.T) {
+	p := parser.NewInputParser(calculation.NewCalculationEngine())
+	pressAll(p, buttons("2*(3+4")...)
+
+	calc, err := p.ProcessButtonInput(parser.ButtonEquals)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calc.Expression != "2*(3+4)" || calc.Result != 14 {
+		t.Errorf("expected open parenthesis closed and 2*(3+4) = 14, got %+v", calc)
+	}
+	if !p.Evaluated() || p.Result().String() != "14" {
+		t.Errorf("expected evaluated result 14, got %v", p.Result())
+	}
+
+	// An operator continues from the result; a digit starts over
+	pressAll(p, buttons("/4")...)
+	if got := p.GetCurrentExpression(); got != "14/4" {
+		t.Errorf("expected operator to continue from result, got %q", got)
+	}
+	calc, _ = p.ProcessButtonInput(parser.ButtonEquals)
+	if !test.AlmostEqual(calc.Result, 3.5, 1e-12) {
+		t.Errorf("expected 3.5, got %v", calc.Result)
+	}
+	pressAll(p, buttons("9")...)
+	if got := p.GetCurrentExpression(); got != "9" {
+		t.Errorf("expected digit to start a new expression, got %q", got)
+	}
+
+	// Negative results stay grouped when continued
+	pressAll(p, buttons("-12=^2")...)
+	if got := p.GetCurrentExpression(); got != "(-3)^2" {
+		t.Errorf("expected negative result in parentheses, got %q", got)
+	}
+}
+
+func TestInputParser_EvaluateErrors(t *testing.T) {
+	p := parser.NewInputParser(calculation.NewCalculationEngine())
+	pressAll(p, buttons("1/0")...)
+
+	calc, err := p.ProcessButtonInput(parser.ButtonEquals)
+	if err == nil || !test.ContainsString(err.Error(), "division by zero") {
+		t.Fatalf("expected division by zero error, got %v", err)
+	}
+	if calc == nil || calc.Error == "" {
+		t.Fatalf("expected calculation carrying the error, got %+v", calc)
+	}
+	if p.Evaluated() || p.GetCurrentExpression() != "1/0" {
+		t.Errorf("expected expression kept for editing, got %q", p.GetCurrentExpression())
+	}
+
+	pressAll(p, parser.ButtonBackspace, "2", "+")
+	if _, err := p.ProcessButtonInput(parser.ButtonEquals); err == nil {
+		t.Error("expected incomplete expression to fail validation")
+	}
+
+	if calc, err := parser.NewInputParser(calculation.NewCalculationEngine()).ProcessButtonInput(parser.ButtonEquals); calc != nil || err != nil {
+		t.Errorf("expected = on an empty expression to do nothing, got %+v, %v", calc, err)
+	}
+}
+
+func TestInputParser_ProcessKeyInput(t *testing.T) {
+	p := parser.NewInputParser(calculation.NewCalculationEngine())
+	for _, key := range "7*6" {
+		if _, err := p.ProcessKeyInput(parser.Key(key)); err != nil {
+			t.Fatalf("unexpected error for %q: %v", key, err)
+		}
+	}
+	calc, err := p.ProcessKeyInput(parser.KeyEnter)
+	if err != nil || calc.Result != 42 {
+		t.Fatalf("expected Enter to evaluate 7*6 = 42, got %+v, %v", calc, err)
+	}
+
+	p.ProcessKeyInput(parser.KeyEscape)
+	if got := p.GetCurrentExpression(); got != "" {
+		t.Errorf("expected Escape to clear, got %q", got)
+	}
+	if _, err := p.ProcessKeyInput('x'); err == nil || !test.ContainsString(err.Error(), "unsupported key") {
+		t.Errorf("expected unsupported key error, got %v", err)
+	}
+}
+
+func TestInputParser_BuildExpressionAndValidate(t *testing.T) {
+	p := parser.NewInputParser(calculation.NewCalculationEngine())
+
+	tests := []struct {
+		current  string
+		input    string
+		expected string
+	}{
+		{current: "2+", input: "*", expected: "2*"},
+		{current: "3", input: "(", expected: "3*("},
+		{current: "1.5", input: "2", expected: "1.52"},
+		{current: "", input: "sqrt(9)", expected: "sqrt(9)"},
+	}
+	for _, tt := range tests {
+		got, err := p.BuildExpression(tt.current, tt.input)
+		if err != nil {
+			t.Errorf("BuildExpression(%q, %q): unexpected error: %v", tt.current, tt.input, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("BuildExpression(%q, %q): expected %q, got %q", tt.current, tt.input, tt.expected, got)
+		}
+	}
+	if _, err := p.BuildExpression("1.5", "."); err == nil {
+		t.Error("expected BuildExpression to reject a second decimal point")
+	}
+	if p.GetCurrentExpression() != "" {
+		t.Error("expected BuildExpression to leave the parser's own expression alone")
+	}
+
+	if err := p.ValidateExpression("(2+3)*4"); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+	if err := p.ValidateExpression("(2+3"); err == nil || !test.ContainsString(err.Error(), "missing closing parenthesis") {
+		t.Errorf("expected missing parenthesis error, got %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"calculator/internal/calculation"
+	"calculator/internal/server"
+)
+
+// serveRPC runs a JSON-RPC session over the given input lines and returns the
+// decoded response lines
+func serveRPC(t *testing.T, srv *server.Server, lines ...string) []any {
+	t.Helper()
+	var out bytes.Buffer
+	if err := srv.ServeRPC(context.Background(), strings.NewReader(strings.Join(lines, "\n")), &out, 100); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var replies []any
+	decoder := json.NewDecoder(&out)
+	for decoder.More() {
+		var reply any
+		if err := decoder.Decode(&reply); err != nil {
+			t.Fatalf("invalid JSON reply: %v", err)
+		}
+		replies = append(replies, reply)
+	}
+	return replies
+}
+
+// field walks nested JSON objects by key
+func field(v any, keys ...string) any {
+	for _, key := range keys {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
+
+func TestServeRPC_Methods(t *testing.T) {
+	srv := server.New(calculation.NewCalculationEngine())
+	replies := serveRPC(t, srv,
+		`{"jsonrpc": "2.0", "method": "calculate", "params": {"expression": "6 * 7"}, "id": 1}`,
+		`{"jsonrpc": "2.0", "method": "calculate", "params": ["1 / 0"], "id": 2}`,
+		`{"jsonrpc": "2.0", "method": "validate", "params": ["(1 + 2"], "id": 3}`,
+		`{"jsonrpc": "2.0", "method": "operations", "id": 4}`,
+		`{"jsonrpc": "2.0", "method": "calculate", "params": ["2 ^ 8"]}`,
+		`{"jsonrpc": "2.0", "method": "history.list", "params": {"limit": 2}, "id": "h"}`,
+	)
+
+	if len(replies) != 5 {
+		t.Fatalf("expected 5 replies (the notification gets none), got %d: %v", len(replies), replies)
+	}
+	if field(replies[0], "result", "result") != 42.0 || field(replies[0], "id") != 1.0 || field(replies[0], "jsonrpc") != "2.0" {
+		t.Errorf("expected calculate result 42, got %v", replies[0])
+	}
+	if field(replies[1], "error", "code") != -32000.0 || field(replies[1], "error", "data", "error_code") != "division_by_zero" {
+		t.Errorf("expected expression error with division_by_zero, got %v", replies[1])
+	}
+	if field(replies[2], "result", "valid") != false || field(replies[2], "result", "error_code") != "syntax_error" {
+		t.Errorf("expected syntax error from validate, got %v", replies[2])
+	}
+	if functions, _ := field(replies[3], "result", "functions").([]any); len(functions) == 0 {
+		t.Errorf("expected functions from operations, got %v", replies[3])
+	}
+
+	calcs, _ := field(replies[4], "result", "calculations").([]any)
+	if len(calcs) != 2 || field(calcs[0], "expression") != "1 / 0" || field(calcs[1], "result") != 256.0 {
+		t.Errorf("expected the last two calculations, including the notification, got %v", replies[4])
+	}
+}
+
+func TestServeRPC_BatchAndErrors(t *testing.T) {
+	srv := server.New(calculation.NewCalculationEngine(), server.WithMaxBatch(3))
+	replies := serveRPC(t, srv,
+		`[{"jsonrpc": "2.0", "method": "calculate", "params": ["1 + 1"], "id": 1}, {"jsonrpc": "2.0", "method": "calculate", "params": ["2 + 2"]}, {"jsonrpc": "2.0", "method": "missing", "id": 2}]`,
+		`[{"jsonrpc": "2.0", "method": "operations"}]`,
+		`{"jsonrpc": "2.0", "method": "calculate", "params": {"expr": "1"}, "id": 3}`,
+		`{"method": "calculate", "id": 4}`,
+		`{not json`,
+		`[]`,
+		`[1, 2, 3, 4]`,
+	)
+
+	if len(replies) != 6 {
+		t.Fatalf("expected 6 replies (an all-notification batch gets none), got %d: %v", len(replies), replies)
+	}
+	batch, ok := replies[0].([]any)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("expected a batch reply with two responses, got %v", replies[0])
+	}
+	if field(batch[0], "result", "result") != 2.0 || field(batch[1], "error", "code") != -32601.0 {
+		t.Errorf("expected a result and method not found, got %v", batch)
+	}
+
+	expected := []float64{-32602, -32600, -32700, -32600, -32600}
+	for i, code := range expected {
+		if got := field(replies[i+1], "error", "code"); got != code {
+			t.Errorf("reply %d: expected error code %v, got %v", i+2, code, replies[i+1])
+		}
+	}
+	if field(replies[3], "id") != nil || field(replies[2], "id") != 4.0 {
+		t.Errorf("expected null id for a parse error and the request id otherwise, got %v / %v", replies[3], replies[2])
+	}
+}
+
+func TestServeRPC_RequestTooLarge(t *testing.T) {
+	srv := server.New(calculation.NewCalculationEngine(), server.WithMaxBodyBytes(100))
+	replies := serveRPC(t, srv,
+		`{"jsonrpc": "2.0", "method": "calculate", "params": ["`+strings.Repeat("1+", 100)+`1"], "id": 1}`,
+		`{"jsonrpc": "2.0", "method": "calculate", "params": ["1+1"], "id": 2}`,
+	)
+
+	if len(replies) != 2 || field(replies[0], "error", "code") != -32600.0 {
+		t.Fatalf("expected the long line to be rejected, got %v", replies)
+	}
+	if field(replies[1], "result", "result") != 2.0 {
+		t.Errorf("expected the session to continue after a long line, got %v", replies[1])
+	}
+}
+
+func TestServeRPC_PanicRecovered(t *testing.T) {
+	srv := server.New(panickingEngine(t))
+	replies := serveRPC(t, srv,
+		`{"jsonrpc": "2.0", "method": "calculate", "params": ["1 + boom()"], "id": 1}`,
+		`{"jsonrpc": "2.0", "method": "calculate", "params": ["2 + 2"], "id": 2}`,
+		`{"jsonrpc": "2.0", "method": "history.list", "id": 3}`,
+	)
+
+	if len(replies) != 3 {
+		t.Fatalf("expected the session to answer every request, got %v", replies)
+	}
+	if field(replies[0], "error", "code") != -32000.0 || field(replies[0], "error", "data", "error_code") != "evaluation_error" {
+		t.Errorf("expected an expression error for the panic, got %v", replies[0])
+	}
+	if field(replies[1], "result", "result") != 4.0 {
+		t.Errorf("expected the session to keep serving, got %v", replies[1])
+	}
+	if calcs, _ := field(replies[2], "result", "calculations").([]any); len(calcs) != 2 {
+		t.Errorf("expected both calculations in the history, got %v", replies[2])
+	}
+}
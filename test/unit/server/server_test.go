@@ -0,0 +1,197 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"calculator/internal/calculation"
+	"calculator/internal/server"
+)
+
+// call sends a request to the API handler and decodes the JSON response
+func call(t *testing.T, handler http.Handler, method, path, body string) (int, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected JSON response, got Content-Type %q", ct)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, decoded
+}
+
+// errorCode extracts the code from a request-level error response
+func errorCode(body map[string]any) string {
+	if e, ok := body["error"].(map[string]any); ok {
+		code, _ := e["code"].(string)
+		return code
+	}
+	return ""
+}
+
+func TestServer_Calculate(t *testing.T) {
+	handler := server.New(calculation.NewCalculationEngine()).Handler()
+
+	tests := []struct {
+		name       string
+		body       string
+		status     int
+		result     float64
+		resultText string
+		errorCode  string
+	}{
+		{name: "arithmetic", body: `{"expression": "2 + 3 * 4"}`, status: 200, result: 14, resultText: "14"},
+		{name: "typed result", body: `{"expression": "5 km to m"}`, status: 200, resultText: "5000 m"},
+		{name: "division by zero", body: `{"expression": "1 / 0"}`, status: 422, errorCode: "division_by_zero"},
+		{name: "zero to a negative power", body: `{"expression": "0 ^ -2"}`, status: 422, errorCode: "division_by_zero"},
+		{name: "complex division by zero", body: `{"expression": "(1+2i) / 0i"}`, status: 422, errorCode: "division_by_zero"},
+		{name: "money division by zero", body: `{"expression": "10 EUR / 0"}`, status: 422, errorCode: "division_by_zero"},
+		{name: "syntax error", body: `{"expression": "2 +"}`, status: 422, errorCode: "syntax_error"},
+		{name: "unknown function", body: `{"expression": "foo(2)"}`, status: 422, errorCode: "evaluation_error"},
+		{name: "empty expression", body: `{"expression": " "}`, status: 400, errorCode: "empty_expression"},
+		{name: "limit exceeded", body: `{"expression": "9 ^ 9 ^ 9"}`, status: 422, errorCode: "limit_exceeded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := call(t, handler, http.MethodPost, "/v1/calculate", tt.body)
+			if status != tt.status {
+				t.Errorf("expected status %d, got %d (%v)", tt.status, status, body)
+			}
+			if body["id"] == "" || body["operation"] != "expression" || body["timestamp"] == nil {
+				t.Errorf("expected the Calculation shape, got %v", body)
+			}
+			if tt.errorCode != "" {
+				if body["error_code"] != tt.errorCode || body["error"] == "" {
+					t.Errorf("expected error_code %q with a message, got %v", tt.errorCode, body)
+				}
+				return
+			}
+			if body["result"] != tt.result || body["result_text"] != tt.resultText {
+				t.Errorf("expected result %v (%s), got %v (%v)", tt.result, tt.resultText, body["result"], body["result_text"])
+			}
+		})
+	}
+}
+
+func TestServer_ValidateAndOperations(t *testing.T) {
+	handler := server.New(calculation.NewCalculationEngine()).Handler()
+
+	_, body := call(t, handler, http.MethodPost, "/v1/validate", `{"expression": "(1 + 2) * 3"}`)
+	if body["valid"] != true {
+		t.Errorf("expected valid expression, got %v", body)
+	}
+	_, body = call(t, handler, http.MethodPost, "/v1/validate", `{"expression": "(1 + 2"}`)
+	if body["valid"] != false || body["error_code"] != "syntax_error" {
+		t.Errorf("expected syntax error, got %v", body)
+	}
+	// Validation does not evaluate, so division by zero is still valid syntax
+	_, body = call(t, handler, http.MethodPost, "/v1/validate", `{"expression": "1 / 0"}`)
+	if body["valid"] != true {
+		t.Errorf("expected 1 / 0 to be syntactically valid, got %v", body)
+	}
+
+	status, body := call(t, handler, http.MethodGet, "/v1/operations", "")
+	operators, _ := body["operators"].([]any)
+	functions, _ := body["functions"].([]any)
+	if status != 200 || len(operators) != 6 || len(functions) == 0 {
+		t.Errorf("expected operators and functions, got %d %v", status, body)
+	}
+}
+
+func TestServer_Batch(t *testing.T) {
+	handler := server.New(calculation.NewCalculationEngine(), server.WithMaxBatch(3)).Handler()
+
+	status, body := call(t, handler, http.MethodPost, "/v1/batch", `{"expressions": ["1 + 1", "1 / 0", "2 ^ 10"]}`)
+	results, _ := body["results"].([]any)
+	if status != 200 || len(results) != 3 {
+		t.Fatalf("expected three results, got %d %v", status, body)
+	}
+	first := results[0].(map[string]any)
+	second := results[1].(map[string]any)
+	third := results[2].(map[string]any)
+	if first["result"] != 2.0 || second["error_code"] != "division_by_zero" || third["result"] != 1024.0 {
+		t.Errorf("expected per-expression results and errors, got %v", results)
+	}
+
+	status, body = call(t, handler, http.MethodPost, "/v1/batch", `{"expressions": ["1", "2", "3", "4"]}`)
+	if status != http.StatusRequestEntityTooLarge || errorCode(body) != "batch_too_large" {
+		t.Errorf("expected batch_too_large, got %d %v", status, body)
+	}
+	status, body = call(t, handler, http.MethodPost, "/v1/batch", `{"expressions": []}`)
+	if status != http.StatusBadRequest || errorCode(body) != "invalid_request" {
+		t.Errorf("expected invalid_request for an empty batch, got %d %v", status, body)
+	}
+}
+
+func TestServer_RequestErrors(t *testing.T) {
+	handler := server.New(calculation.NewCalculationEngine(), server.WithMaxBodyBytes(64)).Handler()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{name: "malformed JSON", method: "POST", path: "/v1/calculate", body: `{"expression": `, status: 400, code: "invalid_request"},
+		{name: "unknown field", method: "POST", path: "/v1/calculate", body: `{"expr": "1"}`, status: 400, code: "invalid_request"},
+		{name: "body too large", method: "POST", path: "/v1/calculate", body: `{"expression": "` + strings.Repeat("1+", 40) + `1"}`, status: 413, code: "request_too_large"},
+		{name: "wrong method", method: "GET", path: "/v1/calculate", status: 405, code: "method_not_allowed"},
+		{name: "unknown route", method: "GET", path: "/v2/calculate", status: 404, code: "not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := call(t, handler, tt.method, tt.path, tt.body)
+			if status != tt.status || errorCode(body) != tt.code {
+				t.Errorf("expected %d %s, got %d %v", tt.status, tt.code, status, body)
+			}
+		})
+	}
+}
+
+// panickingEngine returns an engine with a boom() function that panics, as a
+// stand-in for any evaluation bug
+func panickingEngine(t *testing.T) *calculation.CalculationEngine {
+	t.Helper()
+	registry := calculation.NewRegistry()
+	err := registry.RegisterFunction(calculation.Function{Name: "boom", Arity: 0, Call: func(*calculation.CalculationEngine, []calculation.Value) (calculation.Value, error) {
+		panic("boom")
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return calculation.NewCalculationEngine(calculation.WithRegistry(registry))
+}
+
+func TestServer_PanicRecovered(t *testing.T) {
+	handler := server.New(panickingEngine(t)).Handler()
+
+	// The panic happens in the evaluation goroutine, outside net/http's
+	// handler recovery, so without recovering it would end the process
+	status, body := call(t, handler, http.MethodPost, "/v1/calculate", `{"expression": "1 + boom()"}`)
+	if status != http.StatusUnprocessableEntity || body["error_code"] != "evaluation_error" || !strings.Contains(body["error"].(string), "boom") {
+		t.Errorf("expected an evaluation error, got %d %v", status, body)
+	}
+
+	status, body = call(t, handler, http.MethodPost, "/v1/batch", `{"expressions": ["boom()", "2 + 2"]}`)
+	results, _ := body["results"].([]any)
+	if status != http.StatusOK || len(results) != 2 || field(results[0], "error_code") != "evaluation_error" || field(results[1], "result") != 4.0 {
+		t.Errorf("expected the batch to report the panic and go on, got %d %v", status, body)
+	}
+
+	status, body = call(t, handler, http.MethodPost, "/v1/calculate", `{"expression": "2 + 2"}`)
+	if status != http.StatusOK || body["result"] != 4.0 {
+		t.Errorf("expected the server to keep serving, got %d %v", status, body)
+	}
+}
@@ -0,0 +1,68 @@
+package terminal_test
+
+import (
+	"reflect"
+	"testing"
+
+	"calculator/internal/terminal"
+)
+
+func TestDecodeInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []terminal.Event
+	}{
+		{
+			name:  "printable characters",
+			input: "1+π",
+			expected: []terminal.Event{
+				terminal.KeyEvent{Key: terminal.KeyRune, Rune: '1'},
+				terminal.KeyEvent{Key: terminal.KeyRune, Rune: '+'},
+				terminal.KeyEvent{Key: terminal.KeyRune, Rune: 'π'},
+			},
+		},
+		{
+			name:  "control keys",
+			input: "\r\x7f\x03\t",
+			expected: []terminal.Event{
+				terminal.KeyEvent{Key: terminal.KeyEnter},
+				terminal.KeyEvent{Key: terminal.KeyBackspace},
+				terminal.KeyEvent{Key: terminal.KeyCtrlC},
+				terminal.KeyEvent{Key: terminal.KeyTab},
+			},
+		},
+		{
+			name:  "cursor keys and backtab",
+			input: "\x1b[A\x1b[B\x1bOC\x1b[D\x1b[Z",
+			expected: []terminal.Event{
+				terminal.KeyEvent{Key: terminal.KeyUp},
+				terminal.KeyEvent{Key: terminal.KeyDown},
+				terminal.KeyEvent{Key: terminal.KeyRight},
+				terminal.KeyEvent{Key: terminal.KeyLeft},
+				terminal.KeyEvent{Key: terminal.KeyBacktab},
+			},
+		},
+		{
+			name:     "lone escape",
+			input:    "\x1b",
+			expected: []terminal.Event{terminal.KeyEvent{Key: terminal.KeyEscape}},
+		},
+		{
+			name:  "SGR mouse press and release",
+			input: "\x1b[<0;12;7M\x1b[<0;12;7m",
+			expected: []terminal.Event{
+				terminal.MouseEvent{X: 11, Y: 6, Button: 0, Pressed: true},
+				terminal.MouseEvent{X: 11, Y: 6, Button: 0, Pressed: false},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := terminal.DecodeInput([]byte(tt.input)); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
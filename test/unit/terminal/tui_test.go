@@ -0,0 +1,147 @@
+package terminal_test
+
+import (
+	"strings"
+	"testing"
+
+	"calculator/internal/calculation"
+	"calculator/internal/terminal"
+)
+
+// typeKeys sends each rune of keys to the UI as a key press
+func typeKeys(ui *terminal.TerminalUI, keys string) {
+	for _, r := range keys {
+		ui.HandleKeyEvent(terminal.KeyEvent{Key: terminal.KeyRune, Rune: r})
+	}
+}
+
+// screenLines renders the UI at the given size and splits it into lines
+func screenLines(ui *terminal.TerminalUI, width, height int) []string {
+	return strings.Split(ui.Render(width, height), "\r\n")
+}
+
+func TestTerminalUI_KeyboardEvaluation(t *testing.T) {
+	ui := terminal.NewTerminalUI(calculation.NewCalculationEngine())
+	typeKeys(ui, "(2+3)*4")
+	ui.HandleKeyEvent(terminal.KeyEvent{Key: terminal.KeyEnter})
+
+	lines := screenLines(ui, 51, 24)
+	if !strings.Contains(lines[3], "(2+3)*4 = [H]") || !strings.Contains(lines[4], "20 [C]") {
+		t.Errorf("expected expression and result in the display, got:\n%s\n%s", lines[3], lines[4])
+	}
+	history := ui.History()
+	if len(history) != 1 || history[0].Expression != "(2+3)*4" || history[0].Result != 20 {
+		t.Fatalf("expected one history entry for (2+3)*4 = 20, got %+v", history)
+	}
+	if !strings.Contains(ui.Render(51, 24), "1. (2+3)*4 = 20") {
+		t.Error("expected history panel to list the calculation")
+	}
+
+	// An operator after a result continues from it
+	typeKeys(ui, "/8=")
+	if got := ui.History()[1]; got.Expression != "20/8" || got.Result != 2.5 {
+		t.Errorf("expected 20/8 = 2.5, got %+v", got)
+	}
+}
+
+func TestTerminalUI_ErrorsAndEditing(t *testing.T) {
+	ui := terminal.NewTerminalUI(calculation.NewCalculationEngine())
+	typeKeys(ui, "1/0=")
+	if !strings.Contains(ui.Render(51, 19), "division by zero") {
+		t.Error("expected division by zero error in the display")
+	}
+
+	typeKeys(ui, "c9")
+	ui.HandleKeyEvent(terminal.KeyEvent{Key: terminal.KeyBackspace})
+	typeKeys(ui, "7+s16)=")
+
+	history := ui.History()
+	if got := history[len(history)-1]; got.Expression != "7+sqrt(16)" || got.Result != 11 {
+		t.Errorf("expected 7+sqrt(16) = 11 after clear and backspace, got %+v", got)
+	}
+	if history[0].Error == "" {
+		t.Errorf("expected the failed calculation to be kept with its error, got %+v", history[0])
+	}
+}
+
+func TestTerminalUI_HistoryToggleAndShortcuts(t *testing.T) {
+	ui := terminal.NewTerminalUI(calculation.NewCalculationEngine())
+
+	if !strings.Contains(ui.Render(51, 24), "History:") {
+		t.Error("expected history panel to be shown initially")
+	}
+	typeKeys(ui, "h")
+	if strings.Contains(ui.Render(51, 24), "History:") {
+		t.Error("expected H to hide the history panel")
+	}
+
+	typeKeys(ui, "q")
+	if !ui.Done() {
+		t.Error("expected Q to exit")
+	}
+}
+
+func TestTerminalUI_MouseInput(t *testing.T) {
+	ui := terminal.NewTerminalUI(calculation.NewCalculationEngine())
+	ui.Render(71, 24) // frame centred with a 10-column margin
+
+	click := func(x, y int) {
+		ui.HandleMouseEvent(terminal.MouseEvent{X: x, Y: y, Button: 0, Pressed: true})
+	}
+	// Keypad columns start at margin+2 and are 8 cells apart; rows start at line 6 and are 3 lines tall
+	click(10+2+3, 6+1)       // 7
+	click(10+2+3*8+3, 6+1)   // +
+	click(10+2+1*8+3, 6+3+1) // 5
+	click(10+2+5*8+3, 6+9+1) // =
+
+	history := ui.History()
+	if len(history) != 1 || history[0].Expression != "7+5" || history[0].Result != 12 {
+		t.Fatalf("expected clicks to evaluate 7+5 = 12, got %+v", history)
+	}
+
+	click(10+47, 4) // [C]
+	if lines := screenLines(ui, 71, 24); !strings.Contains(lines[4], " 0 [C]") {
+		t.Errorf("expected [C] to clear the display, got %q", lines[4])
+	}
+	click(10+47, 3) // [H]
+	if strings.Contains(ui.Render(71, 24), "History:") {
+		t.Error("expected [H] to hide the history panel")
+	}
+}
+
+func TestTerminalUI_FocusNavigation(t *testing.T) {
+	ui := terminal.NewTerminalUI(calculation.NewCalculationEngine())
+
+	// From 7, move down twice to 1, then right to 2, and press both
+	ui.HandleKeyEvent(terminal.KeyEvent{Key: terminal.KeyDown})
+	ui.HandleKeyEvent(terminal.KeyEvent{Key: terminal.KeyDown})
+	typeKeys(ui, " ")
+	ui.HandleKeyEvent(terminal.KeyEvent{Key: terminal.KeyRight})
+	typeKeys(ui, " ")
+	// Three times up from 2 wraps past the top row to ".", and Shift+Tab steps back to 0
+	ui.HandleKeyEvent(terminal.KeyEvent{Key: terminal.KeyUp})
+	ui.HandleKeyEvent(terminal.KeyEvent{Key: terminal.KeyUp})
+	ui.HandleKeyEvent(terminal.KeyEvent{Key: terminal.KeyUp})
+	ui.HandleKeyEvent(terminal.KeyEvent{Key: terminal.KeyBacktab})
+	typeKeys(ui, " ")
+	ui.HandleKeyEvent(terminal.KeyEvent{Key: terminal.KeyEnter})
+
+	if history := ui.History(); len(history) != 1 || history[0].Expression != "120" {
+		t.Errorf("expected focus navigation to enter 120, got %+v", history)
+	}
+}
+
+func TestTerminalUI_Resize(t *testing.T) {
+	ui := terminal.NewTerminalUI(calculation.NewCalculationEngine())
+
+	if got := ui.Render(40, 10); !strings.Contains(got, "Terminal too small") {
+		t.Errorf("expected too-small message, got %q", got)
+	}
+	lines := screenLines(ui, 51, 19)
+	if len(lines) != 19 {
+		t.Errorf("expected a 19-line frame without room for history entries, got %d lines", len(lines))
+	}
+	if lines = screenLines(ui, 80, 30); !strings.HasPrefix(lines[0], strings.Repeat(" ", 14)+"┌") {
+		t.Errorf("expected frame centred in a wide terminal, got %q", lines[0])
+	}
+}